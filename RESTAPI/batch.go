@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+
+	database "goMicroService1Assignment/RESTAPI/database"
+)
+
+//maxBatchItems caps the number of courses accepted by a single batch request.
+const maxBatchItems = 5000
+
+//batch modes selected with the mode query parameter.
+const (
+	modeAtomic     = "atomic"
+	modeBestEffort = "besteffort"
+)
+
+//batchResult reports the outcome for one course of a batch, in the order it was received.
+type batchResult struct {
+	Index    int //position of the course in the batch or of the row in the CSV file, from 0
+	Line     int `json:",omitempty"` //line of the file a CSV row starts on
+	CourseID string
+	Status   int
+	Error    string `json:",omitempty"`
+}
+
+//batchResponse is the body returned by batchCourses.
+type batchResponse struct {
+	Mode      string
+	Committed bool
+	Succeeded int
+	Failed    int
+	Results   []batchResult
+	Error     string `json:",omitempty"`
+}
+
+//courseDecoder yields the courses of a request body one at a time.
+type courseDecoder interface {
+	Next() (database.Course, error) //io.EOF once the body is exhausted
+}
+
+//jsonArrayDecoder reads a JSON array of courses without holding the whole array in memory.
+type jsonArrayDecoder struct {
+	dec     *json.Decoder
+	started bool
+}
+
+func (d *jsonArrayDecoder) Next() (database.Course, error) {
+	var c database.Course
+	if !d.started {
+		d.started = true
+		tok, err := d.dec.Token()
+		if err != nil {
+			return c, err
+		}
+		if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+			return c, errors.New("expected a JSON array of courses")
+		}
+	}
+	if !d.dec.More() {
+		if _, err := d.dec.Token(); err != nil { //closing bracket
+			return c, err
+		}
+		return c, io.EOF
+	}
+	err := d.dec.Decode(&c)
+	return c, err
+}
+
+//ndjsonDecoder reads newline delimited JSON, one course object per line.
+type ndjsonDecoder struct {
+	dec *json.Decoder
+}
+
+func (d *ndjsonDecoder) Next() (database.Course, error) {
+	var c database.Course
+	err := d.dec.Decode(&c)
+	return c, err
+}
+
+//newCourseDecoder picks a decoder from the request Content-Type.
+func newCourseDecoder(r *http.Request) (courseDecoder, bool) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, false
+	}
+	switch mediaType {
+	case "application/json", "application/x-ndjson":
+		dec := json.NewDecoder(r.Body)
+		dec.DisallowUnknownFields()
+		if mediaType == "application/json" {
+			return &jsonArrayDecoder{dec: dec}, true
+		}
+		return &ndjsonDecoder{dec: dec}, true
+	}
+	return nil, false
+}
+
+//statusFor maps a per-course insert error onto the status reported in its batchResult.
+func statusFor(err error) int {
+	switch {
+	case errors.Is(err, database.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, database.ErrInvalid):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, database.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, database.ErrTimeout):
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+//batchCourses creates many courses from a JSON array or NDJSON stream.
+//mode=atomic (the default) commits all of them or none; mode=besteffort keeps whatever succeeded.
+func batchCourses(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = modeAtomic
+	}
+	if mode != modeAtomic && mode != modeBestEffort {
+		writeJSONError(w, r, http.StatusBadRequest, "400 - mode must be atomic or besteffort")
+		return
+	}
+
+	r.Body = limitBody(w, r.Body, maxBatchBodyBytes) //before the decoder takes hold of the body
+	decoder, ok := newCourseDecoder(r)
+	if !ok {
+		writeJSONError(w, r, http.StatusUnsupportedMediaType, "415 - Please supply courses as application/json or application/x-ndjson")
+		return
+	}
+
+	batch, err := database.BeginBatch(r.Context(), db, mode == modeAtomic)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+
+	response := batchResponse{Mode: mode, Results: []batchResult{}}
+	tooLarge := false
+	for index := 0; ; index++ {
+		course, err := decoder.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			tooLarge = isBodyTooLarge(r)
+			response.Error = "malformed course at index " + strconv.Itoa(index) + ": " + describeDecodeError(err)
+			batch.Fail()
+			break
+		}
+		if index >= maxBatchItems {
+			response.Error = "batch exceeds " + strconv.Itoa(maxBatchItems) + " courses"
+			batch.Fail()
+			break
+		}
+
+		result := batchResult{Index: index, CourseID: course.CourseID, Status: http.StatusCreated}
+		if err := validateCourse(&course); err != nil {
+			result.Status, result.Error = http.StatusUnprocessableEntity, err.Error()
+			batch.Fail()
+		} else if err := batch.Insert(r.Context(), course); err != nil {
+			result.Status, result.Error = statusFor(err), err.Error()
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	response.Committed, err = batch.Finish(r.Context())
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+
+	for i := range response.Results {
+		result := &response.Results[i]
+		if result.Error != "" {
+			response.Failed++
+		} else if !response.Committed { //inserted, but rolled back with the rest of the batch
+			result.Status, result.Error = http.StatusFailedDependency, "rolled back"
+			response.Failed++
+		} else {
+			response.Succeeded++
+		}
+	}
+
+	status := http.StatusCreated
+	switch {
+	case tooLarge:
+		status = http.StatusRequestEntityTooLarge
+	case response.Error != "" && response.Succeeded == 0:
+		status = http.StatusBadRequest
+	case !response.Committed:
+		status = http.StatusUnprocessableEntity
+	case response.Failed > 0 || response.Error != "":
+		status = http.StatusMultiStatus
+	}
+	if status != http.StatusCreated {
+		log.WithField("requestID", requestID(r)).Warningf("Batch insert finished with %d of %d courses failed", response.Failed, len(response.Results))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&response)
+}
+
+//exportCourses streams every course as NDJSON, one object per line, straight from the database cursor.
+func exportCourses(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	encoder := json.NewEncoder(w)
+	count := 0
+	err := database.EachRecord(r.Context(), db, func(course database.Course) error {
+		if err := encoder.Encode(&course); err != nil {
+			return err
+		}
+		if count++; flusher != nil && count%100 == 0 {
+			flusher.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if count == 0 { //nothing sent yet, so a proper error response is still possible
+			writeDBError(w, r, err)
+			return
+		}
+		log.WithField("requestID", requestID(r)).Error("Course export aborted: ", err)
+	}
+}
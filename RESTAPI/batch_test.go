@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+//batchCourse is the JSON of a course that passes validation, whether or not the database accepts it.
+func batchCourse(courseID, title, lecturerID string) string {
+	return `{"CourseID":"` + courseID + `","Title":"` + title + `","LecturerID":"` + lecturerID + `","ClassSize":30}`
+}
+
+//batchBody is the JSON array of a batch of courses.
+func batchBody(courses ...string) string {
+	return "[" + strings.Join(courses, ",") + "]"
+}
+
+//removeCourses deletes the courses a test creates, before it starts and once it is done.
+func removeCourses(t *testing.T, courseIDs ...string) {
+	t.Helper()
+	for _, id := range courseIDs {
+		if _, err := db.Exec("DELETE FROM Course WHERE CourseID=?", id); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+//storedTitles returns the title stored for each of the courses that exist.
+func storedTitles(t *testing.T, courseIDs ...string) map[string]string {
+	t.Helper()
+	titles := map[string]string{}
+	for _, id := range courseIDs {
+		var title string
+		err := db.QueryRow("SELECT Title FROM Course WHERE CourseID=?", id).Scan(&title)
+		if err == nil {
+			titles[id] = title
+		}
+	}
+	return titles
+}
+
+//TestAtomicBatchFailure sends an atomic batch whose middle course is refused by the database and checks
+//that the courses around it were not kept either.
+func TestAtomicBatchFailure(t *testing.T) {
+	needDB(t)
+	ids := []string{"GOS9991", "GOS9992", "GOS9993"}
+	removeCourses(t, ids...)
+	defer removeCourses(t, ids...)
+
+	//L9999 passes validation but no such lecturer exists
+	body := batchBody(batchCourse(ids[0], "Go Batch", "L0003"), batchCourse(ids[1], "Go Batch", "L9999"), batchCourse(ids[2], "Go Batch", "L0003"))
+	w := serve("POST", "/api/v1/courses:batch?key="+testKey, "application/json", body)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("batch answered %d, want %d: %s", w.Code, http.StatusUnprocessableEntity, w.Body.String())
+	}
+	var response batchResponse
+	if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.Committed || response.Succeeded != 0 || response.Failed != len(ids) {
+		t.Errorf("batch committed %v with %d succeeded and %d failed, want nothing kept", response.Committed, response.Succeeded, response.Failed)
+	}
+	if titles := storedTitles(t, ids...); len(titles) != 0 {
+		t.Errorf("an atomic batch that failed stored %v", titles)
+	}
+}
+
+//TestAtomicBatchDeadlock races two atomic batches inserting the same courses in opposite orders, which makes
+//InnoDB pick one as a deadlock victim. Each batch must be kept whole or not at all: the victim's inserts after
+//the deadlock must not commit outside its transaction.
+func TestAtomicBatchDeadlock(t *testing.T) {
+	needDB(t)
+	ids := []string{"GOS9994", "GOS9995"}
+	removeCourses(t, ids...)
+	defer removeCourses(t, ids...)
+
+	for round := 0; round < 5; round++ {
+		removeCourses(t, ids...)
+
+		titles := []string{"Go Batch First", "Go Batch Second"}
+		bodies := []string{
+			batchBody(batchCourse(ids[0], titles[0], "L0003"), batchCourse(ids[1], titles[0], "L0003")),
+			batchBody(batchCourse(ids[1], titles[1], "L0003"), batchCourse(ids[0], titles[1], "L0003")),
+		}
+		committed := make([]bool, len(bodies))
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		for i := range bodies {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				<-start
+				w := serve("POST", "/api/v1/courses:batch?key="+testKey, "application/json", bodies[i])
+				var response batchResponse
+				if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+					t.Error(err)
+				}
+				committed[i] = response.Committed
+			}(i)
+		}
+		close(start)
+		wg.Wait()
+
+		stored := storedTitles(t, ids...)
+		switch {
+		case committed[0] && committed[1]:
+			t.Errorf("round %d: both batches committed the same courses", round)
+		case committed[0] || committed[1]:
+			winner := titles[0]
+			if committed[1] {
+				winner = titles[1]
+			}
+			if len(stored) != len(ids) || stored[ids[0]] != winner || stored[ids[1]] != winner {
+				t.Errorf("round %d: stored %v, want every course titled %q", round, stored, winner)
+			}
+		case len(stored) != 0:
+			t.Errorf("round %d: neither batch committed but %v was stored", round, stored)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+//Breaker is a circuit breaker shared by the requests of a Client. After Threshold consecutive server
+//failures it opens and requests fail at once with ErrCircuitOpen. Once Cooldown has passed a single
+//trial request is let through: success closes the circuit, another failure opens it for a new Cooldown.
+type Breaker struct {
+	Threshold int
+	Cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int       //consecutive server failures
+	openUntil time.Time //end of the current cooldown
+	trial     bool      //a trial request is in flight
+}
+
+//NewBreaker returns a closed Breaker.
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{Threshold: threshold, Cooldown: cooldown}
+}
+
+//Open reports whether requests are currently refused.
+func (b *Breaker) Open() bool {
+	_, err := b.allowAt(time.Now(), false)
+	return err != nil
+}
+
+//allow reports whether a request may be sent, returning ErrCircuitOpen if not, and whether the request is the
+//trial of a half-open circuit. A nil Breaker allows everything.
+func (b *Breaker) allow() (trial bool, err error) {
+	return b.allowAt(time.Now(), true)
+}
+
+func (b *Breaker) allowAt(now time.Time, take bool) (trial bool, err error) {
+	if b == nil {
+		return false, nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.Threshold <= 0 || b.failures < b.Threshold {
+		return false, nil
+	}
+	if now.Before(b.openUntil) {
+		wait := (b.openUntil.Sub(now) + time.Second - 1).Truncate(time.Second) //rounded up, never "0s"
+		return false, fmt.Errorf("%w, retrying in %s", ErrCircuitOpen, wait)
+	}
+	if b.trial {
+		return false, fmt.Errorf("%w, waiting for a trial request", ErrCircuitOpen)
+	}
+	b.trial = take
+	return take, nil
+}
+
+//record updates the Breaker with the outcome of a request it allowed, trial telling whether allow let it
+//through as the trial request.
+func (b *Breaker) record(trial bool, err error) {
+	b.recordAt(time.Now(), trial, err)
+}
+
+func (b *Breaker) recordAt(now time.Time, trial bool, err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if trial {
+		b.trial = false
+	} else if b.Threshold > 0 && b.failures >= b.Threshold {
+		return //sent before the circuit opened, only the trial decides when it closes
+	}
+	if !serverFailure(err) {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.Threshold {
+		b.openUntil = now.Add(b.Cooldown)
+	}
+}
@@ -0,0 +1,135 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var (
+	errServer   = &APIError{StatusCode: http.StatusServiceUnavailable}
+	errRejected = &APIError{StatusCode: http.StatusUnprocessableEntity}
+)
+
+//allowed fails the test unless b lets a request through at now, and reports whether it is the trial.
+func allowed(t *testing.T, b *Breaker, now time.Time) bool {
+	t.Helper()
+	trial, err := b.allowAt(now, true)
+	if err != nil {
+		t.Fatalf("request refused: %v", err)
+	}
+	return trial
+}
+
+//refused fails the test unless b refuses a request at now with ErrCircuitOpen.
+func refused(t *testing.T, b *Breaker, now time.Time) {
+	t.Helper()
+	trial, err := b.allowAt(now, true)
+	if !errors.Is(err, ErrCircuitOpen) || !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("request allowed (trial %v), want ErrCircuitOpen", trial)
+	}
+}
+
+//tripped returns a Breaker opened by threshold server failures at now.
+func tripped(t *testing.T, threshold int, cooldown time.Duration, now time.Time) *Breaker {
+	t.Helper()
+	b := NewBreaker(threshold, cooldown)
+	for i := 0; i < threshold; i++ {
+		allowed(t, b, now)
+		b.recordAt(now, false, errServer)
+	}
+	return b
+}
+
+func TestBreakerOpens(t *testing.T) {
+	now := time.Now()
+	b := NewBreaker(3, time.Minute)
+	for i := 0; i < 2; i++ {
+		if allowed(t, b, now) {
+			t.Fatal("a closed breaker sent a trial request")
+		}
+		b.recordAt(now, false, errServer)
+	}
+	allowed(t, b, now)
+	b.recordAt(now, false, errRejected) //the server answered, so it is up
+	for i := 0; i < 2; i++ {
+		allowed(t, b, now)
+		b.recordAt(now, false, errServer)
+	}
+	if b.Open() {
+		t.Fatal("breaker opened after 2 consecutive failures, threshold is 3")
+	}
+	allowed(t, b, now)
+	b.recordAt(now, false, errServer)
+	refused(t, b, now)
+	refused(t, b, now.Add(time.Minute-time.Millisecond))
+}
+
+func TestBreakerTrialCloses(t *testing.T) {
+	now := time.Now()
+	b := tripped(t, 2, time.Minute, now)
+	later := now.Add(time.Minute)
+
+	if _, err := b.allowAt(later, false); err != nil {
+		t.Fatalf("breaker still open after the cooldown: %v", err)
+	}
+	if !allowed(t, b, later) {
+		t.Fatal("the first request after the cooldown is not the trial")
+	}
+	refused(t, b, later) //only one trial at a time
+	b.recordAt(later, true, nil)
+	if allowed(t, b, later) {
+		t.Fatal("a closed breaker sent a trial request")
+	}
+}
+
+func TestBreakerTrialReopens(t *testing.T) {
+	now := time.Now()
+	b := tripped(t, 2, time.Minute, now)
+	later := now.Add(time.Minute)
+
+	allowed(t, b, later)
+	b.recordAt(later, true, errServer)
+	refused(t, b, later.Add(time.Minute-time.Millisecond))
+	if !allowed(t, b, later.Add(time.Minute)) {
+		t.Fatal("no trial after the second cooldown")
+	}
+}
+
+//TestBreakerLateOutcome checks that requests sent before the breaker opened cannot close it, nor end the trial.
+func TestBreakerLateOutcome(t *testing.T) {
+	now := time.Now()
+	b := NewBreaker(2, time.Minute)
+	allowed(t, b, now) //slow request, still in flight when the breaker opens
+	for i := 0; i < 2; i++ {
+		allowed(t, b, now)
+		b.recordAt(now, false, errServer)
+	}
+
+	b.recordAt(now, false, nil)
+	refused(t, b, now)
+
+	later := now.Add(time.Minute)
+	if !allowed(t, b, later) {
+		t.Fatal("the first request after the cooldown is not the trial")
+	}
+	b.recordAt(later, false, nil)
+	refused(t, b, later) //the trial is still in flight
+	b.recordAt(later, true, errServer)
+	refused(t, b, later)
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	var b *Breaker
+	if trial, err := b.allow(); trial || err != nil {
+		t.Errorf("nil breaker: trial %v, error %v, want a plain request", trial, err)
+	}
+	b.record(false, errServer)
+
+	b = NewBreaker(0, time.Minute)
+	for i := 0; i < 10; i++ {
+		allowed(t, b, time.Now())
+		b.record(false, errServer)
+	}
+}
@@ -0,0 +1,236 @@
+//Package client is a Go client for the course listing REST API.
+//
+//	api := client.New("https://localhost:5000", apiKey, httpClient)
+//	course, err := api.GetCourse(ctx, "GOS1000")
+//	if errors.Is(err, client.ErrNotFound) { ... }
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+//Course mirrors the course resource of the API. The timestamps are set by the server and ignored when writing.
+type Course struct {
+	CourseID    string    `json:"CourseID" yaml:"CourseID"`
+	Title       string    `json:"Title" yaml:"Title"`
+	LecturerID  string    `json:"LecturerID,omitempty" yaml:"LecturerID,omitempty"`
+	Lecturer    string    `json:"Lecturer" yaml:"Lecturer"` //the lecturer's name; when creating, it must name an existing lecturer unless LecturerID is set
+	ClassSize   int       `json:"ClassSize" yaml:"ClassSize"`
+	Description string    `json:"Description" yaml:"Description"`
+	Credits     int       `json:"Credits" yaml:"Credits"`
+	Status      string    `json:"Status,omitempty" yaml:"Status"` //draft, published or archived; left out, a course keeps its status or starts as draft
+	CreatedAt   time.Time `json:"CreatedAt" yaml:"CreatedAt"`
+	UpdatedAt   time.Time `json:"UpdatedAt" yaml:"UpdatedAt"`
+}
+
+//CoursePatch holds the fields to change with PatchCourse. Nil fields are left as they are.
+type CoursePatch struct {
+	Title       *string `json:",omitempty"`
+	LecturerID  *string `json:",omitempty"`
+	Lecturer    *string `json:",omitempty"` //name of an existing lecturer, ignored if LecturerID is set
+	ClassSize   *int    `json:",omitempty"`
+	Description *string `json:",omitempty"`
+	Credits     *int    `json:",omitempty"`
+	Status      *string `json:",omitempty"`
+}
+
+//Client calls the course API. The zero value is not usable, create one with New.
+type Client struct {
+	BaseURL      string        //scheme and host of the server, e.g. https://localhost:5000
+	APIKey       string        //sent as the key query parameter
+	HTTPClient   *http.Client  //carries the TLS configuration trusting the server certificate
+	Timeout      time.Duration //limit on each attempt, zero for none
+	Retries      int           //extra attempts for idempotent requests that time out, lose the connection or get a 429 or 5xx status
+	RetryWait    time.Duration //wait before the first retry, doubled for each one after
+	MaxRetryWait time.Duration //cap on the wait between retries, including one asked for by Retry-After
+	Breaker      *Breaker      //stops calling a server that keeps failing, nil to always call
+}
+
+//New returns a Client for the server at baseURL. A nil httpClient uses http.DefaultClient.
+func New(baseURL, apiKey string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		BaseURL:      strings.TrimRight(baseURL, "/"),
+		APIKey:       apiKey,
+		HTTPClient:   httpClient,
+		Timeout:      10 * time.Second,
+		Retries:      2,
+		RetryWait:    500 * time.Millisecond,
+		MaxRetryWait: 10 * time.Second,
+		Breaker:      NewBreaker(5, 30*time.Second),
+	}
+}
+
+const coursesPath = "/api/v1/courses"
+
+//ListCourses returns every course.
+func (c *Client) ListCourses(ctx context.Context) ([]Course, error) {
+	var courses []Course
+	err := c.do(ctx, http.MethodGet, coursesPath, nil, &courses)
+	return courses, err
+}
+
+//GetCourse returns one course. The error matches ErrNotFound if there is no such course.
+func (c *Client) GetCourse(ctx context.Context, courseID string) (Course, error) {
+	var course Course
+	err := c.do(ctx, http.MethodGet, coursePath(courseID), nil, &course)
+	return course, err
+}
+
+//CreateCourse adds a new course. The error matches ErrConflict if the course ID is taken, and ErrInvalid if
+//no department owns its prefix or the lecturer belongs to another department.
+func (c *Client) CreateCourse(ctx context.Context, course Course) error {
+	return c.do(ctx, http.MethodPost, coursePath(course.CourseID), course, nil)
+}
+
+//UpdateCourse creates or replaces a course, reporting whether it was created.
+func (c *Client) UpdateCourse(ctx context.Context, course Course) (created bool, err error) {
+	status, err := c.send(ctx, http.MethodPut, coursePath(course.CourseID), course, nil)
+	return status == http.StatusCreated, err
+}
+
+//PatchCourse changes only the fields set in patch and returns the updated course.
+func (c *Client) PatchCourse(ctx context.Context, courseID string, patch CoursePatch) (Course, error) {
+	var course Course
+	err := c.do(ctx, http.MethodPatch, coursePath(courseID), patch, &course)
+	return course, err
+}
+
+//DeleteCourse removes a course. The error matches ErrNotFound if there is no such course,
+//and ErrConflict if other courses require it, students are enrolled or waitlisted, or it is offered in a semester.
+func (c *Client) DeleteCourse(ctx context.Context, courseID string) error {
+	return c.do(ctx, http.MethodDelete, coursePath(courseID), nil, nil)
+}
+
+//ForceDeleteCourse removes a course even if other courses require it, taking it out of their prerequisites.
+func (c *Client) ForceDeleteCourse(ctx context.Context, courseID string) error {
+	return c.do(ctx, http.MethodDelete, coursePath(courseID)+"?force=true", nil, nil)
+}
+
+func coursePath(courseID string) string {
+	return coursesPath + "/" + url.PathEscape(courseID)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	_, err := c.send(ctx, method, path, body, out)
+	return err
+}
+
+//idempotent methods may be retried safely.
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+//send performs the request, retrying idempotent methods, and decodes a JSON response into out.
+func (c *Client) send(ctx context.Context, method, path string, body, out interface{}) (int, error) {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return 0, err
+		}
+	}
+
+	attempts := 1
+	if idempotent(method) {
+		attempts += c.Retries
+	}
+	var status int
+	for attempt := 1; ; attempt++ {
+		trial, err := c.Breaker.allow()
+		if err != nil {
+			return status, err
+		}
+		status, err = c.sendOnce(ctx, method, path, payload, out)
+		c.Breaker.record(trial, err)
+		if attempt >= attempts || ctx.Err() != nil || !retryable(err) {
+			return status, err
+		}
+		select {
+		case <-ctx.Done():
+			return status, ctx.Err()
+		case <-time.After(c.backoff(attempt, err)):
+		}
+	}
+}
+
+//backoff is the wait before retrying after the given failed attempt: RetryWait doubled for each earlier retry,
+//or the Retry-After of the response if longer, capped at MaxRetryWait. The doubled wait is jittered between
+//half and all of its value so that clients failing together do not retry together.
+func (c *Client) backoff(attempt int, err error) time.Duration {
+	wait := c.RetryWait
+	for i := 1; i < attempt && (c.MaxRetryWait <= 0 || wait < c.MaxRetryWait); i++ {
+		wait *= 2
+	}
+	if c.MaxRetryWait > 0 && wait > c.MaxRetryWait {
+		wait = c.MaxRetryWait
+	}
+	if wait > 0 {
+		wait = wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > wait {
+		wait = apiErr.RetryAfter
+		if c.MaxRetryWait > 0 && wait > c.MaxRetryWait {
+			wait = c.MaxRetryWait
+		}
+	}
+	return wait
+}
+
+func (c *Client) sendOnce(ctx context.Context, method, path string, payload []byte, out interface{}) (int, error) {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	separator := "?" //path may carry its own query, e.g. ?force=true
+	if strings.Contains(path, "?") {
+		separator = "&"
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path+separator+"key="+url.QueryEscape(c.APIKey), body)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode, newAPIError(resp)
+	}
+	if out == nil {
+		io.Copy(ioutil.Discard, resp.Body)
+		return resp.StatusCode, nil
+	}
+	return resp.StatusCode, json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+//Department mirrors the department resource of the API. Prefixes are the first three letters of the
+//Course IDs it owns.
+type Department struct {
+	DepartmentID string   `yaml:"DepartmentID"`
+	Name         string   `yaml:"Name"`
+	Prefixes     []string `yaml:"Prefixes"`
+}
+
+//DepartmentStats mirrors the statistics of a department.
+type DepartmentStats struct {
+	DepartmentID string `yaml:"DepartmentID"`
+	Courses      int    `yaml:"Courses"`
+	Lecturers    int    `yaml:"Lecturers"`
+	Seats        int    `yaml:"Seats"`
+	Enrolled     int    `yaml:"Enrolled"`
+	Waitlisted   int    `yaml:"Waitlisted"`
+}
+
+const departmentsPath = "/api/v1/departments"
+
+//ListDepartments returns every department with its prefixes.
+func (c *Client) ListDepartments(ctx context.Context) ([]Department, error) {
+	var departments []Department
+	err := c.do(ctx, http.MethodGet, departmentsPath, nil, &departments)
+	return departments, err
+}
+
+//GetDepartment returns one department. The error matches ErrNotFound if there is no such department.
+func (c *Client) GetDepartment(ctx context.Context, departmentID string) (Department, error) {
+	var department Department
+	err := c.do(ctx, http.MethodGet, departmentPath(departmentID), nil, &department)
+	return department, err
+}
+
+//CreateDepartment adds a new department. The error matches ErrConflict if the department ID or name is taken
+//or another department owns one of the prefixes.
+func (c *Client) CreateDepartment(ctx context.Context, department Department) error {
+	return c.do(ctx, http.MethodPost, departmentPath(department.DepartmentID), department, nil)
+}
+
+//UpdateDepartment replaces the name and prefixes of a department. The error matches ErrNotFound if there is
+//no such department and ErrConflict if a new prefix is taken or a removed one is still used by courses.
+func (c *Client) UpdateDepartment(ctx context.Context, department Department) error {
+	return c.do(ctx, http.MethodPut, departmentPath(department.DepartmentID), department, nil)
+}
+
+//DeleteDepartment removes a department. The error matches ErrConflict while courses use its prefixes
+//or lecturers belong to it.
+func (c *Client) DeleteDepartment(ctx context.Context, departmentID string) error {
+	return c.do(ctx, http.MethodDelete, departmentPath(departmentID), nil, nil)
+}
+
+//DepartmentCourses returns the courses whose prefix a department owns.
+func (c *Client) DepartmentCourses(ctx context.Context, departmentID string) ([]Course, error) {
+	var courses []Course
+	err := c.do(ctx, http.MethodGet, departmentPath(departmentID)+"/courses", nil, &courses)
+	return courses, err
+}
+
+//DepartmentLecturers returns the lecturers belonging to a department.
+func (c *Client) DepartmentLecturers(ctx context.Context, departmentID string) ([]Lecturer, error) {
+	var lecturers []Lecturer
+	err := c.do(ctx, http.MethodGet, departmentPath(departmentID)+"/lecturers", nil, &lecturers)
+	return lecturers, err
+}
+
+//DepartmentStats returns the statistics of a department.
+func (c *Client) DepartmentStats(ctx context.Context, departmentID string) (DepartmentStats, error) {
+	var stats DepartmentStats
+	err := c.do(ctx, http.MethodGet, departmentPath(departmentID)+"/stats", nil, &stats)
+	return stats, err
+}
+
+func departmentPath(departmentID string) string {
+	return departmentsPath + "/" + url.PathEscape(departmentID)
+}
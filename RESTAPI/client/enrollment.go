@@ -0,0 +1,110 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+//Student mirrors the student resource of the API.
+type Student struct {
+	StudentID string `yaml:"StudentID"`
+	Name      string `yaml:"Name"`
+	Email     string `yaml:"Email"`
+}
+
+//Status of an Enrollment.
+const (
+	StatusEnrolled   = "enrolled"
+	StatusWaitlisted = "waitlisted"
+)
+
+//Enrollment is a seat held by a student in a course, or a place on its waitlist.
+//Position is the place on the waitlist starting at 1, and zero for enrolled students.
+type Enrollment struct {
+	CourseID   string    `yaml:"CourseID"`
+	StudentID  string    `yaml:"StudentID"`
+	Status     string    `yaml:"Status"`
+	EnrolledAt time.Time `yaml:"EnrolledAt"`
+	Position   int       `json:",omitempty" yaml:"Position,omitempty"`
+}
+
+//CourseEnrollments is the seat count of a course with its enrolments and waitlist.
+type CourseEnrollments struct {
+	CourseID    string       `yaml:"CourseID"`
+	ClassSize   int          `yaml:"ClassSize"`
+	Enrolled    int          `yaml:"Enrolled"`
+	SeatsLeft   int          `yaml:"SeatsLeft"`
+	Waitlisted  int          `yaml:"Waitlisted"`
+	Enrollments []Enrollment `yaml:"Enrollments"`
+	Waitlist    []Enrollment `yaml:"Waitlist"`
+}
+
+const studentsPath = "/api/v1/students"
+
+//ListStudents returns every student.
+func (c *Client) ListStudents(ctx context.Context) ([]Student, error) {
+	var students []Student
+	err := c.do(ctx, http.MethodGet, studentsPath, nil, &students)
+	return students, err
+}
+
+//GetStudent returns one student. The error matches ErrNotFound if there is no such student.
+func (c *Client) GetStudent(ctx context.Context, studentID string) (Student, error) {
+	var student Student
+	err := c.do(ctx, http.MethodGet, studentPath(studentID), nil, &student)
+	return student, err
+}
+
+//CreateStudent adds a new student. The error matches ErrConflict if the student ID is taken.
+func (c *Client) CreateStudent(ctx context.Context, student Student) error {
+	return c.do(ctx, http.MethodPost, studentPath(student.StudentID), student, nil)
+}
+
+//DeleteStudent removes a student. The error matches ErrConflict while the student is still enrolled.
+func (c *Client) DeleteStudent(ctx context.Context, studentID string) error {
+	return c.do(ctx, http.MethodDelete, studentPath(studentID), nil, nil)
+}
+
+//ListEnrollments returns the seat count of a course, the students enrolled in it and its waitlist.
+func (c *Client) ListEnrollments(ctx context.Context, courseID string) (CourseEnrollments, error) {
+	var list CourseEnrollments
+	err := c.do(ctx, http.MethodGet, enrollmentsPath(courseID), nil, &list)
+	return list, err
+}
+
+//Enroll gives the student a seat in the course, or a place on its waitlist when it is full, which the
+//Status of the result tells apart. The error matches ErrConflict if the student is already enrolled or
+//waitlisted, and ErrNotFound if the course or student does not exist.
+func (c *Client) Enroll(ctx context.Context, courseID, studentID string) (Enrollment, error) {
+	var enrollment Enrollment
+	err := c.do(ctx, http.MethodPost, enrollmentsPath(courseID), map[string]string{"StudentID": studentID}, &enrollment)
+	return enrollment, err
+}
+
+//GetEnrollment returns the seat or waitlist position of the student in the course.
+//The error matches ErrNotFound if the student is neither enrolled nor waitlisted.
+func (c *Client) GetEnrollment(ctx context.Context, courseID, studentID string) (Enrollment, error) {
+	var enrollment Enrollment
+	err := c.do(ctx, http.MethodGet, enrollmentPath(courseID, studentID), nil, &enrollment)
+	return enrollment, err
+}
+
+//Unenroll gives up the student's seat in the course, or takes the student off its waitlist.
+//The error matches ErrNotFound if the student was neither enrolled nor waitlisted.
+func (c *Client) Unenroll(ctx context.Context, courseID, studentID string) error {
+	return c.do(ctx, http.MethodDelete, enrollmentPath(courseID, studentID), nil, nil)
+}
+
+func studentPath(studentID string) string {
+	return studentsPath + "/" + url.PathEscape(studentID)
+}
+
+func enrollmentsPath(courseID string) string {
+	return coursePath(courseID) + "/enrollments"
+}
+
+func enrollmentPath(courseID, studentID string) string {
+	return enrollmentsPath(courseID) + "/" + url.PathEscape(studentID)
+}
@@ -0,0 +1,154 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+//Kinds of failure an *APIError can match with errors.Is.
+var (
+	ErrUnauthorized = errors.New("missing or invalid API key")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflicts with an existing course")
+	ErrInvalid      = errors.New("request rejected as invalid")
+	ErrUnavailable  = errors.New("service unavailable")
+	//ErrCircuitOpen is returned without calling the server while the Breaker is open. It matches ErrUnavailable.
+	ErrCircuitOpen = fmt.Errorf("%w: too many failures, requests are paused", ErrUnavailable)
+)
+
+//APIError is returned for every response with a status of 300 or above.
+type APIError struct {
+	StatusCode int
+	Message    string        //the server's message, e.g. "404 - No course found"
+	RequestID  string        //quote this when reporting a server problem
+	RetryAfter time.Duration //how long the server asked us to wait, from the Retry-After header of a 429 or 503
+}
+
+func (e *APIError) Error() string {
+	msg := "course api: " + e.Message
+	if e.Message == "" {
+		msg = "course api: " + strconv.Itoa(e.StatusCode) + " " + http.StatusText(e.StatusCode)
+	}
+	if e.RequestID != "" {
+		msg += " (request " + e.RequestID + ")"
+	}
+	return msg
+}
+
+//Is matches the error against the sentinel errors of this package.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		//the server answers a bad key with 404 and a "401 - ..." message
+		return e.StatusCode == http.StatusUnauthorized || strings.HasPrefix(e.Message, "401")
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound && !strings.HasPrefix(e.Message, "401")
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrInvalid:
+		switch e.StatusCode {
+		case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnsupportedMediaType, http.StatusUnprocessableEntity:
+			return true
+		}
+	case ErrUnavailable:
+		return e.StatusCode >= http.StatusInternalServerError || e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+//newAPIError builds an APIError from a failed response, reading the JSON error body or plain text message.
+func newAPIError(resp *http.Response) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Request-ID"),
+		RetryAfter: retryAfter(resp.Header.Get("Retry-After")),
+	}
+	data, _ := ioutil.ReadAll(resp.Body)
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	var body struct {
+		Message   string
+		RequestID string
+	}
+	if mediaType == "application/json" && json.Unmarshal(data, &body) == nil && body.Message != "" {
+		apiErr.Message = body.Message
+		if body.RequestID != "" {
+			apiErr.RequestID = body.RequestID
+		}
+	} else {
+		apiErr.Message = strings.TrimSpace(string(data))
+	}
+	return apiErr
+}
+
+//retryAfter parses a Retry-After header given either in seconds or as an HTTP date.
+func retryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		if wait := time.Until(at); wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
+
+//retryable reports whether a failed attempt may succeed if repeated: 429 and 5xx responses, and transport
+//failures that are timeouts, temporary or the connection being reset. Other transport failures, such as a
+//certificate the server cannot be verified with or a malformed URL, fail the same way every time. The caller
+//checks its own context, which ends the retries when done.
+func retryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= http.StatusInternalServerError || apiErr.StatusCode == http.StatusTooManyRequests
+	}
+	var urlErr *url.Error //returned by http.Client for transport failures
+	if !errors.As(err, &urlErr) {
+		return false
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	var temporary interface{ Temporary() bool }
+	if errors.As(err, &temporary) && temporary.Temporary() {
+		return true
+	}
+	//a keep-alive connection the server closed shows up as an unexpected end of the response
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+//serverFailure reports whether err suggests the server is down or broken, as opposed to busy (429)
+//or rejecting the request. Only such failures count towards opening the Breaker. A refused connection
+//is not worth retrying at once, but it is the surest sign of a server that is down.
+func serverFailure(err error) bool {
+	if errors.Is(err, context.Canceled) || isStatus(err, http.StatusTooManyRequests) {
+		return false
+	}
+	var urlErr *url.Error
+	return retryable(err) || (errors.As(err, &urlErr) && errors.Is(err, syscall.ECONNREFUSED))
+}
+
+func isStatus(err error, status int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == status
+}
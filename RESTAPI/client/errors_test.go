@@ -0,0 +1,109 @@
+package client
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+//transport wraps err the way http.Client reports a failed request.
+func transport(err error) error {
+	return &url.Error{Op: "Get", URL: "https://localhost:5000/api/v1/courses", Err: err}
+}
+
+//connection wraps a system call error the way the net package reports it.
+func connection(op string, errno syscall.Errno) error {
+	return transport(&net.OpError{Op: op, Net: "tcp", Err: os.NewSyscallError(op, errno)})
+}
+
+func TestRetryable(t *testing.T) {
+	cases := []struct {
+		name      string
+		err       error
+		retryable bool
+		failure   bool //counts towards opening the breaker
+	}{
+		{"success", nil, false, false},
+		{"503", &APIError{StatusCode: http.StatusServiceUnavailable}, true, true},
+		{"500", &APIError{StatusCode: http.StatusInternalServerError}, true, true},
+		{"429", &APIError{StatusCode: http.StatusTooManyRequests}, true, false},
+		{"404", &APIError{StatusCode: http.StatusNotFound}, false, false},
+		{"422", &APIError{StatusCode: http.StatusUnprocessableEntity}, false, false},
+		{"attempt timed out", transport(context.DeadlineExceeded), true, true},
+		{"connection reset", connection("read", syscall.ECONNRESET), true, true},
+		{"connection closed", transport(io.EOF), true, true},
+		{"connection refused", connection("dial", syscall.ECONNREFUSED), false, true},
+		{"unknown certificate authority", transport(x509.UnknownAuthorityError{}), false, false},
+		{"certificate for another host", transport(x509.HostnameError{Certificate: &x509.Certificate{}, Host: "localhost"}), false, false},
+		{"bad URL", transport(errors.New("unsupported protocol scheme \"htp\"")), false, false},
+		{"cancelled", transport(context.Canceled), false, false},
+		{"truncated JSON", io.ErrUnexpectedEOF, false, false},
+	}
+	for _, c := range cases {
+		if got := retryable(c.err); got != c.retryable {
+			t.Errorf("%s: retryable = %v, want %v", c.name, got, c.retryable)
+		}
+		if got := serverFailure(c.err); got != c.failure {
+			t.Errorf("%s: serverFailure = %v, want %v", c.name, got, c.failure)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	cases := []struct {
+		header   string
+		min, max time.Duration
+	}{
+		{"", 0, 0},
+		{"120", 120 * time.Second, 120 * time.Second},
+		{"0", 0, 0},
+		{"-5", 0, 0},
+		{"soon", 0, 0},
+		{time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), 58 * time.Second, time.Minute},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0, 0},
+	}
+	for _, c := range cases {
+		if got := retryAfter(c.header); got < c.min || got > c.max {
+			t.Errorf("retryAfter(%q) = %s, want between %s and %s", c.header, got, c.min, c.max)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	c := &Client{RetryWait: 100 * time.Millisecond, MaxRetryWait: time.Second}
+	cases := []struct {
+		name     string
+		attempt  int
+		err      error
+		min, max time.Duration
+	}{
+		{"first retry", 1, &APIError{StatusCode: http.StatusServiceUnavailable}, 50 * time.Millisecond, 100 * time.Millisecond},
+		{"third retry", 3, &APIError{StatusCode: http.StatusServiceUnavailable}, 200 * time.Millisecond, 400 * time.Millisecond},
+		{"capped", 20, &APIError{StatusCode: http.StatusServiceUnavailable}, 500 * time.Millisecond, time.Second},
+		{"longer Retry-After", 1, &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: 700 * time.Millisecond}, 700 * time.Millisecond, 700 * time.Millisecond},
+		{"shorter Retry-After", 3, &APIError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Millisecond}, 200 * time.Millisecond, 400 * time.Millisecond},
+		{"Retry-After over the cap", 1, &APIError{StatusCode: http.StatusServiceUnavailable, RetryAfter: time.Hour}, time.Second, time.Second},
+		{"transport failure", 2, connection("read", syscall.ECONNRESET), 100 * time.Millisecond, 200 * time.Millisecond},
+	}
+	for _, c2 := range cases {
+		for i := 0; i < 20; i++ { //the wait is jittered
+			if got := c.backoff(c2.attempt, c2.err); got < c2.min || got > c2.max {
+				t.Errorf("%s: backoff = %s, want between %s and %s", c2.name, got, c2.min, c2.max)
+				break
+			}
+		}
+	}
+
+	uncapped := &Client{RetryWait: 100 * time.Millisecond}
+	if got := uncapped.backoff(1, &APIError{StatusCode: http.StatusServiceUnavailable, RetryAfter: time.Hour}); got != time.Hour {
+		t.Errorf("without MaxRetryWait: backoff = %s, want the full Retry-After of 1h", got)
+	}
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+//Lecturer mirrors the lecturer resource of the API. The department may be given by DepartmentID or by name.
+type Lecturer struct {
+	LecturerID   string `yaml:"LecturerID"`
+	Name         string `yaml:"Name"`
+	Email        string `yaml:"Email"`
+	DepartmentID string `json:",omitempty" yaml:"DepartmentID,omitempty"`
+	Department   string `yaml:"Department"`
+}
+
+const lecturersPath = "/api/v1/lecturers"
+
+//ListLecturers returns every lecturer.
+func (c *Client) ListLecturers(ctx context.Context) ([]Lecturer, error) {
+	var lecturers []Lecturer
+	err := c.do(ctx, http.MethodGet, lecturersPath, nil, &lecturers)
+	return lecturers, err
+}
+
+//GetLecturer returns one lecturer. The error matches ErrNotFound if there is no such lecturer.
+func (c *Client) GetLecturer(ctx context.Context, lecturerID string) (Lecturer, error) {
+	var lecturer Lecturer
+	err := c.do(ctx, http.MethodGet, lecturerPath(lecturerID), nil, &lecturer)
+	return lecturer, err
+}
+
+//CreateLecturer adds a new lecturer. The error matches ErrConflict if the lecturer ID or name is taken.
+func (c *Client) CreateLecturer(ctx context.Context, lecturer Lecturer) error {
+	return c.do(ctx, http.MethodPost, lecturerPath(lecturer.LecturerID), lecturer, nil)
+}
+
+//UpdateLecturer replaces the name, email and department of a lecturer. Its courses show the new name at once.
+//The error matches ErrNotFound if there is no such lecturer, ErrConflict if another lecturer has the name
+//or if the lecturer teaches a course of another department.
+func (c *Client) UpdateLecturer(ctx context.Context, lecturer Lecturer) error {
+	return c.do(ctx, http.MethodPut, lecturerPath(lecturer.LecturerID), lecturer, nil)
+}
+
+//DeleteLecturer removes a lecturer. The error matches ErrConflict while the lecturer still teaches courses.
+func (c *Client) DeleteLecturer(ctx context.Context, lecturerID string) error {
+	return c.do(ctx, http.MethodDelete, lecturerPath(lecturerID), nil, nil)
+}
+
+//LecturerCourses returns the courses taught by a lecturer.
+func (c *Client) LecturerCourses(ctx context.Context, lecturerID string) ([]Course, error) {
+	var courses []Course
+	err := c.do(ctx, http.MethodGet, lecturerPath(lecturerID)+"/courses", nil, &courses)
+	return courses, err
+}
+
+func lecturerPath(lecturerID string) string {
+	return lecturersPath + "/" + url.PathEscape(lecturerID)
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+//Semester mirrors the semester resource of the API. Dates are written as 2006-01-02.
+type Semester struct {
+	SemesterID string `yaml:"SemesterID"`
+	Name       string `yaml:"Name"`
+	StartDate  string `yaml:"StartDate"`
+	EndDate    string `yaml:"EndDate"`
+}
+
+//CourseOffering mirrors a course running in one semester with its own lecturer and class size.
+type CourseOffering struct {
+	CourseID   string `yaml:"CourseID"`
+	SemesterID string `yaml:"SemesterID"`
+	LecturerID string `json:",omitempty" yaml:"LecturerID,omitempty"`
+	Lecturer   string `yaml:"Lecturer"`
+	ClassSize  int    `yaml:"ClassSize"`
+}
+
+//RollForward reports the courses copied into semester To, and those skipped because To already offered them.
+type RollForward struct {
+	From    string   `yaml:"From"`
+	To      string   `yaml:"To"`
+	Copied  []string `yaml:"Copied"`
+	Skipped []string `yaml:"Skipped"`
+}
+
+const semestersPath = "/api/v1/semesters"
+
+//ListSemesters returns every semester ordered by start date.
+func (c *Client) ListSemesters(ctx context.Context) ([]Semester, error) {
+	var semesters []Semester
+	err := c.do(ctx, http.MethodGet, semestersPath, nil, &semesters)
+	return semesters, err
+}
+
+//GetSemester returns one semester. The error matches ErrNotFound if there is no such semester.
+func (c *Client) GetSemester(ctx context.Context, semesterID string) (Semester, error) {
+	var semester Semester
+	err := c.do(ctx, http.MethodGet, semesterPath(semesterID), nil, &semester)
+	return semester, err
+}
+
+//CreateSemester adds a new semester. The error matches ErrConflict if the semester ID is taken.
+func (c *Client) CreateSemester(ctx context.Context, semester Semester) error {
+	return c.do(ctx, http.MethodPost, semesterPath(semester.SemesterID), semester, nil)
+}
+
+//UpdateSemester replaces the name and dates of a semester. The error matches ErrNotFound if there is no such semester.
+func (c *Client) UpdateSemester(ctx context.Context, semester Semester) error {
+	return c.do(ctx, http.MethodPut, semesterPath(semester.SemesterID), semester, nil)
+}
+
+//DeleteSemester removes a semester. The error matches ErrConflict while courses are offered in it.
+func (c *Client) DeleteSemester(ctx context.Context, semesterID string) error {
+	return c.do(ctx, http.MethodDelete, semesterPath(semesterID), nil, nil)
+}
+
+//SemesterOfferings returns the courses offered in a semester.
+func (c *Client) SemesterOfferings(ctx context.Context, semesterID string) ([]CourseOffering, error) {
+	var offerings []CourseOffering
+	err := c.do(ctx, http.MethodGet, semesterPath(semesterID)+"/offerings", nil, &offerings)
+	return offerings, err
+}
+
+//CourseOfferings returns the semesters a course is offered in, in term order.
+func (c *Client) CourseOfferings(ctx context.Context, courseID string) ([]CourseOffering, error) {
+	var offerings []CourseOffering
+	err := c.do(ctx, http.MethodGet, coursePath(courseID)+"/offerings", nil, &offerings)
+	return offerings, err
+}
+
+//GetOffering returns the offering of a course in a semester. The error matches ErrNotFound if it is not offered.
+func (c *Client) GetOffering(ctx context.Context, semesterID, courseID string) (CourseOffering, error) {
+	var offering CourseOffering
+	err := c.do(ctx, http.MethodGet, offeringPath(semesterID, courseID), nil, &offering)
+	return offering, err
+}
+
+//PutOffering offers a course in a semester, or replaces the lecturer and class size of its offering, and
+//returns the result. The error matches ErrInvalid if the lecturer does not exist or belongs to another department.
+func (c *Client) PutOffering(ctx context.Context, offering CourseOffering) (CourseOffering, error) {
+	var saved CourseOffering
+	err := c.do(ctx, http.MethodPut, offeringPath(offering.SemesterID, offering.CourseID), offering, &saved)
+	return saved, err
+}
+
+//DeleteOffering stops offering a course in a semester. The error matches ErrNotFound if it was not offered.
+func (c *Client) DeleteOffering(ctx context.Context, semesterID, courseID string) error {
+	return c.do(ctx, http.MethodDelete, offeringPath(semesterID, courseID), nil, nil)
+}
+
+//RollForwardOfferings copies the offerings of a semester into the next one, or into to when it is not empty.
+//The error matches ErrNotFound if there is no later semester.
+func (c *Client) RollForwardOfferings(ctx context.Context, semesterID, to string) (RollForward, error) {
+	path := semesterPath(semesterID) + "/offerings:rollforward"
+	if to != "" {
+		path += "?to=" + url.QueryEscape(to)
+	}
+	var result RollForward
+	err := c.do(ctx, http.MethodPost, path, nil, &result)
+	return result, err
+}
+
+func semesterPath(semesterID string) string {
+	return semestersPath + "/" + url.PathEscape(semesterID)
+}
+
+func offeringPath(semesterID, courseID string) string {
+	return semesterPath(semesterID) + "/offerings/" + url.PathEscape(courseID)
+}
@@ -0,0 +1,35 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+//Prerequisites returns the courses to take before a course, each after its own prerequisites.
+//With transitive set the prerequisites of the prerequisites are included too.
+func (c *Client) Prerequisites(ctx context.Context, courseID string, transitive bool) ([]Course, error) {
+	path := prerequisitesPath(courseID)
+	if transitive {
+		path += "?transitive=true"
+	}
+	var courses []Course
+	err := c.do(ctx, http.MethodGet, path, nil, &courses)
+	return courses, err
+}
+
+//AddPrerequisite records that prereqID must be taken before courseID. Adding it again is not an error.
+//The error matches ErrConflict if prereqID already depends on courseID, and ErrNotFound if either course is missing.
+func (c *Client) AddPrerequisite(ctx context.Context, courseID, prereqID string) error {
+	return c.do(ctx, http.MethodPut, prerequisitesPath(courseID)+"/"+url.PathEscape(prereqID), nil, nil)
+}
+
+//RemovePrerequisite removes prereqID from the prerequisites of courseID.
+//The error matches ErrNotFound if it was not one.
+func (c *Client) RemovePrerequisite(ctx context.Context, courseID, prereqID string) error {
+	return c.do(ctx, http.MethodDelete, prerequisitesPath(courseID)+"/"+url.PathEscape(prereqID), nil, nil)
+}
+
+func prerequisitesPath(courseID string) string {
+	return coursePath(courseID) + "/prerequisites"
+}
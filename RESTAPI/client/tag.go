@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+)
+
+//CourseTags mirrors the tags of one course, in alphabetical order.
+type CourseTags struct {
+	CourseID string   `yaml:"CourseID"`
+	Tags     []string `yaml:"Tags"`
+}
+
+//TagCount mirrors a facet: a tag and how many of the courses considered carry it.
+type TagCount struct {
+	Tag   string `yaml:"Tag"`
+	Count int    `yaml:"Count"`
+}
+
+//CourseSearch is the result of FilterCourses: the matching courses and the tag counts among them.
+type CourseSearch struct {
+	Courses []Course   `yaml:"Courses"`
+	Facets  []TagCount `yaml:"Facets"`
+}
+
+//ListTags returns every tag in use with the number of courses carrying it, most used first.
+func (c *Client) ListTags(ctx context.Context) ([]TagCount, error) {
+	var tags []TagCount
+	err := c.do(ctx, http.MethodGet, "/api/v1/tags", nil, &tags)
+	return tags, err
+}
+
+//CourseTags returns the tags of a course. The error matches ErrNotFound if there is no such course.
+func (c *Client) CourseTags(ctx context.Context, courseID string) (CourseTags, error) {
+	var tags CourseTags
+	err := c.do(ctx, http.MethodGet, coursePath(courseID)+"/tags", nil, &tags)
+	return tags, err
+}
+
+//AddTag tags a course. Adding a tag again is not an error. The error matches ErrNotFound if there is no such course.
+func (c *Client) AddTag(ctx context.Context, courseID, tag string) error {
+	return c.do(ctx, http.MethodPut, tagPath(courseID, tag), nil, nil)
+}
+
+//RemoveTag untags a course. The error matches ErrNotFound if the course did not carry the tag.
+func (c *Client) RemoveTag(ctx context.Context, courseID, tag string) error {
+	return c.do(ctx, http.MethodDelete, tagPath(courseID, tag), nil, nil)
+}
+
+//FilterCourses returns the courses carrying every one of tags, with the tag counts among them.
+//With no tags every course is returned.
+func (c *Client) FilterCourses(ctx context.Context, tags []string) (CourseSearch, error) {
+	query := url.Values{"facets": {"true"}, "tag": tags}
+	var search CourseSearch
+	err := c.do(ctx, http.MethodGet, coursesPath+"?"+query.Encode(), nil, &search)
+	return search, err
+}
+
+func tagPath(courseID, tag string) string {
+	return coursePath(courseID) + "/tags/" + url.PathEscape(tag)
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+//Room mirrors the room resource of the API.
+type Room struct {
+	RoomID   string `yaml:"RoomID"`
+	Name     string `yaml:"Name"`
+	Capacity int    `yaml:"Capacity"`
+}
+
+//Session mirrors a weekly session of a course. Day is a weekday name and the times are written as 15:04.
+type Session struct {
+	SessionID int    `yaml:"SessionID"`
+	CourseID  string `yaml:"CourseID"`
+	Day       string `yaml:"Day"`
+	StartTime string `yaml:"StartTime"`
+	EndTime   string `yaml:"EndTime"`
+	RoomID    string `yaml:"RoomID"`
+}
+
+const roomsPath = "/api/v1/rooms"
+
+//ListRooms returns every room ordered by room ID.
+func (c *Client) ListRooms(ctx context.Context) ([]Room, error) {
+	var rooms []Room
+	err := c.do(ctx, http.MethodGet, roomsPath, nil, &rooms)
+	return rooms, err
+}
+
+//GetRoom returns one room. The error matches ErrNotFound if there is no such room.
+func (c *Client) GetRoom(ctx context.Context, roomID string) (Room, error) {
+	var room Room
+	err := c.do(ctx, http.MethodGet, roomPath(roomID), nil, &room)
+	return room, err
+}
+
+//CreateRoom adds a new room. The error matches ErrConflict if the room ID is taken.
+func (c *Client) CreateRoom(ctx context.Context, room Room) error {
+	return c.do(ctx, http.MethodPost, roomPath(room.RoomID), room, nil)
+}
+
+//UpdateRoom replaces the name and capacity of a room. The error matches ErrConflict if a course meeting in
+//the room has more students than the new capacity.
+func (c *Client) UpdateRoom(ctx context.Context, room Room) error {
+	return c.do(ctx, http.MethodPut, roomPath(room.RoomID), room, nil)
+}
+
+//DeleteRoom removes a room. The error matches ErrConflict while sessions are held in it.
+func (c *Client) DeleteRoom(ctx context.Context, roomID string) error {
+	return c.do(ctx, http.MethodDelete, roomPath(roomID), nil, nil)
+}
+
+//CourseTimetable returns the weekly sessions of a course, Monday first.
+func (c *Client) CourseTimetable(ctx context.Context, courseID string) ([]Session, error) {
+	var sessions []Session
+	err := c.do(ctx, http.MethodGet, coursePath(courseID)+"/sessions", nil, &sessions)
+	return sessions, err
+}
+
+//LecturerTimetable returns the sessions of every course a lecturer teaches, Monday first.
+func (c *Client) LecturerTimetable(ctx context.Context, lecturerID string) ([]Session, error) {
+	var sessions []Session
+	err := c.do(ctx, http.MethodGet, lecturerPath(lecturerID)+"/timetable", nil, &sessions)
+	return sessions, err
+}
+
+//RoomTimetable returns the sessions held in a room, Monday first.
+func (c *Client) RoomTimetable(ctx context.Context, roomID string) ([]Session, error) {
+	var sessions []Session
+	err := c.do(ctx, http.MethodGet, roomPath(roomID)+"/timetable", nil, &sessions)
+	return sessions, err
+}
+
+//GetSession returns one session of a course. The error matches ErrNotFound if the course has no such session.
+func (c *Client) GetSession(ctx context.Context, courseID string, sessionID int) (Session, error) {
+	var session Session
+	err := c.do(ctx, http.MethodGet, sessionPath(courseID, sessionID), nil, &session)
+	return session, err
+}
+
+//CreateSession schedules a session of a course and returns it with its session ID. The error matches
+//ErrConflict if the room or the lecturer is already booked at the time or the room is too small.
+func (c *Client) CreateSession(ctx context.Context, session Session) (Session, error) {
+	var saved Session
+	err := c.do(ctx, http.MethodPost, coursePath(session.CourseID)+"/sessions", session, &saved)
+	return saved, err
+}
+
+//UpdateSession moves a session of a course to another day, time or room. The error matches ErrConflict
+//like CreateSession.
+func (c *Client) UpdateSession(ctx context.Context, session Session) error {
+	return c.do(ctx, http.MethodPut, sessionPath(session.CourseID, session.SessionID), session, nil)
+}
+
+//DeleteSession cancels a session of a course. The error matches ErrNotFound if the course has no such session.
+func (c *Client) DeleteSession(ctx context.Context, courseID string, sessionID int) error {
+	return c.do(ctx, http.MethodDelete, sessionPath(courseID, sessionID), nil, nil)
+}
+
+func roomPath(roomID string) string {
+	return roomsPath + "/" + url.PathEscape(roomID)
+}
+
+func sessionPath(courseID string, sessionID int) string {
+	return coursePath(courseID) + "/sessions/" + strconv.Itoa(sessionID)
+}
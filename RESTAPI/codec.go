@@ -0,0 +1,364 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+//codec converts values to and from one wire format.
+type codec interface {
+	Encode(w io.Writer, v interface{}) error
+	Decode(r io.Reader, v interface{}) error
+}
+
+//codecs maps each supported media type to its codec. mediaTypes keeps the order of preference,
+//the first one being the default when the client expresses no preference.
+var (
+	codecs     = map[string]codec{}
+	mediaTypes []string
+)
+
+//registerCodec makes c available under each of the given media types, appended in order of preference.
+func registerCodec(c codec, types ...string) {
+	for _, t := range types {
+		codecs[t] = c
+		mediaTypes = append(mediaTypes, t)
+	}
+}
+
+func init() {
+	registerCodec(jsonCodec{}, "application/json")
+	registerCodec(xmlCodec{}, "application/xml", "text/xml")
+	registerCodec(yamlCodec{}, "application/yaml", "application/x-yaml", "text/yaml")
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Encode(w io.Writer, v interface{}) error { return json.NewEncoder(w).Encode(v) }
+
+//Decode rejects unknown fields and anything after the first JSON value.
+func (jsonCodec) Decode(r io.Reader, v interface{}) error {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	//a second value, or a stray ] or }, is trailing data; only the end of the body may follow
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return errTrailingData
+	}
+	return nil
+}
+
+type yamlCodec struct{}
+
+func (yamlCodec) Encode(w io.Writer, v interface{}) error { return yaml.NewEncoder(w).Encode(v) }
+
+//Decode rejects unknown and duplicate fields.
+func (yamlCodec) Decode(r io.Reader, v interface{}) error {
+	dec := yaml.NewDecoder(r)
+	dec.SetStrict(true)
+	return dec.Decode(v)
+}
+
+//xmlCodec wraps slices in a root element named after their element type, e.g. <Courses><Course>...</Course></Courses>,
+//since a bare sequence of elements is not a well-formed XML document.
+type xmlCodec struct{}
+
+func (xmlCodec) Encode(w io.Writer, v interface{}) error {
+	io.WriteString(w, xml.Header)
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Slice {
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		return enc.Flush()
+	}
+
+	root := xml.StartElement{Name: xml.Name{Local: value.Type().Elem().Name() + "s"}}
+	if err := enc.EncodeToken(root); err != nil {
+		return err
+	}
+	for i := 0; i < value.Len(); i++ {
+		if err := enc.Encode(value.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	if err := enc.EncodeToken(root.End()); err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+//Decode rejects unknown fields, fields holding elements rather than text, and anything after the root element,
+//which encoding/xml would otherwise skip. The name of the root element is not checked.
+func (xmlCodec) Decode(r io.Reader, v interface{}) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if err := checkXML(data, xmlFields(reflect.TypeOf(v))); err != nil {
+		return err
+	}
+	return xml.Unmarshal(data, v)
+}
+
+//xmlFields returns the element names encoding/xml decodes into the fields of the struct t points to.
+func xmlFields(t reflect.Type) map[string]bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	fields := map[string]bool{}
+	if t.Kind() != reflect.Struct {
+		return fields
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("xml"), ",")[0]
+		switch {
+		case f.PkgPath != "" && !f.Anonymous, name == "-", f.Name == "XMLName":
+			continue
+		case f.Anonymous && name == "": //the fields of an embedded struct are decoded as if they were its own
+			for embedded := range xmlFields(f.Type) {
+				fields[embedded] = true
+			}
+			continue
+		case name == "":
+			name = f.Name
+		}
+		fields[name] = true
+	}
+	return fields
+}
+
+//checkXML walks the tokens of an XML document and reports the first element that is not one of fields directly
+//under the root, and any element or text after the root. An empty document is left to xml.Unmarshal.
+func checkXML(data []byte, fields map[string]bool) error {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	depth, done, field := 0, false, ""
+	for {
+		token, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			depth++
+			switch {
+			case done:
+				return errTrailingData
+			case depth == 2 && !fields[token.Name.Local]:
+				return fmt.Errorf("unknown field %q", token.Name.Local)
+			case depth == 2:
+				field = token.Name.Local
+			case depth > 2:
+				return fmt.Errorf("field %s must hold text, not element %s", field, token.Name.Local)
+			}
+		case xml.EndElement:
+			depth--
+			done = depth == 0
+		case xml.CharData:
+			if done && len(bytes.TrimSpace(token)) > 0 {
+				return errTrailingData
+			}
+		case xml.Directive:
+			if done {
+				return errTrailingData
+			}
+		}
+	}
+}
+
+//acceptRange is one entry of an Accept header.
+type acceptRange struct {
+	mediaType string
+	q         float64
+}
+
+//parseAccept splits an Accept header into its media ranges, skipping malformed ones.
+func parseAccept(header string) []acceptRange {
+	ranges := []acceptRange{}
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType, q})
+	}
+	return ranges
+}
+
+//matches reports how specifically the range matches mediaType: 3 exact, 2 type/*, 1 */*, 0 not at all.
+func (a acceptRange) matches(mediaType string) int {
+	switch {
+	case a.mediaType == mediaType:
+		return 3
+	case a.mediaType == "*/*":
+		return 1
+	case strings.HasSuffix(a.mediaType, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(a.mediaType, "*")):
+		return 2
+	}
+	return 0
+}
+
+//negotiate picks the offer the client prefers according to its Accept header. An absent header accepts the first offer.
+func negotiate(r *http.Request, offers []string) (string, bool) {
+	header := r.Header.Get("Accept")
+	if header == "" {
+		return offers[0], true
+	}
+	ranges := parseAccept(header)
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		//the quality of an offer comes from the most specific range matching it
+		q, specificity := 0.0, 0
+		for _, a := range ranges {
+			if s := a.matches(offer); s > specificity {
+				q, specificity = a.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best, best != ""
+}
+
+//writeResponse encodes v in the format negotiated from the Accept header, or answers 406 if none is acceptable.
+func writeResponse(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	mediaType, ok := negotiate(r, mediaTypes)
+	if !ok {
+		writeJSONError(w, r, http.StatusNotAcceptable, "406 - Supported formats are "+strings.Join(mediaTypes, ", "))
+		return
+	}
+	w.Header().Set("Content-Type", mediaType+"; charset=utf-8")
+	w.WriteHeader(status)
+	if err := codecs[mediaType].Encode(w, v); err != nil {
+		log.WithField("requestID", requestID(r)).Error("Error encoding response: ", err)
+	}
+}
+
+//requestCodec returns the codec for the Content-Type of the request body. Only UTF-8 bodies are accepted.
+func requestCodec(r *http.Request) (codec, bool) {
+	mediaType, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, false
+	}
+	if charset, ok := params["charset"]; ok && !strings.EqualFold(charset, "utf-8") {
+		return nil, false
+	}
+	c, ok := codecs[mediaType]
+	return c, ok
+}
+
+//errTrailingData is reported when a body holds more than the single value expected.
+var errTrailingData = errors.New("unexpected data after the end of the body")
+
+//describeDecodeError turns a decoding error into a message that tells the client what to fix.
+func describeDecodeError(err error) string {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == io.EOF:
+		return "request body is empty"
+	case err == io.ErrUnexpectedEOF:
+		return "request body is truncated"
+	case errors.As(err, &syntaxErr):
+		return fmt.Sprintf("malformed JSON at byte %d: %v", syntaxErr.Offset, syntaxErr)
+	case errors.As(err, &typeErr):
+		return fmt.Sprintf("field %s must be of type %s", typeErr.Field, typeErr.Type)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		return "unknown field " + strings.TrimPrefix(err.Error(), "json: unknown field ")
+	}
+	return err.Error()
+}
+
+//errBodyTooLarge is returned by a limitedBody once the client has sent more than the limit.
+var errBodyTooLarge = errors.New("request body too large")
+
+//limitedBody reads a request body up to a number of bytes like http.MaxBytesReader, but remembers whether the
+//limit was hit: a decoder may wrap the read error, and yaml.v2 keeps only its message.
+type limitedBody struct {
+	io.ReadCloser
+	w         http.ResponseWriter
+	remaining int64
+	exceeded  bool
+}
+
+//limitBody cuts off body after n bytes. Check isBodyTooLarge when reading it fails.
+func limitBody(w http.ResponseWriter, body io.ReadCloser, n int64) io.ReadCloser {
+	return &limitedBody{ReadCloser: body, w: w, remaining: n}
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.exceeded {
+		return 0, errBodyTooLarge
+	}
+	if int64(len(p)) > b.remaining+1 { //one byte past the limit is enough to tell the body is too large
+		p = p[:b.remaining+1]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) <= b.remaining {
+		b.remaining -= int64(n)
+		return n, err
+	}
+	n, b.remaining, b.exceeded = int(b.remaining), 0, true
+	//the rest of the body is left unread, so the connection cannot serve another request
+	b.w.Header().Set("Connection", "close")
+	return n, errBodyTooLarge
+}
+
+//isBodyTooLarge reports whether the body of r was cut off by limitBody.
+func isBodyTooLarge(r *http.Request) bool {
+	b, ok := r.Body.(*limitedBody)
+	return ok && b.exceeded
+}
+
+//decodeRequest decodes the request body into v using the codec for its Content-Type, reading at most
+//maxBodyBytes. It writes the error response itself and returns false if the body cannot be used.
+func decodeRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	c, ok := requestCodec(r)
+	if !ok {
+		writeJSONError(w, r, http.StatusUnsupportedMediaType, "415 - Please supply course information as "+strings.Join(mediaTypes, ", ")+" (UTF-8)")
+		log.Warning("Fail attempt to decode request body: 415 - Unsupported Content-Type ", r.Header.Get("Content-Type"))
+		return false
+	}
+	r.Body = limitBody(w, r.Body, maxBodyBytes)
+	if err := c.Decode(r.Body, v); err != nil {
+		if isBodyTooLarge(r) {
+			writeJSONError(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("413 - Request body exceeds %d bytes", maxBodyBytes))
+			log.Warning("Fail attempt to decode request body: 413 - Request body too large")
+			return false
+		}
+		message := describeDecodeError(err)
+		writeJSONError(w, r, http.StatusBadRequest, "400 - "+message)
+		log.Warning("Fail attempt to decode request body: 400 - ", message)
+		return false
+	}
+	return true
+}
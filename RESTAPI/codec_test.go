@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"goMicroService1Assignment/RESTAPI/database"
+)
+
+func TestParseAccept(t *testing.T) {
+	cases := []struct {
+		header string
+		ranges []acceptRange
+	}{
+		{"application/json", []acceptRange{{"application/json", 1}}},
+		{"application/xml;q=0.5, */*;q=0.1", []acceptRange{{"application/xml", 0.5}, {"*/*", 0.1}}},
+		{"text/*; q=0 , Application/YAML", []acceptRange{{"text/*", 0}, {"application/yaml", 1}}},
+		{"application/json;q=high, application/xml", []acceptRange{{"application/xml", 1}}},
+		{"no slash, application/xml", []acceptRange{{"application/xml", 1}}},
+		{"", []acceptRange{}},
+	}
+	for _, c := range cases {
+		if got := parseAccept(c.header); fmt.Sprint(got) != fmt.Sprint(c.ranges) {
+			t.Errorf("parseAccept(%q) = %v, want %v", c.header, got, c.ranges)
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		accept    string
+		mediaType string //empty if nothing is acceptable
+	}{
+		{"", "application/json"},
+		{"application/xml", "application/xml"},
+		{"*/*", "application/json"},
+		{"text/*", "text/xml"},
+		{"application/*", "application/json"},
+		{"application/json;q=0.5, application/yaml", "application/yaml"},
+		{"application/*;q=0.2, application/xml;q=0.9", "application/xml"},
+		{"application/yaml, application/xml", "application/xml"}, //equal quality goes to the server's preference
+		{"application/json;q=0, */*", "application/xml"},
+		{"application/json;q=0, application/*", "application/xml"},
+		{"text/*;q=0, application/x-yaml;q=0.1", "application/x-yaml"},
+		{"*/*;q=0", ""},
+		{"application/*;q=0, */*;q=0.5", "text/xml"},
+		{"text/html", ""},
+		{"text/html, image/png", ""},
+		{"application/json;q=high", ""},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest("GET", "https://localhost/api/v1/courses", nil)
+		if c.accept != "" {
+			r.Header.Set("Accept", c.accept)
+		}
+		got, ok := negotiate(r, mediaTypes)
+		if got != c.mediaType || ok != (c.mediaType != "") {
+			t.Errorf("Accept %q: negotiated %q (%v), want %q", c.accept, got, ok, c.mediaType)
+		}
+	}
+}
+
+func TestXMLDecode(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		err  string //empty if the body decodes
+	}{
+		{"course", "<Course><Title>Go Basic</Title><ClassSize>30</ClassSize></Course>", ""},
+		{"prolog and comments", strings.TrimSpace(xml.Header) + "\n<!-- new -->\n<Course><Title>Go</Title></Course>\n<!-- end -->\n", ""},
+		{"any root name", "<Anything><Title>Go</Title></Anything>", ""},
+		{"unknown field", "<Course><Titel>Go</Titel></Course>", `unknown field "Titel"`},
+		{"field holding an element", "<Course><Title><b>Go</b></Title></Course>", "field Title must hold text, not element b"},
+		{"second root", "<Course><Title>Go</Title></Course><Course/>", errTrailingData.Error()},
+		{"trailing text", "<Course><Title>Go</Title></Course>]", errTrailingData.Error()},
+		{"stray end tag", "<Course><Title>Go</Title></Course></Course>", "XML syntax error on line 1: unexpected end element </Course>"},
+		{"truncated", "<Course><Title>Go</Title>", "XML syntax error on line 1: unexpected EOF"},
+		{"empty", "", io.EOF.Error()},
+	}
+	for _, c := range cases {
+		var course database.Course
+		err := xmlCodec{}.Decode(strings.NewReader(c.body), &course)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != c.err {
+			t.Errorf("%s: error %q, want %q", c.name, got, c.err)
+		} else if err == nil && course.Title == "" {
+			t.Errorf("%s: no title decoded", c.name)
+		}
+	}
+
+	//the fields of an embedded struct belong to the outer element
+	var entry database.CalendarEntry
+	body := "<CalendarEntry><CourseID>GOS1000</CourseID><Title>Go Basic</Title></CalendarEntry>"
+	if err := (xmlCodec{}).Decode(strings.NewReader(body), &entry); err != nil || entry.CourseID != "GOS1000" {
+		t.Errorf("embedded fields: decoded %+v with error %v", entry, err)
+	}
+}
+
+//TestDecodeRequestTooLarge checks that each format answers 413 once the body passes maxBodyBytes, however the
+//decoder reports the failed read.
+func TestDecodeRequestTooLarge(t *testing.T) {
+	defer func(limit int64) { maxBodyBytes = limit }(maxBodyBytes)
+	maxBodyBytes = 64
+
+	long := strings.Repeat("x", 100)
+	cases := []struct {
+		contentType string
+		fits, over  string
+	}{
+		{"application/json", `{"Title":"Go"}`, `{"Title":"` + long + `"}`},
+		{"application/xml", "<Course><Title>Go</Title></Course>", "<Course><Title>" + long + "</Title></Course>"},
+		{"application/yaml", "Title: Go\n", "Title: " + long + "\n"},
+	}
+	for _, c := range cases {
+		for _, body := range []string{c.fits, c.over} {
+			r := httptest.NewRequest("POST", "https://localhost/api/v1/courses/GOS9999", strings.NewReader(body))
+			r.Header.Set("Content-Type", c.contentType)
+			w := httptest.NewRecorder()
+			var course database.Course
+			ok := decodeRequest(w, r, &course)
+
+			tooLarge := len(body) > int(maxBodyBytes)
+			switch {
+			case tooLarge && (ok || w.Code != http.StatusRequestEntityTooLarge):
+				t.Errorf("%s body of %d bytes: decoded %v, answered %d, want 413", c.contentType, len(body), ok, w.Code)
+			case tooLarge && w.Header().Get("Connection") != "close":
+				t.Errorf("%s body of %d bytes: connection kept open", c.contentType, len(body))
+			case !tooLarge && (!ok || course.Title != "Go"):
+				t.Errorf("%s body of %d bytes: decoded %v with title %q, answered %d", c.contentType, len(body), ok, course.Title, w.Code)
+			}
+		}
+	}
+}
+
+func TestLimitBody(t *testing.T) {
+	for _, size := range []int{0, 9, 10, 11, 100} {
+		body := strings.Repeat("x", size)
+		r := httptest.NewRequest("POST", "https://localhost/", strings.NewReader(body))
+		r.Body = limitBody(httptest.NewRecorder(), r.Body, 10)
+		data, err := ioutil.ReadAll(r.Body)
+		if size <= 10 && (err != nil || string(data) != body || isBodyTooLarge(r)) {
+			t.Errorf("body of %d bytes: read %d bytes, error %v, too large %v", size, len(data), err, isBodyTooLarge(r))
+		}
+		if size > 10 && (err != errBodyTooLarge || len(data) != 10 || !isBodyTooLarge(r)) {
+			t.Errorf("body of %d bytes: read %d bytes, error %v, too large %v, want 10 and errBodyTooLarge", size, len(data), err, isBodyTooLarge(r))
+		}
+	}
+}
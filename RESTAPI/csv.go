@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	database "goMicroService1Assignment/RESTAPI/database"
+)
+
+//csvColumns are the CSV columns, named after the Course struct fields.
+var csvColumns = []string{"CourseID", "Title", "LecturerID", "Lecturer", "ClassSize", "Description", "Credits", "Status"}
+
+//csvOptionalColumns may be left out of an upload. Files from before lecturers had IDs name them in Lecturer only,
+//and files from before courses had descriptions, credits and a status leave those at their defaults.
+var csvOptionalColumns = map[string]bool{"LecturerID": true, "Description": true, "Credits": true, "Status": true}
+
+//csvRecord is the CSV row of a course, in the order of csvColumns. The timestamps are not exported.
+func csvRecord(course database.Course) []string {
+	return []string{course.CourseID, course.Title, course.LecturerID, course.Lecturer, strconv.Itoa(course.ClassSize),
+		course.Description, strconv.Itoa(course.Credits), course.Status}
+}
+
+//listMediaTypes are the formats offered for the course list: every registered codec plus CSV.
+func listMediaTypes() []string {
+	return append(append([]string{}, mediaTypes...), "text/csv")
+}
+
+//writeCoursesCSV streams every course as CSV with a header row.
+func writeCoursesCSV(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="courses.csv"`)
+
+	out := csv.NewWriter(w)
+	count := 0
+	err := database.EachRecord(r.Context(), db, func(course database.Course) error {
+		if count == 0 {
+			out.Write(csvColumns)
+		}
+		count++
+		return out.Write(csvRecord(course))
+	})
+	if err != nil && count == 0 {
+		writeDBError(w, r, err)
+		return
+	}
+	if count == 0 { //empty table, still send the header
+		out.Write(csvColumns)
+	}
+	out.Flush()
+	if err == nil {
+		err = out.Error()
+	}
+	if err != nil {
+		log.WithField("requestID", requestID(r)).Error("CSV export aborted: ", err)
+	}
+}
+
+//csvCourse is one parsed data row and the line of the file it starts on.
+type csvCourse struct {
+	line   int
+	course database.Course
+	err    error
+}
+
+//lineReader hands out its input at most one line per Read. The CSV reader then never buffers past the line it
+//is working on, so the newlines handed out so far tell which line of the file it has read up to.
+type lineReader struct {
+	r       *bufio.Reader
+	pending []byte
+	lines   int  //newlines handed out
+	partial bool //the last byte handed out was not a newline
+}
+
+func (l *lineReader) Read(p []byte) (int, error) {
+	if len(l.pending) == 0 {
+		line, err := l.r.ReadSlice('\n')
+		if len(line) == 0 {
+			return 0, err
+		}
+		l.pending = line //stays valid since it is handed out before the next ReadSlice
+	}
+	n := copy(p, l.pending)
+	l.pending = l.pending[n:]
+	l.lines += bytes.Count(p[:n], []byte{'\n'})
+	l.partial = p[n-1] != '\n'
+	return n, nil
+}
+
+//recordLine returns the line of the file that the record just read starts on. Line breaks inside quoted
+//fields come back as one \n each, so the lines the record spans are counted from its fields.
+func (l *lineReader) recordLine(record []string) int {
+	line := l.lines
+	if l.partial { //the last line of the file has no newline
+		line++
+	}
+	for _, field := range record {
+		line -= strings.Count(field, "\n")
+	}
+	return line
+}
+
+//readCoursesCSV parses a CSV upload. The header row may list the columns in any order; every data row is
+//returned with its own parse error, if any, so that the caller can report on all of them.
+func readCoursesCSV(body io.Reader) ([]csvCourse, error) {
+	lines := &lineReader{r: bufio.NewReader(body)}
+	reader := csv.NewReader(lines)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("the file is empty, expected a header row of %s", strings.Join(csvColumns, ","))
+	}
+	if err != nil {
+		return nil, err
+	}
+	index := map[string]int{}
+	for i, name := range header {
+		for _, column := range csvColumns {
+			if strings.EqualFold(strings.TrimSpace(name), column) {
+				index[column] = i
+			}
+		}
+	}
+	for _, column := range csvColumns {
+		if _, ok := index[column]; !ok && !csvOptionalColumns[column] {
+			return nil, fmt.Errorf("header row is missing the %s column", column)
+		}
+	}
+
+	rows := []csvCourse{}
+	seen := map[string]int{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if len(rows) >= maxBatchItems {
+			return nil, fmt.Errorf("the file has more than %d courses", maxBatchItems)
+		}
+		row := csvCourse{line: lines.recordLine(record)}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) && parseErr.Err == csv.ErrFieldCount {
+				row.line = parseErr.StartLine
+				row.err = fmt.Errorf("expected %d fields, got %d", len(header), len(record))
+			} else {
+				return nil, err
+			}
+		} else {
+			row.course = database.Course{
+				CourseID: record[index["CourseID"]],
+				Title:    record[index["Title"]],
+				Lecturer: record[index["Lecturer"]],
+			}
+			if i, ok := index["LecturerID"]; ok {
+				row.course.LecturerID = record[i]
+			}
+			if i, ok := index["Description"]; ok {
+				row.course.Description = record[i]
+			}
+			if i, ok := index["Status"]; ok {
+				row.course.Status = strings.TrimSpace(record[i])
+			}
+			size, convErr := strconv.Atoi(strings.TrimSpace(record[index["ClassSize"]]))
+			credits, creditsErr := 0, error(nil)
+			if i, ok := index["Credits"]; ok && strings.TrimSpace(record[i]) != "" {
+				credits, creditsErr = strconv.Atoi(strings.TrimSpace(record[i]))
+			}
+			switch {
+			case convErr != nil:
+				row.err = fmt.Errorf("ClassSize %q is not a whole number", record[index["ClassSize"]])
+			case creditsErr != nil:
+				row.err = fmt.Errorf("Credits %q is not a whole number", record[index["Credits"]])
+			default:
+				row.course.ClassSize, row.course.Credits = size, credits
+				row.err = validateCourse(&row.course)
+			}
+			if row.err == nil {
+				if first, dup := seen[row.course.CourseID]; dup {
+					row.err = fmt.Errorf("duplicate of course ID on line %d", first)
+				} else {
+					seen[row.course.CourseID] = row.line
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+//importCoursesCSV creates courses from a CSV upload. Every row is validated first and, if any row is
+//rejected, the row-by-row report is returned without touching the database. Otherwise all courses are
+//inserted in one transaction. With dryRun=true the inserts are rolled back even when they all succeed.
+func importCoursesCSV(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "text/csv" {
+		writeJSONError(w, r, http.StatusUnsupportedMediaType, "415 - Please supply courses as text/csv")
+		return
+	}
+	dryRun := r.URL.Query().Get("dryRun") == "true"
+
+	r.Body = limitBody(w, r.Body, maxBatchBodyBytes)
+	rows, err := readCoursesCSV(r.Body)
+	if isBodyTooLarge(r) {
+		writeJSONError(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("413 - Request body exceeds %d bytes", maxBatchBodyBytes))
+		return
+	} else if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "400 - "+err.Error())
+		return
+	}
+
+	response := batchResponse{Mode: modeAtomic, Results: make([]batchResult, len(rows))}
+	for i, row := range rows {
+		response.Results[i] = batchResult{Index: i, Line: row.line, CourseID: row.course.CourseID, Status: http.StatusCreated}
+		if row.err != nil {
+			response.Results[i].Status, response.Results[i].Error = http.StatusUnprocessableEntity, row.err.Error()
+			response.Failed++
+		}
+	}
+
+	if response.Failed == 0 {
+		batch, err := database.BeginBatch(r.Context(), db, true)
+		if err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		for i, row := range rows {
+			if err := batch.Insert(r.Context(), row.course); err != nil {
+				response.Results[i].Status, response.Results[i].Error = statusFor(err), err.Error()
+				response.Failed++
+			}
+		}
+		if dryRun {
+			batch.Fail()
+		}
+		response.Committed, err = batch.Finish(r.Context())
+		if err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+	}
+
+	rejected := response.Failed
+	for i := range response.Results {
+		result := &response.Results[i]
+		if result.Error == "" && !response.Committed {
+			if dryRun && rejected == 0 {
+				result.Status = http.StatusOK
+			} else {
+				result.Status, result.Error = http.StatusFailedDependency, "not imported because other rows failed"
+				response.Failed++
+			}
+		}
+		if result.Error == "" {
+			response.Succeeded++
+		}
+	}
+
+	status := http.StatusCreated
+	if rejected > 0 {
+		status = http.StatusUnprocessableEntity
+		log.WithField("requestID", requestID(r)).Warningf("CSV import rejected with %d of %d rows failed", rejected, len(rows))
+	} else if dryRun {
+		status = http.StatusOK
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&response)
+}
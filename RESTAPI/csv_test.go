@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLineReader(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		lines []int //line each record starts on
+	}{
+		{"one line each", "a,b\nc,d\ne,f\n", []int{1, 2, 3}},
+		{"no final newline", "a,b\nc,d", []int{1, 2}},
+		{"CRLF", "a,b\r\nc,d\r\n", []int{1, 2}},
+		{"blank lines", "a,b\n\n\nc,d\n", []int{1, 4}},
+		{"quoted line break", "a,\"b\nb\"\nc,d\n", []int{1, 3}},
+		{"several quoted line breaks", "a,\"b\n\nb\",\"c\nc\"\nd,e,f\n", []int{1, 5}},
+		{"quoted CRLF", "a,\"b\r\nb\"\r\nc,d\r\n", []int{1, 3}},
+		{"quoted line break on the last line", "a,b\nc,\"d\nd\"", []int{1, 2}},
+	}
+	for _, c := range cases {
+		lines := &lineReader{r: bufio.NewReader(strings.NewReader(c.input))}
+		reader := csv.NewReader(lines)
+		got := []int{}
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: %v", c.name, err)
+			}
+			got = append(got, lines.recordLine(record))
+		}
+		if len(got) != len(c.lines) {
+			t.Errorf("%s: read %d records, want %d", c.name, len(got), len(c.lines))
+			continue
+		}
+		for i := range got {
+			if got[i] != c.lines[i] {
+				t.Errorf("%s: record %d starts on line %d, want %d", c.name, i, got[i], c.lines[i])
+			}
+		}
+	}
+}
+
+//TestLineReaderReads checks that no Read hands out more than one line, whatever the size of the buffer.
+func TestLineReaderReads(t *testing.T) {
+	input := "CourseID,Title\nGOS1000,\"Go\nBasic\"\n\nGOS1001,Go Advanced"
+	for _, size := range []int{1, 3, 16, 4096} {
+		lines := &lineReader{r: bufio.NewReader(strings.NewReader(input))}
+		var out bytes.Buffer
+		p := make([]byte, size)
+		for {
+			n, err := lines.Read(p)
+			if i := bytes.IndexByte(p[:n], '\n'); i >= 0 && i != n-1 {
+				t.Fatalf("buffer of %d: Read handed out %q, past the end of a line", size, p[:n])
+			}
+			out.Write(p[:n])
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if out.String() != input {
+			t.Errorf("buffer of %d: read %q, want %q", size, out.String(), input)
+		}
+		if lines.lines != 4 || !lines.partial {
+			t.Errorf("buffer of %d: counted %d newlines, partial %v, want 4 and true", size, lines.lines, lines.partial)
+		}
+	}
+}
+
+func TestReadCoursesCSVLines(t *testing.T) {
+	input := "CourseID,Title,Lecturer,ClassSize,Description\n" +
+		"GOS1000,Go Basic,Low Kheng Hian,25,\"First line\nsecond line\nthird line\"\n" +
+		"\n" +
+		"GOS1001,Go Advanced,Lee Ching Yun,many,\n" +
+		"GOS1002,\"Go\nIn Action\",Lee Ching Yun,22\n" +
+		"GOS1000,Go Basic,Low Kheng Hian,25,\"\"\n"
+	rows, err := readCoursesCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		line int
+		err  string
+	}{
+		{2, ""},
+		{6, `ClassSize "many" is not a whole number`},
+		{7, "expected 5 fields, got 4"},
+		{9, "duplicate of course ID on line 2"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("read %d rows, want %d", len(rows), len(want))
+	}
+	for i, row := range rows {
+		got := ""
+		if row.err != nil {
+			got = row.err.Error()
+		}
+		if row.line != want[i].line || got != want[i].err {
+			t.Errorf("row %d: line %d with error %q, want line %d with error %q", i, row.line, got, want[i].line, want[i].err)
+		}
+	}
+}
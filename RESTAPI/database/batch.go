@@ -0,0 +1,163 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+//Batch inserts a stream of courses or sessions. In atomic mode every insert runs in one transaction that is only
+//committed if all of them succeed; otherwise each course is inserted independently.
+type Batch struct {
+	db     *sql.DB
+	ctx    context.Context //the batch was begun with, a transaction begun again after a deadlock lives as long
+	tx     *sql.Tx
+	redo   []func(ctx context.Context, tx *sql.Tx) error //inserts made in tx so far, replayed after a deadlock
+	failed bool
+	lost   error //why tx was rolled back without being replaced, no statement may run after it
+}
+
+//BeginBatch starts a batch. ctx should live for the whole batch, typically the HTTP request context.
+func BeginBatch(ctx context.Context, db *sql.DB, atomic bool) (*Batch, error) {
+	b := &Batch{db: db, ctx: ctx}
+	if atomic {
+		tx, err := db.BeginTx(ctx, nil)
+		if err != nil {
+			return nil, wrapError(ctx, "BeginBatch", err)
+		}
+		b.tx = tx
+	}
+	return b, nil
+}
+
+//Insert adds one course to the batch. A failure is remembered so that Finish rolls an atomic batch back,
+//but later inserts are still attempted so the caller can report on every course.
+func (b *Batch) Insert(ctx context.Context, course Course) error {
+	if b.tx == nil {
+		return InsertRecord(ctx, b.db, course)
+	}
+
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	insert := func(ctx context.Context, tx *sql.Tx) error {
+		return insertCourse(ctx, tx, course)
+	}
+	err := b.run(ctx, insert)
+	if err == nil {
+		b.redo = append(b.redo, insert)
+	}
+	return wrapError(ctx, "Batch.Insert", err)
+}
+
+//InsertSession adds one session of a course to the batch and returns it with its SessionID. It is checked for
+//clashes with the sessions inserted before it in the same batch. Failures are remembered like those of Insert.
+func (b *Batch) InsertSession(ctx context.Context, session Session) (Session, error) {
+	if b.tx == nil {
+		return InsertSession(ctx, b.db, session)
+	}
+
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	saved := session
+	err := b.run(ctx, func(ctx context.Context, tx *sql.Tx) error {
+		var err error
+		saved, err = insertSession(ctx, tx, session)
+		return err
+	})
+	if err == nil {
+		inserted := saved //the caller has been told this SessionID, a replay must keep it
+		b.redo = append(b.redo, func(ctx context.Context, tx *sql.Tx) error {
+			return reinsertSession(ctx, tx, inserted)
+		})
+	}
+	return saved, wrapError(ctx, "Batch.InsertSession", err)
+}
+
+//run executes insert in the transaction of an atomic batch. A deadlock makes InnoDB roll back the whole
+//transaction, and any statement sent on its connection afterwards would commit on its own. So, as withTx
+//does, a new transaction is begun, the inserts made so far are replayed in it and insert is tried again.
+//If that cannot be done the transaction is lost and every later insert fails without running.
+func (b *Batch) run(ctx context.Context, insert func(ctx context.Context, tx *sql.Tx) error) error {
+	if b.lost != nil {
+		b.failed = true
+		return b.lost
+	}
+	err := insert(ctx, b.tx)
+	for attempt := 1; isDeadlock(err); attempt++ {
+		if attempt == txAttempts {
+			b.lost = err
+			break
+		}
+		if err = b.restart(ctx); err == nil {
+			err = insert(ctx, b.tx)
+		} else if !isDeadlock(err) {
+			b.lost = err
+		}
+	}
+	if err != nil {
+		b.failed = true
+	}
+	return err
+}
+
+//restart replaces the transaction of the batch after a deadlock and replays the inserts made in it.
+func (b *Batch) restart(ctx context.Context) error {
+	b.tx.Rollback() //already rolled back by InnoDB, this only releases the connection
+	tx, err := b.db.BeginTx(b.ctx, nil)
+	if err != nil {
+		return err
+	}
+	b.tx = tx
+	for _, redo := range b.redo {
+		if err := redo(ctx, tx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Fail marks an atomic batch as failed, e.g. because the caller rejected a course before inserting it.
+func (b *Batch) Fail() {
+	b.failed = true
+}
+
+//Finish ends the batch and reports whether its inserts were kept. An atomic batch is committed only if
+//nothing failed; a non-atomic batch has nothing left to do.
+func (b *Batch) Finish(ctx context.Context) (committed bool, err error) {
+	if b.tx == nil {
+		return true, nil
+	}
+	if b.failed {
+		err := b.tx.Rollback()
+		if b.lost != nil { //nothing is left to roll back, the failed insert has reported why
+			err = nil
+		}
+		return false, wrapError(ctx, "Batch.Finish", err)
+	}
+	if err := b.tx.Commit(); err != nil {
+		return false, wrapError(ctx, "Batch.Finish", err)
+	}
+	return true, nil
+}
+
+//EachRecord calls fn for every course in CourseID order without loading the whole table into memory.
+//Only the request context bounds it, not QueryTimeout, because the caller may be streaming to a slow client.
+//Iteration stops at the first error returned by fn.
+func EachRecord(ctx context.Context, db *sql.DB, fn func(Course) error) error {
+	results, err := queryContext(ctx, db, queryAllCoursesOrdered)
+	if err != nil {
+		return wrapError(ctx, "EachRecord", err)
+	}
+	defer results.Close()
+	for results.Next() {
+		var course Course
+		if err = results.Scan(courseFields(&course)...); err != nil {
+			return wrapError(ctx, "EachRecord", err)
+		}
+		if err = fn(course); err != nil {
+			return err
+		}
+	}
+	return wrapError(ctx, "EachRecord", results.Err())
+}
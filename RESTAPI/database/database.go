@@ -3,71 +3,409 @@ package database
 import (
 	"context"
 	"database/sql"
-	"fmt"
+	"strings"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
-	log "github.com/sirupsen/logrus"
 )
 
+//Course is a course with the name of its lecturer. LecturerID is the reference kept in the table, the name
+//is joined in from the Lecturer table. A course being written may give either, the other is looked up.
+//ClassSize is the default capacity that enrolments count against; each CourseOffering has its own for its semester.
+//Description may hold the markup the sanitizing policy lets through. Status is one of the Status constants, a
+//course written without one keeps its current status or starts as a draft. The timestamps are set by the
+//database package and ignored when a course is written.
 type Course struct {
-	CourseID  string
-	Title     string
-	Lecturer  string
-	ClassSize int
+	CourseID    string    `json:"CourseID" yaml:"CourseID"`
+	Title       string    `json:"Title" yaml:"Title"`
+	LecturerID  string    `json:"LecturerID,omitempty" yaml:"LecturerID,omitempty"`
+	Lecturer    string    `json:"Lecturer" yaml:"Lecturer"`
+	ClassSize   int       `json:"ClassSize" yaml:"ClassSize"`
+	Description string    `json:"Description" yaml:"Description"`
+	Credits     int       `json:"Credits" yaml:"Credits"`
+	Status      string    `json:"Status" yaml:"Status"`
+	CreatedAt   time.Time `json:"CreatedAt" yaml:"CreatedAt"`
+	UpdatedAt   time.Time `json:"UpdatedAt" yaml:"UpdatedAt"`
 }
 
-var ctx = context.Background()
+//CoursePatch holds the fields to change in a partial update. Nil fields are left as they are.
+//The lecturer may be changed by LecturerID or by name.
+type CoursePatch struct {
+	Title       *string `json:"Title" yaml:"Title"`
+	LecturerID  *string `json:"LecturerID" yaml:"LecturerID"`
+	Lecturer    *string `json:"Lecturer" yaml:"Lecturer"`
+	ClassSize   *int    `json:"ClassSize" yaml:"ClassSize"`
+	Description *string `json:"Description" yaml:"Description"`
+	Credits     *int    `json:"Credits" yaml:"Credits"`
+	Status      *string `json:"Status" yaml:"Status"`
+}
+
+//The lifecycle of a course.
+const (
+	StatusDraft     = "draft"
+	StatusPublished = "published"
+	StatusArchived  = "archived"
+)
+
+const (
+	queryCourseExist  = "SELECT EXISTS(SELECT * FROM Course WHERE CourseID=?)"
+	queryDeleteCourse = "DELETE FROM Course WHERE CourseID=?"
+	queryUpdateCourse = "UPDATE Course SET Title=?, LecturerID=?, ClassSize=?, Description=?, Credits=?, Status=?, UpdatedAt=? WHERE CourseID=?"
+	queryInsertCourse = "INSERT INTO Course (CourseID, Title, LecturerID, ClassSize, Description, Credits, Status, CreatedAt, UpdatedAt) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	queryAllCourses   = "SELECT c.CourseID, c.Title, c.LecturerID, l.Name, c.ClassSize, c.Description, c.Credits, c.Status, c.CreatedAt, c.UpdatedAt FROM Course c JOIN Lecturer l ON l.LecturerID=c.LecturerID"
+	queryGetCourse    = queryAllCourses + " WHERE c.CourseID=?"
+	queryCourseTerms  = "SELECT SemesterID FROM CourseOffering WHERE CourseID=? ORDER BY SemesterID"
+	queryLockCourse   = "SELECT CourseID, Title, LecturerID, ClassSize, Description, Credits, Status, CreatedAt, UpdatedAt FROM Course WHERE CourseID=? FOR UPDATE"
+
+	queryAllCoursesOrdered = queryAllCourses + " ORDER BY c.CourseID"
+)
+
+//QueryTimeout bounds how long a single data-access function may run. Zero disables the limit.
+var QueryTimeout = 5 * time.Second
+
+//withTimeout derives the context used for one query from the caller's (usually the HTTP request's) context.
+func withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if QueryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, QueryTimeout)
+}
+
+//ValidStatus reports whether status is one of the Status constants.
+func ValidStatus(status string) bool {
+	return status == StatusDraft || status == StatusPublished || status == StatusArchived
+}
+
+//courseFields lists the destinations of a course row returned by queryAllCourses, in column order.
+func courseFields(course *Course) []interface{} {
+	return []interface{}{&course.CourseID, &course.Title, &course.LecturerID, &course.Lecturer, &course.ClassSize,
+		&course.Description, &course.Credits, &course.Status, &course.CreatedAt, &course.UpdatedAt}
+}
+
+//lockCourse reads and locks the stored columns of a course within tx. The lecturer's name is not filled in.
+func lockCourse(ctx context.Context, tx *sql.Tx, CourseID string) (Course, error) {
+	var course Course
+	err := queryRowContext(ctx, tx, queryLockCourse, CourseID).Scan(&course.CourseID, &course.Title, &course.LecturerID,
+		&course.ClassSize, &course.Description, &course.Credits, &course.Status, &course.CreatedAt, &course.UpdatedAt)
+	return course, err
+}
+
+func CourseExist(ctx context.Context, db *sql.DB, CourseID string) (int, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
 
-func CourseExist(db *sql.DB, CourseID string) (int, error) {
-	query := fmt.Sprintln("SELECT EXISTS(SELECT * FROM Course WHERE CourseID=?)")
 	var exist int
-	err := db.QueryRowContext(ctx, query, CourseID).Scan(&exist)
+	err := queryRowContext(ctx, db, queryCourseExist, CourseID).Scan(&exist)
 	if err != nil {
-		return 0, err
+		return 0, wrapError(ctx, "CourseExist", err)
 	}
-	return exist, err
+	return exist, nil
 }
 
-func DeleteRecord(db *sql.DB, CourseID string) error {
-	query := fmt.Sprintln("DELETE FROM Course WHERE CourseID=?")
-	_, err := db.QueryContext(ctx, query, CourseID)
-	return err
+//DeleteRecord removes a course. ErrNotFound is returned if there was nothing to delete. A course that other
+//courses require is only deleted if force is true, and they lose it as a prerequisite; otherwise a RuleError
+//of kind ErrConflict naming them is returned. The prerequisites, sessions and tags of the course itself go with it,
+//but a course with enrolled or waitlisted students or with semester offerings is refused with a RuleError of kind
+//ErrConflict saying which, even when forced.
+func DeleteRecord(ctx context.Context, db *sql.DB, CourseID string, force bool) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	err := withTx(ctx, db, func(tx *sql.Tx) error {
+		//the lock keeps new prerequisites from referring to the course until it is gone
+		var current int
+		if err := queryRowContext(ctx, tx, queryLockClassSize, CourseID).Scan(&current); err != nil {
+			return err
+		}
+		blocker, err := courseBlocker(ctx, tx, CourseID)
+		if err != nil {
+			return err
+		}
+		if blocker != "" {
+			return &RuleError{ErrConflict, blocker}
+		}
+		dependents, err := courseDependents(ctx, tx, CourseID)
+		if err != nil {
+			return err
+		}
+		if len(dependents) > 0 && !force {
+			return &RuleError{ErrConflict, "the course is a prerequisite of " + strings.Join(dependents, ", ") +
+				", delete it with force=true to remove it from them"}
+		}
+		if _, err = execContext(ctx, tx, queryDeleteCourseEdges, CourseID, CourseID); err != nil {
+			return err
+		}
+		if _, err = execContext(ctx, tx, queryDeleteSessions, CourseID); err != nil {
+			return err
+		}
+		if _, err = execContext(ctx, tx, queryDeleteTags, CourseID); err != nil {
+			return err
+		}
+		result, err := execContext(ctx, tx, queryDeleteCourse, CourseID)
+		if err != nil {
+			return err
+		}
+		return affectOne(result)
+	})
+	return wrapError(ctx, "DeleteRecord", err)
 }
 
-func EditRecord(db *sql.DB, CourseID string, Title string, Lecturer string, ClassSize int) error {
-	query := fmt.Sprintln("UPDATE Course SET Title=?, Lecturer=?, ClassSize=? WHERE CourseID=?")
-	_, err := db.QueryContext(ctx, query, Title, Lecturer, ClassSize, CourseID)
-	return err
+//courseBlocker describes the rows that keep a course from being deleted, or returns "" if there are none.
+func courseBlocker(ctx context.Context, tx *sql.Tx, CourseID string) (string, error) {
+	var enrolled, waitlisted int
+	if err := queryRowContext(ctx, tx, queryCountEnrolled, CourseID).Scan(&enrolled); err != nil {
+		return "", err
+	}
+	if enrolled > 0 {
+		return "students are enrolled in the course, drop them before deleting it", nil
+	}
+	if err := queryRowContext(ctx, tx, queryCountWaitlist, CourseID).Scan(&waitlisted); err != nil {
+		return "", err
+	}
+	if waitlisted > 0 {
+		return "students are on the waitlist of the course, drop them before deleting it", nil
+	}
+
+	rows, err := queryContext(ctx, tx, queryCourseTerms, CourseID)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	semesters := []string{}
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return "", err
+		}
+		semesters = append(semesters, id)
+	}
+	if err = rows.Err(); err != nil || len(semesters) == 0 {
+		return "", err
+	}
+	return "the course is offered in semester " + strings.Join(semesters, ", ") + ", remove the offerings before deleting it", nil
+}
+
+//EditRecord updates an existing course. ErrNotFound is returned if the course has gone, e.g. deleted concurrently,
+//ErrUnknownLecturer if its lecturer does not exist, ErrOutOfDepartment if a new lecturer belongs to another
+//department and ErrClassSizeTooSmall if ClassSize is below the number of
+//enrolled students. A lecturer or ClassSize that clashes with the timetable of the course is refused with a
+//RuleError of kind ErrConflict. Seats added by a larger ClassSize are given to the waitlist in the same transaction.
+func EditRecord(ctx context.Context, db *sql.DB, course Course) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	err := withTx(ctx, db, func(tx *sql.Tx) error {
+		if err := updateCourse(ctx, tx, course); err != nil {
+			return err
+		}
+		return resize(ctx, tx, course.CourseID, course.ClassSize)
+	})
+	return wrapError(ctx, "EditRecord", err)
+}
+
+//InsertRecord creates a new course. ErrConflict is returned if the CourseID is already taken,
+//ErrUnknownLecturer if its lecturer does not exist, ErrUnknownPrefix if no department owns the prefix of
+//its CourseID and ErrOutOfDepartment if the lecturer belongs to another department.
+func InsertRecord(ctx context.Context, db *sql.DB, course Course) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	err := withTx(ctx, db, func(tx *sql.Tx) error {
+		return insertCourse(ctx, tx, course)
+	})
+	return wrapError(ctx, "InsertRecord", err)
+}
+
+//UpsertRecord creates the course, or updates it if it already exists, in a single transaction.
+//The insert is attempted first so that two concurrent upserts of a new course serialise on the primary key
+//instead of both deciding to insert. An update is refused with ErrClassSizeTooSmall if ClassSize is below
+//the number of enrolled students, and seats it adds are given to the waitlist. ErrUnknownLecturer is returned
+//if the lecturer of the course does not exist; the department rules of InsertRecord and EditRecord apply.
+func UpsertRecord(ctx context.Context, db *sql.DB, course Course) (created bool, err error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	err = withTx(ctx, db, func(tx *sql.Tx) error {
+		created = false
+		err := insertCourse(ctx, tx, course)
+		if err == nil {
+			created = true
+			return nil
+		}
+		if kindOf(err) != ErrConflict {
+			return err
+		}
+		if err := updateCourse(ctx, tx, course); err != nil {
+			return err
+		}
+		return resize(ctx, tx, course.CourseID, course.ClassSize)
+	})
+	return created, wrapError(ctx, "UpsertRecord", err)
+}
+
+//PatchRecord applies a partial update to a course and returns the result. The row is locked while the
+//patch is applied so that concurrent patches of different fields do not overwrite each other.
+//ErrClassSizeTooSmall is returned if the new ClassSize is below the number of enrolled students,
+//ErrUnknownLecturer if the new lecturer does not exist and ErrOutOfDepartment if it belongs to another
+//department. A new lecturer or ClassSize that clashes with the timetable of the course is refused with a
+//RuleError of kind ErrConflict. Seats added by a larger ClassSize are given to the waitlist.
+func PatchRecord(ctx context.Context, db *sql.DB, CourseID string, patch CoursePatch) (Course, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	var course Course
+	err := withTx(ctx, db, func(tx *sql.Tx) error {
+		var err error
+		if course, err = lockCourse(ctx, tx, CourseID); err != nil {
+			return err
+		}
+		current := course.LecturerID
+		if patch.Title != nil {
+			course.Title = *patch.Title
+		}
+		switch {
+		case patch.LecturerID != nil:
+			course.LecturerID = *patch.LecturerID
+		case patch.Lecturer != nil:
+			course.LecturerID, course.Lecturer = "", *patch.Lecturer
+		}
+		if patch.ClassSize != nil {
+			course.ClassSize = *patch.ClassSize
+		}
+		if patch.Description != nil {
+			course.Description = *patch.Description
+		}
+		if patch.Credits != nil {
+			course.Credits = *patch.Credits
+		}
+		if patch.Status != nil {
+			course.Status = *patch.Status
+		}
+		course.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+		department, err := resolveLecturer(ctx, tx, &course)
+		if err != nil {
+			return err
+		}
+		if course.LecturerID != current {
+			if err = checkScope(ctx, tx, course, department); err != nil {
+				return err
+			}
+		}
+		if err = writeCourse(ctx, tx, course); err != nil {
+			return err
+		}
+		if patch.LecturerID != nil || patch.Lecturer != nil || patch.ClassSize != nil {
+			if err = checkCourseSessions(ctx, tx, CourseID); err != nil {
+				return err
+			}
+		}
+		if patch.ClassSize == nil {
+			return nil
+		}
+		return resize(ctx, tx, CourseID, course.ClassSize)
+	})
+	return course, wrapError(ctx, "PatchRecord", err)
+}
+
+//insertCourse inserts a course within tx, looking up its lecturer first. The prefix of a new course must
+//belong to a department, and a lecturer with a department may only teach that department's courses.
+//The checks come after the insert so that an existing course still fails with ErrConflict, which UpsertRecord
+//relies on; the caller rolls the insert back on any error.
+func insertCourse(ctx context.Context, tx *sql.Tx, course Course) error {
+	lecturerDepartment, err := resolveLecturer(ctx, tx, &course)
+	if err != nil {
+		return err
+	}
+	if course.Status == "" {
+		course.Status = StatusDraft
+	}
+	course.CreatedAt = time.Now().UTC().Truncate(time.Second)
+	_, err = execContext(ctx, tx, queryInsertCourse, course.CourseID, course.Title, course.LecturerID, course.ClassSize,
+		course.Description, course.Credits, course.Status, course.CreatedAt, course.CreatedAt)
+	if err != nil {
+		return err
+	}
+	department, err := prefixDepartment(ctx, tx, coursePrefix(course.CourseID))
+	if err != nil {
+		return err
+	}
+	if department == "" {
+		return ErrUnknownPrefix
+	}
+	if lecturerDepartment != "" && lecturerDepartment != department {
+		return ErrOutOfDepartment
+	}
+	return nil
 }
 
-func InsertRecord(db *sql.DB, CourseID string, Title string, Lecturer string, ClassSize int) error {
-	query := fmt.Sprintln("INSERT INTO Course VALUES (?, ?, ?, ?)")
-	_, err := db.QueryContext(ctx, query, CourseID, Title, Lecturer, ClassSize)
+//updateCourse locks and rewrites an existing course within tx. Courses that predate their department keep
+//their lecturer, but a new lecturer is checked against the department like on insert. A new lecturer or
+//ClassSize must still fit the timetable of the course. A course given without a Status keeps its current one.
+func updateCourse(ctx context.Context, tx *sql.Tx, course Course) error {
+	current, err := lockCourse(ctx, tx, course.CourseID)
+	if err != nil {
+		return err
+	}
+	if course.Status == "" {
+		course.Status = current.Status
+	}
+	course.UpdatedAt = time.Now().UTC().Truncate(time.Second)
+	department, err := resolveLecturer(ctx, tx, &course)
+	if err != nil {
+		return err
+	}
+	if course.LecturerID != current.LecturerID {
+		if err = checkScope(ctx, tx, course, department); err != nil {
+			return err
+		}
+	}
+	if err = writeCourse(ctx, tx, course); err != nil {
+		return err
+	}
+	if course.LecturerID == current.LecturerID && course.ClassSize == current.ClassSize {
+		return nil
+	}
+	return checkCourseSessions(ctx, tx, course.CourseID)
+}
+
+//writeCourse rewrites the stored columns of an existing course within tx, except CreatedAt.
+func writeCourse(ctx context.Context, tx *sql.Tx, course Course) error {
+	_, err := execContext(ctx, tx, queryUpdateCourse, course.Title, course.LecturerID, course.ClassSize,
+		course.Description, course.Credits, course.Status, course.UpdatedAt, course.CourseID)
 	return err
 }
 
-func GetRecord(db *sql.DB, CourseID string) (Course, error) {
-	query := fmt.Sprintln("SELECT * FROM Course WHERE CourseID=?")
+func GetRecord(ctx context.Context, db *sql.DB, CourseID string) (Course, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
 	var course Course
-	err := db.QueryRowContext(ctx, query, CourseID).Scan(&course.CourseID, &course.Title, &course.Lecturer, &course.ClassSize)
-	return course, err
+	err := queryRowContext(ctx, db, queryGetCourse, CourseID).Scan(courseFields(&course)...)
+	return course, wrapError(ctx, "GetRecord", err)
 }
 
-// map this type to the record in the table
-func GetAllRecords(db *sql.DB) []Course {
+//GetAllRecords returns every course in the table.
+func GetAllRecords(ctx context.Context, db *sql.DB) ([]Course, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
 	allCourses := []Course{}
-	results, err := db.Query("Select * FROM my_db_goMicroservice1.Course")
+	results, err := queryContext(ctx, db, queryAllCourses)
 	if err != nil {
-		log.Panic(err.Error())
+		return nil, wrapError(ctx, "GetAllRecords", err)
 	}
+	defer results.Close()
 	for results.Next() { //.Next go through every single record
 		// map this type to the record in the table
 		var course Course
-		err = results.Scan(&course.CourseID, &course.Title, &course.Lecturer, &course.ClassSize)
+		err = results.Scan(courseFields(&course)...)
 		if err != nil {
-			log.Panic(err.Error())
+			return nil, wrapError(ctx, "GetAllRecords", err)
 		}
 		allCourses = append(allCourses, course)
 	}
-	return allCourses
+	if err = results.Err(); err != nil {
+		return nil, wrapError(ctx, "GetAllRecords", err)
+	}
+	return allCourses, nil
 }
@@ -0,0 +1,353 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+)
+
+//Department owns the course-code prefixes in Prefixes, the first three letters of a CourseID, so that every
+//course with one of them belongs to it. Lecturers may belong to a department too, see resolveLecturer.
+type Department struct {
+	DepartmentID string   `yaml:"DepartmentID"`
+	Name         string   `yaml:"Name"`
+	Prefixes     []string `yaml:"Prefixes"`
+}
+
+//DepartmentStats summarises the courses and lecturers of a department.
+//Seats is the total ClassSize of its courses, Enrolled and Waitlisted count students over all of them.
+type DepartmentStats struct {
+	DepartmentID string `yaml:"DepartmentID"`
+	Courses      int    `yaml:"Courses"`
+	Lecturers    int    `yaml:"Lecturers"`
+	Seats        int    `yaml:"Seats"`
+	Enrolled     int    `yaml:"Enrolled"`
+	Waitlisted   int    `yaml:"Waitlisted"`
+}
+
+const (
+	queryGetDepartment     = "SELECT DepartmentID, Name FROM Department WHERE DepartmentID=?"
+	queryLockDepartment    = queryGetDepartment + " FOR UPDATE"
+	queryAllDepartments    = "SELECT DepartmentID, Name FROM Department ORDER BY DepartmentID"
+	queryInsertDepartment  = "INSERT INTO Department (DepartmentID, Name) VALUES (?, ?)"
+	queryUpdateDepartment  = "UPDATE Department SET Name=? WHERE DepartmentID=?"
+	queryDeleteDepartment  = "DELETE FROM Department WHERE DepartmentID=?"
+	queryShareDepartmentID = "SELECT DepartmentID, Name FROM Department WHERE DepartmentID=? LOCK IN SHARE MODE"
+	queryShareDepartment   = "SELECT DepartmentID, Name FROM Department WHERE Name=? LOCK IN SHARE MODE"
+
+	queryAllPrefixes        = "SELECT DepartmentID, Prefix FROM CoursePrefix ORDER BY Prefix"
+	queryDepartmentPrefixes = "SELECT DepartmentID, Prefix FROM CoursePrefix WHERE DepartmentID=? ORDER BY Prefix"
+	queryPrefixDepartment   = "SELECT DepartmentID FROM CoursePrefix WHERE Prefix=? LOCK IN SHARE MODE"
+	queryInsertPrefix       = "INSERT INTO CoursePrefix (Prefix, DepartmentID) VALUES (?, ?)"
+	queryDeletePrefix       = "DELETE FROM CoursePrefix WHERE Prefix=?"
+	queryPrefixInUse        = "SELECT EXISTS(SELECT * FROM Course WHERE LEFT(CourseID, 3)=?)"
+
+	queryDepartmentCourses   = queryAllCourses + " JOIN CoursePrefix p ON p.Prefix=LEFT(c.CourseID, 3) WHERE p.DepartmentID=? ORDER BY c.CourseID"
+	queryDepartmentLecturers = queryAllLecturers + " WHERE l.DepartmentID=? ORDER BY l.LecturerID"
+	queryDepartmentStats     = "SELECT" +
+		" (SELECT COUNT(*) FROM Course c JOIN CoursePrefix p ON p.Prefix=LEFT(c.CourseID, 3) WHERE p.DepartmentID=?)," +
+		" (SELECT COUNT(*) FROM Lecturer WHERE DepartmentID=?)," +
+		" (SELECT COALESCE(SUM(c.ClassSize), 0) FROM Course c JOIN CoursePrefix p ON p.Prefix=LEFT(c.CourseID, 3) WHERE p.DepartmentID=?)," +
+		" (SELECT COUNT(*) FROM Enrollment e JOIN CoursePrefix p ON p.Prefix=LEFT(e.CourseID, 3) WHERE p.DepartmentID=?)," +
+		" (SELECT COUNT(*) FROM Waitlist w JOIN CoursePrefix p ON p.Prefix=LEFT(w.CourseID, 3) WHERE p.DepartmentID=?)"
+	queryLecturerOutOfScope = "SELECT c.CourseID FROM (SELECT CourseID, LecturerID FROM Course UNION ALL SELECT CourseID, LecturerID FROM CourseOffering) c" +
+		" JOIN CoursePrefix p ON p.Prefix=LEFT(c.CourseID, 3)" +
+		" WHERE c.LecturerID=? AND p.DepartmentID<>? ORDER BY c.CourseID LIMIT 1"
+)
+
+//GetDepartment returns one department with its prefixes. ErrDepartmentNotFound is returned if there is no such department.
+func GetDepartment(ctx context.Context, db *sql.DB, DepartmentID string) (Department, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	department, err := getDepartment(ctx, db, queryGetDepartment, DepartmentID)
+	return department, wrapError(ctx, "GetDepartment", err)
+}
+
+//GetAllDepartments returns every department with its prefixes, ordered by DepartmentID.
+func GetAllDepartments(ctx context.Context, db *sql.DB) ([]Department, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	departments := []Department{}
+	rows, err := queryContext(ctx, db, queryAllDepartments)
+	if err != nil {
+		return nil, wrapError(ctx, "GetAllDepartments", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		department := Department{Prefixes: []string{}}
+		if err = rows.Scan(&department.DepartmentID, &department.Name); err != nil {
+			return nil, wrapError(ctx, "GetAllDepartments", err)
+		}
+		departments = append(departments, department)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, wrapError(ctx, "GetAllDepartments", err)
+	}
+
+	prefixes, err := loadPrefixes(ctx, db, queryAllPrefixes)
+	if err != nil {
+		return nil, wrapError(ctx, "GetAllDepartments", err)
+	}
+	for i := range departments {
+		if p, ok := prefixes[departments[i].DepartmentID]; ok {
+			departments[i].Prefixes = p
+		}
+	}
+	return departments, nil
+}
+
+//InsertDepartment creates a department owning the given prefixes. ErrConflict is returned if the DepartmentID
+//or the Name is already taken, ErrPrefixTaken if another department owns one of the prefixes.
+func InsertDepartment(ctx context.Context, db *sql.DB, department Department) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	err := withTx(ctx, db, func(tx *sql.Tx) error {
+		if _, err := execContext(ctx, tx, queryInsertDepartment, department.DepartmentID, department.Name); err != nil {
+			return err
+		}
+		return setPrefixes(ctx, tx, department)
+	})
+	return wrapError(ctx, "InsertDepartment", err)
+}
+
+//UpdateDepartment renames a department and replaces its prefixes. ErrDepartmentNotFound is returned if there
+//is no such department, ErrConflict if another department has the Name, ErrPrefixTaken if another department
+//owns one of the new prefixes and ErrPrefixInUse if a prefix being removed still has courses.
+func UpdateDepartment(ctx context.Context, db *sql.DB, department Department) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	err := withTx(ctx, db, func(tx *sql.Tx) error {
+		if _, err := getDepartment(ctx, tx, queryLockDepartment, department.DepartmentID); err != nil {
+			return err
+		}
+		if _, err := execContext(ctx, tx, queryUpdateDepartment, department.Name, department.DepartmentID); err != nil {
+			return err
+		}
+		return setPrefixes(ctx, tx, department)
+	})
+	return wrapError(ctx, "UpdateDepartment", err)
+}
+
+//DeleteDepartment removes a department and releases its prefixes. ErrDepartmentHasCourses is returned while
+//courses use its prefixes, ErrDepartmentHasLecturers while lecturers belong to it and ErrDepartmentNotFound
+//if there was nothing to delete.
+func DeleteDepartment(ctx context.Context, db *sql.DB, DepartmentID string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	err := withTx(ctx, db, func(tx *sql.Tx) error {
+		department, err := getDepartment(ctx, tx, queryLockDepartment, DepartmentID)
+		if err != nil {
+			return err
+		}
+		department.Prefixes = []string{}
+		if err = setPrefixes(ctx, tx, department); err == ErrPrefixInUse {
+			return ErrDepartmentHasCourses
+		} else if err != nil {
+			return err
+		}
+		_, err = execContext(ctx, tx, queryDeleteDepartment, DepartmentID)
+		if err != nil && kindOf(err) == ErrConflict { //the Lecturer foreign key refuses the delete
+			return ErrDepartmentHasLecturers
+		}
+		return err
+	})
+	return wrapError(ctx, "DeleteDepartment", err)
+}
+
+//GetDepartmentCourses returns the courses whose prefix belongs to a department, ordered by CourseID.
+//ErrDepartmentNotFound is returned if there is no such department.
+func GetDepartmentCourses(ctx context.Context, db *sql.DB, DepartmentID string) ([]Course, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	if _, err := getDepartment(ctx, db, queryGetDepartment, DepartmentID); err != nil {
+		return nil, wrapError(ctx, "GetDepartmentCourses", err)
+	}
+	courses := []Course{}
+	rows, err := queryContext(ctx, db, queryDepartmentCourses, DepartmentID)
+	if err != nil {
+		return nil, wrapError(ctx, "GetDepartmentCourses", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var course Course
+		if err = rows.Scan(courseFields(&course)...); err != nil {
+			return nil, wrapError(ctx, "GetDepartmentCourses", err)
+		}
+		courses = append(courses, course)
+	}
+	return courses, wrapError(ctx, "GetDepartmentCourses", rows.Err())
+}
+
+//GetDepartmentLecturers returns the lecturers belonging to a department, ordered by LecturerID.
+//ErrDepartmentNotFound is returned if there is no such department.
+func GetDepartmentLecturers(ctx context.Context, db *sql.DB, DepartmentID string) ([]Lecturer, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	if _, err := getDepartment(ctx, db, queryGetDepartment, DepartmentID); err != nil {
+		return nil, wrapError(ctx, "GetDepartmentLecturers", err)
+	}
+	lecturers, err := scanLecturers(ctx, db, queryDepartmentLecturers, DepartmentID)
+	return lecturers, wrapError(ctx, "GetDepartmentLecturers", err)
+}
+
+//GetDepartmentStats counts the courses, lecturers, seats and students of a department.
+//ErrDepartmentNotFound is returned if there is no such department.
+func GetDepartmentStats(ctx context.Context, db *sql.DB, DepartmentID string) (DepartmentStats, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	stats := DepartmentStats{DepartmentID: DepartmentID}
+	if _, err := getDepartment(ctx, db, queryGetDepartment, DepartmentID); err != nil {
+		return stats, wrapError(ctx, "GetDepartmentStats", err)
+	}
+	err := queryRowContext(ctx, db, queryDepartmentStats, DepartmentID, DepartmentID, DepartmentID, DepartmentID, DepartmentID).
+		Scan(&stats.Courses, &stats.Lecturers, &stats.Seats, &stats.Enrolled, &stats.Waitlisted)
+	return stats, wrapError(ctx, "GetDepartmentStats", err)
+}
+
+//getDepartment reads a department with query, either queryGetDepartment or the locking form, and its prefixes.
+func getDepartment(ctx context.Context, q querier, query string, DepartmentID string) (Department, error) {
+	department := Department{Prefixes: []string{}}
+	err := queryRowContext(ctx, q, query, DepartmentID).Scan(&department.DepartmentID, &department.Name)
+	if err == sql.ErrNoRows {
+		return department, ErrDepartmentNotFound
+	} else if err != nil {
+		return department, err
+	}
+	prefixes, err := loadPrefixes(ctx, q, queryDepartmentPrefixes, DepartmentID)
+	if p, ok := prefixes[DepartmentID]; ok {
+		department.Prefixes = p
+	}
+	return department, err
+}
+
+//loadPrefixes reads prefixes with query and groups them by department.
+func loadPrefixes(ctx context.Context, q querier, query string, args ...interface{}) (map[string][]string, error) {
+	rows, err := queryContext(ctx, q, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	prefixes := map[string][]string{}
+	for rows.Next() {
+		var departmentID, prefix string
+		if err = rows.Scan(&departmentID, &prefix); err != nil {
+			return nil, err
+		}
+		prefixes[departmentID] = append(prefixes[departmentID], prefix)
+	}
+	return prefixes, rows.Err()
+}
+
+//setPrefixes makes department.Prefixes the prefixes owned by the department, adding and removing rows as needed.
+func setPrefixes(ctx context.Context, tx *sql.Tx, department Department) error {
+	current, err := loadPrefixes(ctx, tx, queryDepartmentPrefixes, department.DepartmentID)
+	if err != nil {
+		return err
+	}
+	wanted := map[string]bool{}
+	for _, prefix := range department.Prefixes {
+		wanted[prefix] = true
+	}
+	owned := map[string]bool{}
+	for _, prefix := range current[department.DepartmentID] {
+		owned[prefix] = true
+		if wanted[prefix] {
+			continue
+		}
+		var inUse int
+		if err := queryRowContext(ctx, tx, queryPrefixInUse, prefix).Scan(&inUse); err != nil {
+			return err
+		}
+		if inUse != 0 {
+			return ErrPrefixInUse
+		}
+		if _, err := execContext(ctx, tx, queryDeletePrefix, prefix); err != nil {
+			return err
+		}
+	}
+
+	added := []string{}
+	for prefix := range wanted {
+		if !owned[prefix] {
+			added = append(added, prefix)
+		}
+	}
+	sort.Strings(added) //a fixed order so that concurrent updates take their locks in the same order
+	for _, prefix := range added {
+		owner, err := prefixDepartment(ctx, tx, prefix)
+		if err != nil {
+			return err
+		}
+		if owner != "" {
+			return ErrPrefixTaken
+		}
+		if _, err := execContext(ctx, tx, queryInsertPrefix, prefix, department.DepartmentID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//prefixDepartment returns the department owning prefix, or "" if it is not registered.
+//The prefix row is share locked until the transaction ends so it cannot move to another department meanwhile.
+func prefixDepartment(ctx context.Context, q querier, prefix string) (string, error) {
+	var departmentID string
+	err := queryRowContext(ctx, q, queryPrefixDepartment, prefix).Scan(&departmentID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return departmentID, err
+}
+
+//coursePrefix returns the prefix of a CourseID, the part a department owns.
+func coursePrefix(CourseID string) string {
+	if len(CourseID) < 3 {
+		return CourseID
+	}
+	return CourseID[:3]
+}
+
+//checkScope enforces that a lecturer who belongs to a department is only assigned to courses whose prefix it
+//owns. Lecturers without a department, and courses whose prefix is not registered, are not restricted.
+func checkScope(ctx context.Context, tx *sql.Tx, course Course, lecturerDepartment string) error {
+	if lecturerDepartment == "" {
+		return nil
+	}
+	department, err := prefixDepartment(ctx, tx, coursePrefix(course.CourseID))
+	if err != nil {
+		return err
+	}
+	if department != "" && department != lecturerDepartment {
+		return ErrOutOfDepartment
+	}
+	return nil
+}
+
+//resolveDepartment fills in the DepartmentID of a lecturer being written from its Department name when no ID
+//was given, and the name from the ID otherwise. A lecturer with neither belongs to no department.
+//The department row is share locked until the transaction ends so it cannot be deleted meanwhile.
+//ErrUnknownDepartment is returned if there is no such department.
+func resolveDepartment(ctx context.Context, tx *sql.Tx, lecturer *Lecturer) error {
+	var row *sql.Row
+	switch {
+	case lecturer.DepartmentID != "":
+		row = queryRowContext(ctx, tx, queryShareDepartmentID, lecturer.DepartmentID)
+	case lecturer.Department != "":
+		row = queryRowContext(ctx, tx, queryShareDepartment, lecturer.Department)
+	default:
+		return nil
+	}
+	err := row.Scan(&lecturer.DepartmentID, &lecturer.Department)
+	if err == sql.ErrNoRows {
+		err = ErrUnknownDepartment
+	}
+	return err
+}
@@ -0,0 +1,345 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+//Student is a person who can enrol in courses.
+type Student struct {
+	StudentID string `yaml:"StudentID"`
+	Name      string `yaml:"Name"`
+	Email     string `yaml:"Email"`
+}
+
+//Status of an Enrollment.
+const (
+	StatusEnrolled   = "enrolled"
+	StatusWaitlisted = "waitlisted"
+)
+
+//Enrollment records that a student holds a seat in a course, or is waiting for one.
+//EnrolledAt is when the seat was taken, or when the student joined the waitlist.
+//Position is the place on the waitlist starting at 1, and zero for enrolled students.
+type Enrollment struct {
+	CourseID   string    `yaml:"CourseID"`
+	StudentID  string    `yaml:"StudentID"`
+	Status     string    `yaml:"Status"`
+	EnrolledAt time.Time `yaml:"EnrolledAt"`
+	Position   int       `json:",omitempty" yaml:"Position,omitempty"`
+}
+
+const (
+	queryStudentExist  = "SELECT EXISTS(SELECT * FROM Student WHERE StudentID=?)"
+	queryGetStudent    = "SELECT StudentID, Name, Email FROM Student WHERE StudentID=?"
+	queryAllStudents   = "SELECT StudentID, Name, Email FROM Student ORDER BY StudentID"
+	queryInsertStudent = "INSERT INTO Student (StudentID, Name, Email) VALUES (?, ?, ?)"
+	queryDeleteStudent = "DELETE FROM Student WHERE StudentID=?"
+
+	queryLockClassSize     = "SELECT ClassSize FROM Course WHERE CourseID=? FOR UPDATE"
+	queryCountEnrolled     = "SELECT COUNT(*) FROM Enrollment WHERE CourseID=?"
+	queryEnrollmentExist   = "SELECT EXISTS(SELECT * FROM Enrollment WHERE CourseID=? AND StudentID=?)"
+	queryInsertEnrollment  = "INSERT INTO Enrollment (CourseID, StudentID, EnrolledAt) VALUES (?, ?, ?)"
+	queryDeleteEnrollment  = "DELETE FROM Enrollment WHERE CourseID=? AND StudentID=?"
+	queryGetEnrollment     = "SELECT CourseID, StudentID, EnrolledAt FROM Enrollment WHERE CourseID=? AND StudentID=?"
+	queryCourseEnrollments = "SELECT CourseID, StudentID, EnrolledAt FROM Enrollment WHERE CourseID=? ORDER BY EnrolledAt, StudentID"
+
+	queryWaitlistExist  = "SELECT EXISTS(SELECT * FROM Waitlist WHERE CourseID=? AND StudentID=?)"
+	queryCountWaitlist  = "SELECT COUNT(*) FROM Waitlist WHERE CourseID=?"
+	queryInsertWaitlist = "INSERT INTO Waitlist (CourseID, StudentID, RequestedAt) VALUES (?, ?, ?)"
+	queryDeleteWaitlist = "DELETE FROM Waitlist WHERE CourseID=? AND StudentID=?"
+	queryCourseWaitlist = "SELECT CourseID, StudentID, RequestedAt FROM Waitlist WHERE CourseID=? ORDER BY RequestedAt, StudentID"
+	queryNextWaitlisted = queryCourseWaitlist + " LIMIT ?"
+)
+
+//GetStudent returns one student. ErrStudentNotFound is returned if there is no such student.
+func GetStudent(ctx context.Context, db *sql.DB, StudentID string) (Student, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	var student Student
+	err := queryRowContext(ctx, db, queryGetStudent, StudentID).Scan(&student.StudentID, &student.Name, &student.Email)
+	if err == sql.ErrNoRows {
+		err = ErrStudentNotFound
+	}
+	return student, wrapError(ctx, "GetStudent", err)
+}
+
+//GetAllStudents returns every student ordered by StudentID.
+func GetAllStudents(ctx context.Context, db *sql.DB) ([]Student, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	students := []Student{}
+	rows, err := queryContext(ctx, db, queryAllStudents)
+	if err != nil {
+		return nil, wrapError(ctx, "GetAllStudents", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var student Student
+		if err = rows.Scan(&student.StudentID, &student.Name, &student.Email); err != nil {
+			return nil, wrapError(ctx, "GetAllStudents", err)
+		}
+		students = append(students, student)
+	}
+	return students, wrapError(ctx, "GetAllStudents", rows.Err())
+}
+
+//InsertStudent creates a new student. ErrConflict is returned if the StudentID is already taken.
+func InsertStudent(ctx context.Context, db *sql.DB, student Student) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	_, err := execContext(ctx, db, queryInsertStudent, student.StudentID, student.Name, student.Email)
+	return wrapError(ctx, "InsertStudent", err)
+}
+
+//DeleteStudent removes a student who holds no seats and waits for none. ErrStudentHasEnrolments is
+//returned while the student is still enrolled or waitlisted, ErrStudentNotFound if there was nothing to delete.
+func DeleteStudent(ctx context.Context, db *sql.DB, StudentID string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	result, err := execContext(ctx, db, queryDeleteStudent, StudentID)
+	switch {
+	case err != nil && kindOf(err) == ErrConflict: //the Enrollment or Waitlist foreign key refuses the delete
+		err = ErrStudentHasEnrolments
+	case err == nil && affectOne(result) == sql.ErrNoRows:
+		err = ErrStudentNotFound
+	}
+	return wrapError(ctx, "DeleteStudent", err)
+}
+
+//Enroll gives the student a seat in the course, or a place at the end of its waitlist when the course is full.
+//The course row is locked while the seats are counted, so concurrent enrolments, and changes to ClassSize,
+//queue up behind each other and the course can never be overfilled. ErrAlreadyEnrolled, ErrAlreadyWaitlisted,
+//ErrStudentNotFound or ErrNotFound for a missing course are returned when the student cannot be added.
+func Enroll(ctx context.Context, db *sql.DB, CourseID string, StudentID string) (Enrollment, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	var enrollment Enrollment
+	err := withTx(ctx, db, func(tx *sql.Tx) error {
+		enrollment = Enrollment{CourseID: CourseID, StudentID: StudentID}
+		var classSize int
+		if err := queryRowContext(ctx, tx, queryLockClassSize, CourseID).Scan(&classSize); err != nil {
+			return err
+		}
+		var exist int
+		if err := queryRowContext(ctx, tx, queryStudentExist, StudentID).Scan(&exist); err != nil {
+			return err
+		}
+		if exist == 0 {
+			return ErrStudentNotFound
+		}
+		if err := queryRowContext(ctx, tx, queryEnrollmentExist, CourseID, StudentID).Scan(&exist); err != nil {
+			return err
+		}
+		if exist == 1 {
+			return ErrAlreadyEnrolled
+		}
+		if err := queryRowContext(ctx, tx, queryWaitlistExist, CourseID, StudentID).Scan(&exist); err != nil {
+			return err
+		}
+		if exist == 1 {
+			return ErrAlreadyWaitlisted
+		}
+
+		enrolled, err := countEnrolled(ctx, tx, CourseID)
+		if err != nil {
+			return err
+		}
+		if enrolled < classSize {
+			enrollment.Status = StatusEnrolled
+			enrollment.EnrolledAt = time.Now().UTC().Truncate(time.Second)
+			_, err = execContext(ctx, tx, queryInsertEnrollment, CourseID, StudentID, enrollment.EnrolledAt)
+			return err
+		}
+
+		//the course is full, join the end of the waitlist
+		enrollment.Status = StatusWaitlisted
+		enrollment.EnrolledAt = time.Now().UTC().Truncate(time.Microsecond)
+		if _, err = execContext(ctx, tx, queryInsertWaitlist, CourseID, StudentID, enrollment.EnrolledAt); err != nil {
+			return err
+		}
+		return queryRowContext(ctx, tx, queryCountWaitlist, CourseID).Scan(&enrollment.Position)
+	})
+	return enrollment, wrapError(ctx, "Enroll", err)
+}
+
+//Unenroll gives up the student's seat in the course, promoting the first student on the waitlist into it,
+//or takes the student off the waitlist. ErrNotEnrolled is returned if the student was neither.
+//The students promoted are returned.
+func Unenroll(ctx context.Context, db *sql.DB, CourseID string, StudentID string) (promoted []string, err error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	err = withTx(ctx, db, func(tx *sql.Tx) error {
+		promoted = nil
+		var classSize int
+		if err := queryRowContext(ctx, tx, queryLockClassSize, CourseID).Scan(&classSize); err != nil {
+			return err
+		}
+		result, err := execContext(ctx, tx, queryDeleteEnrollment, CourseID, StudentID)
+		if err != nil {
+			return err
+		}
+		if affectOne(result) == nil { //a seat came free
+			promoted, err = promote(ctx, tx, CourseID, classSize)
+			return err
+		}
+
+		result, err = execContext(ctx, tx, queryDeleteWaitlist, CourseID, StudentID)
+		if err != nil {
+			return err
+		}
+		if affectOne(result) == sql.ErrNoRows {
+			return ErrNotEnrolled
+		}
+		return nil
+	})
+	return promoted, wrapError(ctx, "Unenroll", err)
+}
+
+//GetEnrollment returns the enrolment of the student in the course, with the waitlist position if waiting.
+//ErrNotEnrolled is returned if the student is neither enrolled nor waitlisted.
+func GetEnrollment(ctx context.Context, db *sql.DB, CourseID string, StudentID string) (Enrollment, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	enrollment := Enrollment{Status: StatusEnrolled}
+	err := queryRowContext(ctx, db, queryGetEnrollment, CourseID, StudentID).Scan(&enrollment.CourseID, &enrollment.StudentID, &enrollment.EnrolledAt)
+	if err != sql.ErrNoRows {
+		return enrollment, wrapError(ctx, "GetEnrollment", err)
+	}
+
+	waitlist, err := courseWaitlist(ctx, db, CourseID)
+	if err != nil {
+		return enrollment, wrapError(ctx, "GetEnrollment", err)
+	}
+	for _, waiting := range waitlist {
+		if waiting.StudentID == StudentID {
+			return waiting, nil
+		}
+	}
+	return Enrollment{}, wrapError(ctx, "GetEnrollment", ErrNotEnrolled)
+}
+
+//GetEnrollments returns the course together with its enrolments in the order the seats were taken,
+//and its waitlist in order of position. ErrNotFound is returned if there is no such course.
+func GetEnrollments(ctx context.Context, db *sql.DB, CourseID string) (Course, []Enrollment, []Enrollment, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	var course Course
+	err := queryRowContext(ctx, db, queryGetCourse, CourseID).Scan(courseFields(&course)...)
+	if err != nil {
+		return course, nil, nil, wrapError(ctx, "GetEnrollments", err)
+	}
+
+	enrollments := []Enrollment{}
+	rows, err := queryContext(ctx, db, queryCourseEnrollments, CourseID)
+	if err != nil {
+		return course, nil, nil, wrapError(ctx, "GetEnrollments", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		enrollment := Enrollment{Status: StatusEnrolled}
+		if err = rows.Scan(&enrollment.CourseID, &enrollment.StudentID, &enrollment.EnrolledAt); err != nil {
+			return course, nil, nil, wrapError(ctx, "GetEnrollments", err)
+		}
+		enrollments = append(enrollments, enrollment)
+	}
+	if err = rows.Err(); err != nil {
+		return course, nil, nil, wrapError(ctx, "GetEnrollments", err)
+	}
+
+	waitlist, err := courseWaitlist(ctx, db, CourseID)
+	return course, enrollments, waitlist, wrapError(ctx, "GetEnrollments", err)
+}
+
+//courseWaitlist returns the waitlist of a course with the position of each student.
+func courseWaitlist(ctx context.Context, q querier, CourseID string) ([]Enrollment, error) {
+	waitlist := []Enrollment{}
+	rows, err := queryContext(ctx, q, queryCourseWaitlist, CourseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		waiting := Enrollment{Status: StatusWaitlisted, Position: len(waitlist) + 1}
+		if err = rows.Scan(&waiting.CourseID, &waiting.StudentID, &waiting.EnrolledAt); err != nil {
+			return nil, err
+		}
+		waitlist = append(waitlist, waiting)
+	}
+	return waitlist, rows.Err()
+}
+
+//promote moves students from the head of the waitlist into the free seats of the course, returning their IDs.
+//The caller must hold the lock on the course row and pass its current ClassSize.
+func promote(ctx context.Context, tx *sql.Tx, CourseID string, classSize int) ([]string, error) {
+	enrolled, err := countEnrolled(ctx, tx, CourseID)
+	if err != nil || enrolled >= classSize {
+		return nil, err
+	}
+
+	//read the whole batch before writing, a statement cannot run while rows are still open on the connection
+	next, err := queryContext(ctx, tx, queryNextWaitlisted, CourseID, classSize-enrolled)
+	if err != nil {
+		return nil, err
+	}
+	promoted := []string{}
+	for next.Next() {
+		var courseID, studentID string
+		var requestedAt time.Time
+		if err = next.Scan(&courseID, &studentID, &requestedAt); err != nil {
+			next.Close()
+			return nil, err
+		}
+		promoted = append(promoted, studentID)
+	}
+	next.Close()
+	if err = next.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, studentID := range promoted {
+		if _, err = execContext(ctx, tx, queryDeleteWaitlist, CourseID, studentID); err != nil {
+			return nil, err
+		}
+		if _, err = execContext(ctx, tx, queryInsertEnrollment, CourseID, studentID, now); err != nil {
+			return nil, err
+		}
+	}
+	if len(promoted) > 0 {
+		metrics.Add("waitlistPromotions", int64(len(promoted)))
+	}
+	return promoted, nil
+}
+
+//countEnrolled counts the seats taken in a course. Callers hold the lock on the course row,
+//which every enrolment takes first, so the count cannot change before they commit.
+func countEnrolled(ctx context.Context, q querier, CourseID string) (int, error) {
+	var enrolled int
+	err := queryRowContext(ctx, q, queryCountEnrolled, CourseID).Scan(&enrolled)
+	return enrolled, err
+}
+
+//resize checks a new ClassSize of a course and fills any seats it adds from the waitlist. The caller must
+//hold the lock on the course row and call resize after writing the new ClassSize, in the same transaction.
+//ErrClassSizeTooSmall is returned if classSize would leave enrolled students without a seat.
+func resize(ctx context.Context, tx *sql.Tx, CourseID string, classSize int) error {
+	enrolled, err := countEnrolled(ctx, tx, CourseID)
+	if err != nil {
+		return err
+	}
+	if classSize < enrolled {
+		return ErrClassSizeTooSmall
+	}
+	_, err = promote(ctx, tx, CourseID, classSize)
+	return err
+}
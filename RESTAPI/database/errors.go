@@ -0,0 +1,170 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+//Kinds of failure reported by the data-access functions. Callers should test for them with errors.Is.
+var (
+	ErrNotFound    = errors.New("record not found")
+	ErrConflict    = errors.New("record conflicts with existing data")
+	ErrUnavailable = errors.New("database unavailable")
+	ErrTimeout     = errors.New("query timed out")
+	ErrInvalid     = errors.New("record refers to missing data")
+)
+
+//RuleError is a violation of a rule the package enforces in code rather than with a table constraint,
+//such as a student enrolling twice. Kind is the sentinel it matches, Message explains the violation to the client.
+type RuleError struct {
+	Kind    error
+	Message string
+}
+
+func (e *RuleError) Error() string { return e.Message }
+
+//Rules enforced by the enrolment functions.
+var (
+	ErrAlreadyEnrolled      = &RuleError{ErrConflict, "the student is already enrolled in the course"}
+	ErrAlreadyWaitlisted    = &RuleError{ErrConflict, "the student is already on the waitlist of the course"}
+	ErrClassSizeTooSmall    = &RuleError{ErrConflict, "ClassSize cannot be lower than the number of enrolled students"}
+	ErrStudentHasEnrolments = &RuleError{ErrConflict, "the student is still enrolled in or waitlisted for courses"}
+	ErrStudentNotFound      = &RuleError{ErrNotFound, "no student found"}
+	ErrNotEnrolled          = &RuleError{ErrNotFound, "the student is neither enrolled in nor waitlisted for the course"}
+)
+
+//Rules enforced by the prerequisite functions.
+var (
+	ErrOwnPrerequisite = &RuleError{ErrConflict, "a course cannot be its own prerequisite"}
+	ErrNotPrerequisite = &RuleError{ErrNotFound, "the course is not a prerequisite of the other"}
+)
+
+//Rules enforced by the lecturer functions.
+var (
+	ErrLecturerNotFound   = &RuleError{ErrNotFound, "no lecturer found"}
+	ErrUnknownLecturer    = &RuleError{ErrInvalid, "the lecturer does not exist, add it at /api/v1/lecturers first"}
+	ErrLecturerHasCourses = &RuleError{ErrConflict, "the lecturer still teaches courses"}
+)
+
+//Rules enforced by the department functions.
+var (
+	ErrDepartmentNotFound     = &RuleError{ErrNotFound, "no department found"}
+	ErrUnknownDepartment      = &RuleError{ErrInvalid, "the department does not exist, add it at /api/v1/departments first"}
+	ErrUnknownPrefix          = &RuleError{ErrInvalid, "no department owns the prefix of the Course ID, add it to one at /api/v1/departments first"}
+	ErrOutOfDepartment        = &RuleError{ErrInvalid, "the lecturer belongs to another department than the course"}
+	ErrPrefixTaken            = &RuleError{ErrConflict, "the prefix belongs to another department"}
+	ErrPrefixInUse            = &RuleError{ErrConflict, "a prefix cannot be removed while courses use it"}
+	ErrDepartmentHasCourses   = &RuleError{ErrConflict, "courses still use the prefixes of the department"}
+	ErrDepartmentHasLecturers = &RuleError{ErrConflict, "lecturers still belong to the department"}
+)
+
+//Rules enforced by the semester and offering functions.
+var (
+	ErrSemesterNotFound     = &RuleError{ErrNotFound, "no semester found"}
+	ErrOfferingNotFound     = &RuleError{ErrNotFound, "the course is not offered in the semester"}
+	ErrSemesterHasOfferings = &RuleError{ErrConflict, "courses are still offered in the semester"}
+	ErrNoNextSemester       = &RuleError{ErrNotFound, "there is no later semester to roll forward into, add it at /api/v1/semesters first"}
+	ErrRollIntoItself       = &RuleError{ErrConflict, "a semester cannot be rolled forward into itself"}
+)
+
+//Rules enforced by the room and session functions.
+var (
+	ErrRoomNotFound    = &RuleError{ErrNotFound, "no room found"}
+	ErrUnknownRoom     = &RuleError{ErrInvalid, "the room does not exist, add it at /api/v1/rooms first"}
+	ErrRoomInUse       = &RuleError{ErrConflict, "sessions are still held in the room"}
+	ErrSessionNotFound = &RuleError{ErrNotFound, "the course has no such session"}
+)
+
+//Rules enforced by the tag functions.
+var (
+	ErrTagNotFound = &RuleError{ErrNotFound, "the course does not have the tag"}
+)
+
+//MySQL server error numbers that are mapped onto the error kinds above.
+const (
+	mysqlDuplicateEntry     = 1062
+	mysqlRowIsReferenced    = 1451
+	mysqlNoReferencedRow    = 1452
+	mysqlLockWaitTimeout    = 1205
+	mysqlDeadlock           = 1213
+	mysqlTooManyConnections = 1040
+)
+
+//Error is returned by every data-access function in this package.
+//Op names the function that failed, Kind is one of the sentinel errors (or nil when the
+//failure does not fit any of them) and Err is the underlying driver error.
+type Error struct {
+	Op   string
+	Kind error
+	Err  error
+}
+
+func (e *Error) Error() string {
+	if e.Kind != nil && e.Err != e.Kind {
+		return e.Op + ": " + e.Kind.Error() + ": " + e.Err.Error()
+	}
+	return e.Op + ": " + e.Err.Error()
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+//Is allows errors.Is(err, ErrNotFound) and friends to match on the kind of the error.
+func (e *Error) Is(target error) bool {
+	return e.Kind != nil && target == e.Kind
+}
+
+//wrapError classifies err and wraps it with the name of the failing operation. A nil err stays nil.
+//Once ctx is done the driver error is usually just a symptom, so the context error is reported instead.
+func wrapError(ctx context.Context, op string, err error) error {
+	if err == nil {
+		return nil
+	}
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		metrics.Add("queriesTimedOut", 1)
+		return &Error{Op: op, Kind: ErrTimeout, Err: ctx.Err()}
+	case context.Canceled:
+		metrics.Add("queriesCancelled", 1)
+		return &Error{Op: op, Kind: ErrUnavailable, Err: ctx.Err()}
+	}
+	kind := kindOf(err)
+	var rule *RuleError
+	if kind != ErrNotFound && !errors.As(err, &rule) { //missing records and broken rules are the client's doing
+		metrics.Add("queryErrors", 1)
+	}
+	return &Error{Op: op, Kind: kind, Err: err}
+}
+
+//kindOf maps driver and network errors onto the sentinel error kinds.
+func kindOf(err error) error {
+	var mysqlErr *mysql.MySQLError
+	var netErr net.Error
+	var rule *RuleError
+	switch {
+	case errors.As(err, &rule):
+		return rule.Kind
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrNotFound
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrTimeout
+	case errors.Is(err, context.Canceled):
+		return ErrUnavailable
+	case errors.As(err, &mysqlErr):
+		switch mysqlErr.Number {
+		case mysqlDuplicateEntry, mysqlRowIsReferenced, mysqlNoReferencedRow:
+			return ErrConflict
+		case mysqlLockWaitTimeout, mysqlDeadlock, mysqlTooManyConnections:
+			return ErrUnavailable
+		}
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, mysql.ErrInvalidConn), errors.Is(err, sql.ErrConnDone):
+		return ErrUnavailable
+	case errors.As(err, &netErr):
+		return ErrUnavailable
+	}
+	return nil
+}
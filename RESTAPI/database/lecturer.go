@@ -0,0 +1,192 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+//Lecturer teaches courses. Courses refer to the lecturer by LecturerID, so a rename is a single update.
+//A lecturer may belong to a department, kept as DepartmentID with the Department name joined in like the
+//lecturer of a course. Either may be given when writing, and a lecturer with neither belongs to no department.
+type Lecturer struct {
+	LecturerID   string `yaml:"LecturerID"`
+	Name         string `yaml:"Name"`
+	Email        string `yaml:"Email"`
+	DepartmentID string `json:",omitempty" yaml:"DepartmentID,omitempty"`
+	Department   string `yaml:"Department"`
+}
+
+const (
+	queryAllLecturers      = "SELECT l.LecturerID, l.Name, l.Email, COALESCE(l.DepartmentID, ''), COALESCE(d.Name, '') FROM Lecturer l LEFT JOIN Department d ON d.DepartmentID=l.DepartmentID"
+	queryGetLecturer       = queryAllLecturers + " WHERE l.LecturerID=?"
+	queryAllLecturersByID  = queryAllLecturers + " ORDER BY l.LecturerID"
+	queryInsertLecturer    = "INSERT INTO Lecturer (LecturerID, Name, Email, DepartmentID) VALUES (?, ?, ?, ?)"
+	queryUpdateLecturer    = "UPDATE Lecturer SET Name=?, Email=?, DepartmentID=? WHERE LecturerID=?"
+	queryDeleteLecturer    = "DELETE FROM Lecturer WHERE LecturerID=?"
+	queryLecturerCourses   = queryAllCourses + " WHERE c.LecturerID=? ORDER BY c.CourseID"
+	queryShareLecturerByID = "SELECT LecturerID, Name, COALESCE(DepartmentID, '') FROM Lecturer WHERE LecturerID=? LOCK IN SHARE MODE"
+	queryShareLecturerName = "SELECT LecturerID, Name, COALESCE(DepartmentID, '') FROM Lecturer WHERE Name=? LOCK IN SHARE MODE"
+)
+
+//GetLecturer returns one lecturer. ErrLecturerNotFound is returned if there is no such lecturer.
+func GetLecturer(ctx context.Context, db *sql.DB, LecturerID string) (Lecturer, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	lecturer, err := getLecturer(ctx, db, LecturerID)
+	return lecturer, wrapError(ctx, "GetLecturer", err)
+}
+
+//GetAllLecturers returns every lecturer ordered by LecturerID.
+func GetAllLecturers(ctx context.Context, db *sql.DB) ([]Lecturer, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	lecturers, err := scanLecturers(ctx, db, queryAllLecturersByID)
+	return lecturers, wrapError(ctx, "GetAllLecturers", err)
+}
+
+//InsertLecturer creates a new lecturer. ErrConflict is returned if the LecturerID or the Name is already taken,
+//ErrUnknownDepartment if its department does not exist.
+func InsertLecturer(ctx context.Context, db *sql.DB, lecturer Lecturer) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	err := withTx(ctx, db, func(tx *sql.Tx) error {
+		if err := resolveDepartment(ctx, tx, &lecturer); err != nil {
+			return err
+		}
+		_, err := execContext(ctx, tx, queryInsertLecturer, lecturer.LecturerID, lecturer.Name, lecturer.Email, nullable(lecturer.DepartmentID))
+		return err
+	})
+	return wrapError(ctx, "InsertLecturer", err)
+}
+
+//UpdateLecturer changes the name, email and department of a lecturer. Courses pick up the new name at once.
+//ErrLecturerNotFound is returned if there is no such lecturer, ErrConflict if another lecturer has the Name,
+//ErrUnknownDepartment if the department does not exist and a RuleError of kind ErrConflict if the lecturer
+//teaches a course of another department.
+func UpdateLecturer(ctx context.Context, db *sql.DB, lecturer Lecturer) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	err := withTx(ctx, db, func(tx *sql.Tx) error {
+		if err := resolveDepartment(ctx, tx, &lecturer); err != nil {
+			return err
+		}
+		result, err := execContext(ctx, tx, queryUpdateLecturer, lecturer.Name, lecturer.Email, nullable(lecturer.DepartmentID), lecturer.LecturerID)
+		if err != nil {
+			return err
+		}
+		if affectOne(result) == sql.ErrNoRows {
+			return ErrLecturerNotFound
+		}
+		if lecturer.DepartmentID == "" {
+			return nil
+		}
+		var courseID string
+		err = queryRowContext(ctx, tx, queryLecturerOutOfScope, lecturer.LecturerID, lecturer.DepartmentID).Scan(&courseID)
+		if err == sql.ErrNoRows {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		return &RuleError{ErrConflict, "the lecturer teaches " + courseID + ", which belongs to another department"}
+	})
+	return wrapError(ctx, "UpdateLecturer", err)
+}
+
+//DeleteLecturer removes a lecturer who teaches no courses. ErrLecturerHasCourses is returned while courses
+//still refer to the lecturer, ErrLecturerNotFound if there was nothing to delete.
+func DeleteLecturer(ctx context.Context, db *sql.DB, LecturerID string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	result, err := execContext(ctx, db, queryDeleteLecturer, LecturerID)
+	switch {
+	case err != nil && kindOf(err) == ErrConflict: //the Course foreign key refuses the delete
+		err = ErrLecturerHasCourses
+	case err == nil && affectOne(result) == sql.ErrNoRows:
+		err = ErrLecturerNotFound
+	}
+	return wrapError(ctx, "DeleteLecturer", err)
+}
+
+//GetLecturerCourses returns the courses taught by a lecturer ordered by CourseID.
+//ErrLecturerNotFound is returned if there is no such lecturer.
+func GetLecturerCourses(ctx context.Context, db *sql.DB, LecturerID string) ([]Course, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	if _, err := getLecturer(ctx, db, LecturerID); err != nil {
+		return nil, wrapError(ctx, "GetLecturerCourses", err)
+	}
+	courses := []Course{}
+	rows, err := queryContext(ctx, db, queryLecturerCourses, LecturerID)
+	if err != nil {
+		return nil, wrapError(ctx, "GetLecturerCourses", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var course Course
+		if err = rows.Scan(courseFields(&course)...); err != nil {
+			return nil, wrapError(ctx, "GetLecturerCourses", err)
+		}
+		courses = append(courses, course)
+	}
+	return courses, wrapError(ctx, "GetLecturerCourses", rows.Err())
+}
+
+func getLecturer(ctx context.Context, q querier, LecturerID string) (Lecturer, error) {
+	var lecturer Lecturer
+	err := queryRowContext(ctx, q, queryGetLecturer, LecturerID).Scan(&lecturer.LecturerID, &lecturer.Name, &lecturer.Email, &lecturer.DepartmentID, &lecturer.Department)
+	if err == sql.ErrNoRows {
+		err = ErrLecturerNotFound
+	}
+	return lecturer, err
+}
+
+//scanLecturers reads every lecturer returned by query.
+func scanLecturers(ctx context.Context, q querier, query string, args ...interface{}) ([]Lecturer, error) {
+	lecturers := []Lecturer{}
+	rows, err := queryContext(ctx, q, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var lecturer Lecturer
+		if err = rows.Scan(&lecturer.LecturerID, &lecturer.Name, &lecturer.Email, &lecturer.DepartmentID, &lecturer.Department); err != nil {
+			return nil, err
+		}
+		lecturers = append(lecturers, lecturer)
+	}
+	return lecturers, rows.Err()
+}
+
+//resolveLecturer fills in the LecturerID of a course being written from its Lecturer name when no ID was
+//given, and the name from the ID otherwise, so both match the Lecturer table. It returns the department of
+//the lecturer, or "" if there is none, for checkScope. The lecturer row is share locked until the transaction
+//ends so it cannot be deleted or moved to another department before the course refers to it.
+//ErrUnknownLecturer is returned if there is no such lecturer.
+func resolveLecturer(ctx context.Context, tx *sql.Tx, course *Course) (department string, err error) {
+	var row *sql.Row
+	if course.LecturerID != "" {
+		row = queryRowContext(ctx, tx, queryShareLecturerByID, course.LecturerID)
+	} else {
+		row = queryRowContext(ctx, tx, queryShareLecturerName, course.Lecturer)
+	}
+	err = row.Scan(&course.LecturerID, &course.Lecturer, &department)
+	if err == sql.ErrNoRows {
+		err = ErrUnknownLecturer
+	}
+	return department, err
+}
+
+//nullable turns an empty string into NULL for an optional foreign key column.
+func nullable(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,6 @@
+package database
+
+import "expvar"
+
+//metrics counts failed queries by cause and students promoted from waitlists. It is published through expvar under the name "database".
+var metrics = expvar.NewMap("database")
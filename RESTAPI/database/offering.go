@@ -0,0 +1,306 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+//Semester is a teaching term. Dates are written as 2006-01-02; terms are ordered by StartDate, which decides
+//the term an offering is rolled forward into.
+type Semester struct {
+	SemesterID string `yaml:"SemesterID"`
+	Name       string `yaml:"Name"`
+	StartDate  string `yaml:"StartDate"`
+	EndDate    string `yaml:"EndDate"`
+}
+
+//CourseOffering is a course running in one semester, with the lecturer and class size of that run.
+//The lecturer is given by LecturerID or by name like the lecturer of a course.
+type CourseOffering struct {
+	CourseID   string `yaml:"CourseID"`
+	SemesterID string `yaml:"SemesterID"`
+	LecturerID string `json:",omitempty" yaml:"LecturerID,omitempty"`
+	Lecturer   string `yaml:"Lecturer"`
+	ClassSize  int    `yaml:"ClassSize"`
+}
+
+//RollForward reports which offerings of semester From were copied into semester To, and which were skipped
+//because To already had an offering of the course.
+type RollForward struct {
+	From    string   `yaml:"From"`
+	To      string   `yaml:"To"`
+	Copied  []string `yaml:"Copied"`
+	Skipped []string `yaml:"Skipped"`
+}
+
+const (
+	querySemesters        = "SELECT SemesterID, Name, DATE_FORMAT(StartDate, '%Y-%m-%d'), DATE_FORMAT(EndDate, '%Y-%m-%d') FROM Semester"
+	queryGetSemester      = querySemesters + " WHERE SemesterID=?"
+	queryShareSemester    = queryGetSemester + " LOCK IN SHARE MODE"
+	queryAllSemesters     = querySemesters + " ORDER BY StartDate, SemesterID"
+	queryNextSemester     = querySemesters + " WHERE StartDate > (SELECT StartDate FROM Semester WHERE SemesterID=?) ORDER BY StartDate, SemesterID LIMIT 1"
+	queryInsertSemester   = "INSERT INTO Semester (SemesterID, Name, StartDate, EndDate) VALUES (?, ?, ?, ?)"
+	queryUpdateSemester   = "UPDATE Semester SET Name=?, StartDate=?, EndDate=? WHERE SemesterID=?"
+	queryDeleteSemester   = "DELETE FROM Semester WHERE SemesterID=?"
+	queryOfferings        = "SELECT o.CourseID, o.SemesterID, o.LecturerID, l.Name, o.ClassSize FROM CourseOffering o JOIN Lecturer l ON l.LecturerID=o.LecturerID"
+	queryGetOffering      = queryOfferings + " WHERE o.CourseID=? AND o.SemesterID=?"
+	querySemesterOffering = queryOfferings + " WHERE o.SemesterID=? ORDER BY o.CourseID"
+	queryLockOfferings    = querySemesterOffering + " FOR UPDATE"
+	queryCourseOfferings  = queryOfferings + " JOIN Semester s ON s.SemesterID=o.SemesterID WHERE o.CourseID=? ORDER BY s.StartDate, o.SemesterID"
+	queryInsertOffering   = "INSERT INTO CourseOffering (CourseID, SemesterID, LecturerID, ClassSize) VALUES (?, ?, ?, ?)"
+	queryUpdateOffering   = "UPDATE CourseOffering SET LecturerID=?, ClassSize=? WHERE CourseID=? AND SemesterID=?"
+	queryDeleteOffering   = "DELETE FROM CourseOffering WHERE CourseID=? AND SemesterID=?"
+)
+
+//GetSemester returns one semester. ErrSemesterNotFound is returned if there is no such semester.
+func GetSemester(ctx context.Context, db *sql.DB, SemesterID string) (Semester, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	semester, err := getSemester(ctx, db, queryGetSemester, SemesterID)
+	return semester, wrapError(ctx, "GetSemester", err)
+}
+
+//GetAllSemesters returns every semester in term order.
+func GetAllSemesters(ctx context.Context, db *sql.DB) ([]Semester, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	semesters := []Semester{}
+	rows, err := queryContext(ctx, db, queryAllSemesters)
+	if err != nil {
+		return nil, wrapError(ctx, "GetAllSemesters", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var semester Semester
+		if err = rows.Scan(&semester.SemesterID, &semester.Name, &semester.StartDate, &semester.EndDate); err != nil {
+			return nil, wrapError(ctx, "GetAllSemesters", err)
+		}
+		semesters = append(semesters, semester)
+	}
+	return semesters, wrapError(ctx, "GetAllSemesters", rows.Err())
+}
+
+//InsertSemester creates a new semester. ErrConflict is returned if the SemesterID is already taken.
+func InsertSemester(ctx context.Context, db *sql.DB, semester Semester) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	_, err := execContext(ctx, db, queryInsertSemester, semester.SemesterID, semester.Name, semester.StartDate, semester.EndDate)
+	return wrapError(ctx, "InsertSemester", err)
+}
+
+//UpdateSemester changes the name and dates of a semester. ErrSemesterNotFound is returned if there is no such semester.
+func UpdateSemester(ctx context.Context, db *sql.DB, semester Semester) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	result, err := execContext(ctx, db, queryUpdateSemester, semester.Name, semester.StartDate, semester.EndDate, semester.SemesterID)
+	if err == nil && affectOne(result) == sql.ErrNoRows {
+		err = ErrSemesterNotFound
+	}
+	return wrapError(ctx, "UpdateSemester", err)
+}
+
+//DeleteSemester removes a semester without offerings. ErrSemesterHasOfferings is returned while courses are
+//offered in it, ErrSemesterNotFound if there was nothing to delete.
+func DeleteSemester(ctx context.Context, db *sql.DB, SemesterID string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	result, err := execContext(ctx, db, queryDeleteSemester, SemesterID)
+	switch {
+	case err != nil && kindOf(err) == ErrConflict: //the CourseOffering foreign key refuses the delete
+		err = ErrSemesterHasOfferings
+	case err == nil && affectOne(result) == sql.ErrNoRows:
+		err = ErrSemesterNotFound
+	}
+	return wrapError(ctx, "DeleteSemester", err)
+}
+
+//GetOffering returns the offering of a course in a semester. ErrOfferingNotFound is returned if the course
+//is not offered in the semester.
+func GetOffering(ctx context.Context, db *sql.DB, CourseID string, SemesterID string) (CourseOffering, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	var offering CourseOffering
+	err := queryRowContext(ctx, db, queryGetOffering, CourseID, SemesterID).
+		Scan(&offering.CourseID, &offering.SemesterID, &offering.LecturerID, &offering.Lecturer, &offering.ClassSize)
+	if err == sql.ErrNoRows {
+		err = ErrOfferingNotFound
+	}
+	return offering, wrapError(ctx, "GetOffering", err)
+}
+
+//GetSemesterOfferings returns the offerings of a semester ordered by CourseID.
+//ErrSemesterNotFound is returned if there is no such semester.
+func GetSemesterOfferings(ctx context.Context, db *sql.DB, SemesterID string) ([]CourseOffering, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	if _, err := getSemester(ctx, db, queryGetSemester, SemesterID); err != nil {
+		return nil, wrapError(ctx, "GetSemesterOfferings", err)
+	}
+	offerings, err := scanOfferings(ctx, db, querySemesterOffering, SemesterID)
+	return offerings, wrapError(ctx, "GetSemesterOfferings", err)
+}
+
+//GetCourseOfferings returns the offerings of a course in term order. ErrNotFound is returned if there is no such course.
+func GetCourseOfferings(ctx context.Context, db *sql.DB, CourseID string) ([]CourseOffering, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	var exist int
+	if err := queryRowContext(ctx, db, queryCourseExist, CourseID).Scan(&exist); err != nil {
+		return nil, wrapError(ctx, "GetCourseOfferings", err)
+	}
+	if exist == 0 {
+		return nil, wrapError(ctx, "GetCourseOfferings", sql.ErrNoRows)
+	}
+	offerings, err := scanOfferings(ctx, db, queryCourseOfferings, CourseID)
+	return offerings, wrapError(ctx, "GetCourseOfferings", err)
+}
+
+//UpsertOffering offers a course in a semester, or changes the lecturer and class size of an existing offering.
+//It returns the offering with both lecturer fields filled in and reports whether it was new.
+//ErrNotFound is returned if there is no such course, ErrSemesterNotFound if there is no such semester,
+//ErrUnknownLecturer if the lecturer does not exist and ErrOutOfDepartment if the lecturer belongs to
+//another department than the course.
+func UpsertOffering(ctx context.Context, db *sql.DB, offering CourseOffering) (saved CourseOffering, created bool, err error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	err = withTx(ctx, db, func(tx *sql.Tx) error {
+		created = false
+		var exist int
+		if err := queryRowContext(ctx, tx, queryCourseExist, offering.CourseID).Scan(&exist); err != nil {
+			return err
+		}
+		if exist == 0 {
+			return sql.ErrNoRows
+		}
+		if _, err := getSemester(ctx, tx, queryShareSemester, offering.SemesterID); err != nil {
+			return err
+		}
+		//the lecturer is resolved and checked exactly as for the course itself
+		course := Course{CourseID: offering.CourseID, LecturerID: offering.LecturerID, Lecturer: offering.Lecturer}
+		department, err := resolveLecturer(ctx, tx, &course)
+		if err != nil {
+			return err
+		}
+		if err = checkScope(ctx, tx, course, department); err != nil {
+			return err
+		}
+		offering.LecturerID, offering.Lecturer = course.LecturerID, course.Lecturer
+
+		_, err = execContext(ctx, tx, queryInsertOffering, offering.CourseID, offering.SemesterID, course.LecturerID, offering.ClassSize)
+		if err == nil {
+			created = true
+			return nil
+		}
+		if kindOf(err) != ErrConflict {
+			return err
+		}
+		_, err = execContext(ctx, tx, queryUpdateOffering, course.LecturerID, offering.ClassSize, offering.CourseID, offering.SemesterID)
+		return err
+	})
+	return offering, created, wrapError(ctx, "UpsertOffering", err)
+}
+
+//DeleteOffering stops offering a course in a semester. ErrOfferingNotFound is returned if it was not offered.
+func DeleteOffering(ctx context.Context, db *sql.DB, CourseID string, SemesterID string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	result, err := execContext(ctx, db, queryDeleteOffering, CourseID, SemesterID)
+	if err == nil && affectOne(result) == sql.ErrNoRows {
+		err = ErrOfferingNotFound
+	}
+	return wrapError(ctx, "DeleteOffering", err)
+}
+
+//RollForwardOfferings copies the offerings of semester From into semester To with the same lecturers and class
+//sizes. When To is empty the next semester by StartDate is used. Courses To already offers are left as they
+//are and reported as skipped. ErrSemesterNotFound is returned if either semester does not exist and
+//ErrNoNextSemester if From is the last one.
+func RollForwardOfferings(ctx context.Context, db *sql.DB, From string, To string) (RollForward, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	result := RollForward{From: From, To: To, Copied: []string{}, Skipped: []string{}}
+	err := withTx(ctx, db, func(tx *sql.Tx) error {
+		result.Copied, result.Skipped = []string{}, []string{}
+		if _, err := getSemester(ctx, tx, queryShareSemester, From); err != nil {
+			return err
+		}
+		if To == "" {
+			next, err := getSemester(ctx, tx, queryNextSemester, From)
+			if err == ErrSemesterNotFound {
+				return ErrNoNextSemester
+			} else if err != nil {
+				return err
+			}
+			result.To = next.SemesterID
+		} else if _, err := getSemester(ctx, tx, queryShareSemester, To); err != nil {
+			return err
+		}
+		if result.To == From {
+			return ErrRollIntoItself
+		}
+
+		offerings, err := scanOfferings(ctx, tx, queryLockOfferings, From)
+		if err != nil {
+			return err
+		}
+		existing, err := scanOfferings(ctx, tx, queryLockOfferings, result.To)
+		if err != nil {
+			return err
+		}
+		offered := map[string]bool{}
+		for _, offering := range existing {
+			offered[offering.CourseID] = true
+		}
+		for _, offering := range offerings {
+			if offered[offering.CourseID] {
+				result.Skipped = append(result.Skipped, offering.CourseID)
+				continue
+			}
+			if _, err := execContext(ctx, tx, queryInsertOffering, offering.CourseID, result.To, offering.LecturerID, offering.ClassSize); err != nil {
+				return err
+			}
+			result.Copied = append(result.Copied, offering.CourseID)
+		}
+		return nil
+	})
+	return result, wrapError(ctx, "RollForwardOfferings", err)
+}
+
+//getSemester reads a semester with query, which takes the SemesterID as its only argument.
+func getSemester(ctx context.Context, q querier, query string, SemesterID string) (Semester, error) {
+	var semester Semester
+	err := queryRowContext(ctx, q, query, SemesterID).Scan(&semester.SemesterID, &semester.Name, &semester.StartDate, &semester.EndDate)
+	if err == sql.ErrNoRows {
+		err = ErrSemesterNotFound
+	}
+	return semester, err
+}
+
+//scanOfferings reads every offering returned by query.
+func scanOfferings(ctx context.Context, q querier, query string, args ...interface{}) ([]CourseOffering, error) {
+	offerings := []CourseOffering{}
+	rows, err := queryContext(ctx, q, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var offering CourseOffering
+		if err = rows.Scan(&offering.CourseID, &offering.SemesterID, &offering.LecturerID, &offering.Lecturer, &offering.ClassSize); err != nil {
+			return nil, err
+		}
+		offerings = append(offerings, offering)
+	}
+	return offerings, rows.Err()
+}
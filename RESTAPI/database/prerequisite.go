@@ -0,0 +1,215 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+)
+
+const (
+	queryAllPrerequisites   = "SELECT CourseID, PrereqID FROM Prerequisite"
+	queryLockPrerequisites  = queryAllPrerequisites + " FOR UPDATE"
+	queryInsertPrerequisite = "INSERT INTO Prerequisite (CourseID, PrereqID) VALUES (?, ?)"
+	queryDeletePrerequisite = "DELETE FROM Prerequisite WHERE CourseID=? AND PrereqID=?"
+	queryDependents         = "SELECT CourseID FROM Prerequisite WHERE PrereqID=? ORDER BY CourseID"
+	queryDeleteCourseEdges  = "DELETE FROM Prerequisite WHERE CourseID=? OR PrereqID=?"
+)
+
+//prerequisites maps each course to the courses it directly requires.
+type prerequisites map[string][]string
+
+//GetPrerequisites returns the courses that must be taken before a course, in topological order: every course
+//comes after its own prerequisites. Only the direct prerequisites are returned unless transitive is true.
+//ErrNotFound is returned if there is no such course.
+func GetPrerequisites(ctx context.Context, db *sql.DB, CourseID string, transitive bool) ([]Course, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	var exist int
+	if err := queryRowContext(ctx, db, queryCourseExist, CourseID).Scan(&exist); err != nil {
+		return nil, wrapError(ctx, "GetPrerequisites", err)
+	}
+	if exist == 0 {
+		return nil, wrapError(ctx, "GetPrerequisites", sql.ErrNoRows)
+	}
+	graph, err := loadPrerequisites(ctx, db, queryAllPrerequisites)
+	if err != nil {
+		return nil, wrapError(ctx, "GetPrerequisites", err)
+	}
+
+	wanted := map[string]bool{}
+	for _, id := range graph[CourseID] {
+		wanted[id] = true
+	}
+	order := graph.order(CourseID)
+	ids := []string{}
+	for _, id := range order[:len(order)-1] { //the course itself comes last
+		if transitive || wanted[id] {
+			ids = append(ids, id)
+		}
+	}
+
+	courses := make([]Course, 0, len(ids))
+	for _, id := range ids {
+		var course Course
+		err := queryRowContext(ctx, db, queryGetCourse, id).Scan(courseFields(&course)...)
+		if err != nil {
+			return nil, wrapError(ctx, "GetPrerequisites", err)
+		}
+		courses = append(courses, course)
+	}
+	return courses, nil
+}
+
+//AddPrerequisite records that PrereqID must be taken before CourseID and reports whether it was new.
+//The whole graph is locked while it is checked, so concurrent additions cannot close a cycle between them.
+//This serialises every addition, even between unrelated courses. Locking only the prerequisites reachable from
+//PrereqID would let those run side by side, but relies on InnoDB gap locks to stop a concurrent insert into
+//a chain already walked, and the table is small and rarely written, so the simpler lock is kept.
+//ErrNotFound is returned if either course does not exist, ErrOwnPrerequisite if they are the same course,
+//and a RuleError of kind ErrConflict showing the path if the prerequisite would close a cycle.
+func AddPrerequisite(ctx context.Context, db *sql.DB, CourseID string, PrereqID string) (created bool, err error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	err = withTx(ctx, db, func(tx *sql.Tx) error {
+		created = false
+		graph, err := loadPrerequisites(ctx, tx, queryLockPrerequisites)
+		if err != nil {
+			return err
+		}
+		for _, id := range []string{CourseID, PrereqID} {
+			var exist int
+			if err := queryRowContext(ctx, tx, queryCourseExist, id).Scan(&exist); err != nil {
+				return err
+			}
+			if exist == 0 {
+				return sql.ErrNoRows
+			}
+		}
+		for _, id := range graph[CourseID] {
+			if id == PrereqID {
+				return nil
+			}
+		}
+		if CourseID == PrereqID {
+			return ErrOwnPrerequisite
+		}
+		if path := graph.path(PrereqID, CourseID); path != nil {
+			//shown in the order the courses are taken, the reverse of the chain of requirements
+			for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+				path[i], path[j] = path[j], path[i]
+			}
+			return &RuleError{ErrConflict, "the prerequisite would create a cycle, " + CourseID + " is already taken before " +
+				PrereqID + ": " + strings.Join(path, " → ")}
+		}
+		if _, err := execContext(ctx, tx, queryInsertPrerequisite, CourseID, PrereqID); err != nil {
+			return err
+		}
+		created = true
+		return nil
+	})
+	return created, wrapError(ctx, "AddPrerequisite", err)
+}
+
+//RemovePrerequisite removes PrereqID from the prerequisites of CourseID.
+//ErrNotPrerequisite is returned if it was not one.
+func RemovePrerequisite(ctx context.Context, db *sql.DB, CourseID string, PrereqID string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	result, err := execContext(ctx, db, queryDeletePrerequisite, CourseID, PrereqID)
+	if err == nil && affectOne(result) == sql.ErrNoRows {
+		err = ErrNotPrerequisite
+	}
+	return wrapError(ctx, "RemovePrerequisite", err)
+}
+
+//courseDependents returns the courses that directly require CourseID.
+func courseDependents(ctx context.Context, q querier, CourseID string) ([]string, error) {
+	rows, err := queryContext(ctx, q, queryDependents, CourseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	dependents := []string{}
+	for rows.Next() {
+		var id string
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		dependents = append(dependents, id)
+	}
+	return dependents, rows.Err()
+}
+
+//loadPrerequisites reads every prerequisite with query, either queryAllPrerequisites or the locking form.
+func loadPrerequisites(ctx context.Context, q querier, query string) (prerequisites, error) {
+	rows, err := queryContext(ctx, q, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	graph := prerequisites{}
+	for rows.Next() {
+		var courseID, prereqID string
+		if err = rows.Scan(&courseID, &prereqID); err != nil {
+			return nil, err
+		}
+		graph[courseID] = append(graph[courseID], prereqID)
+	}
+	for _, ids := range graph { //sorted so that the order of the results does not depend on the table
+		sort.Strings(ids)
+	}
+	return graph, rows.Err()
+}
+
+//order returns courseID and everything it requires, each course after all of its own prerequisites.
+//The graph must be acyclic, which AddPrerequisite guarantees.
+func (g prerequisites) order(courseID string) []string {
+	order := []string{}
+	visited := map[string]bool{}
+	var visit func(id string)
+	visit = func(id string) {
+		if visited[id] {
+			return
+		}
+		visited[id] = true
+		for _, prereq := range g[id] {
+			visit(prereq)
+		}
+		order = append(order, id)
+	}
+	visit(courseID)
+	return order
+}
+
+//path returns a chain of prerequisites leading from one course to another, or nil if from does not depend on to.
+//A course trivially depends on itself, so path(id, id) is just id.
+func (g prerequisites) path(from, to string) []string {
+	if from == to {
+		return []string{from}
+	}
+	previous := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, prereq := range g[id] {
+			if _, seen := previous[prereq]; seen {
+				continue
+			}
+			previous[prereq] = id
+			if prereq == to {
+				path := []string{to}
+				for at := id; at != ""; at = previous[at] {
+					path = append([]string{at}, path...)
+				}
+				return path
+			}
+			queue = append(queue, prereq)
+		}
+	}
+	return nil
+}
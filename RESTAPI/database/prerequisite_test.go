@@ -0,0 +1,81 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+)
+
+//chain is A ← B ← C: C requires B, which requires A.
+var chain = prerequisites{"B": {"A"}, "C": {"B"}}
+
+//diamond is D requiring B and C, which both require A.
+var diamond = prerequisites{"B": {"A"}, "C": {"A"}, "D": {"B", "C"}}
+
+//TestPrerequisiteCycles checks the test AddPrerequisite makes before recording that prereq must be taken before
+//course: the prerequisite closes a cycle exactly when prereq already depends on course.
+func TestPrerequisiteCycles(t *testing.T) {
+	cases := []struct {
+		name           string
+		graph          prerequisites
+		course, prereq string
+		path           []string //nil if there is no cycle
+	}{
+		{"self-loop", prerequisites{}, "A", "A", []string{"A"}},
+		{"self-loop on a chain", chain, "B", "B", []string{"B"}},
+		{"direct cycle", chain, "A", "B", []string{"B", "A"}},
+		{"indirect cycle", chain, "A", "C", []string{"C", "B", "A"}},
+		{"shortcut", chain, "C", "A", nil},
+		{"edge already there", chain, "C", "B", nil},
+		{"unrelated course", chain, "A", "X", nil},
+		{"diamond shortcut", diamond, "D", "A", nil},
+		{"diamond side", diamond, "C", "B", nil},
+		{"diamond cycle", diamond, "A", "D", []string{"D", "B", "A"}},
+		{"diamond cycle through one side", diamond, "C", "D", []string{"D", "C"}},
+	}
+	for _, c := range cases {
+		got := c.graph.path(c.prereq, c.course)
+		if fmt.Sprint(got) != fmt.Sprint(c.path) || (got == nil) != (c.path == nil) {
+			t.Errorf("%s: path from %s to %s is %v, want %v", c.name, c.prereq, c.course, got, c.path)
+		}
+	}
+}
+
+func TestPrerequisiteOrder(t *testing.T) {
+	cases := []struct {
+		name   string
+		graph  prerequisites
+		course string
+		order  []string
+	}{
+		{"no prerequisites", chain, "A", []string{"A"}},
+		{"unknown course", chain, "X", []string{"X"}},
+		{"chain", chain, "C", []string{"A", "B", "C"}},
+		{"middle of a chain", chain, "B", []string{"A", "B"}},
+		{"diamond", diamond, "D", []string{"A", "B", "C", "D"}},
+		{"side of a diamond", diamond, "C", []string{"A", "C"}},
+		{"two diamonds", prerequisites{"B": {"A"}, "C": {"A"}, "D": {"B", "C"}, "E": {"C", "F"}, "F": {"D"}, "G": {"D", "E"}},
+			"G", []string{"A", "B", "C", "D", "F", "E", "G"}},
+	}
+	for _, c := range cases {
+		got := c.graph.order(c.course)
+		if fmt.Sprint(got) != fmt.Sprint(c.order) {
+			t.Errorf("%s: order of %s is %v, want %v", c.name, c.course, got, c.order)
+			continue
+		}
+		//each course must come after every one of its prerequisites, and only once
+		position := map[string]int{}
+		for i, id := range got {
+			if _, seen := position[id]; seen {
+				t.Errorf("%s: %s appears twice in %v", c.name, id, got)
+			}
+			position[id] = i
+		}
+		for _, id := range got {
+			for _, prereq := range c.graph[id] {
+				if position[prereq] > position[id] {
+					t.Errorf("%s: %s comes before its prerequisite %s in %v", c.name, id, prereq, got)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+//querier is satisfied by both *sql.DB and *sql.Tx so the helpers below work inside and outside transactions.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+//statements holds the prepared form of each query, keyed by its text. It is filled once by Prepare
+//and only read afterwards, so it is safe for concurrent use.
+var statements = map[string]*sql.Stmt{}
+
+//preparedQueries lists every query that Prepare should prepare at startup.
+var preparedQueries = []string{
+	queryCourseExist,
+	queryDeleteCourse,
+	queryUpdateCourse,
+	queryInsertCourse,
+	queryGetCourse,
+	queryAllCourses,
+	queryAllCoursesOrdered,
+	queryLockCourse,
+	queryCourseTerms,
+	queryStudentExist,
+	queryGetStudent,
+	queryAllStudents,
+	queryInsertStudent,
+	queryDeleteStudent,
+	queryLockClassSize,
+	queryCountEnrolled,
+	queryEnrollmentExist,
+	queryInsertEnrollment,
+	queryDeleteEnrollment,
+	queryCourseEnrollments,
+	queryGetEnrollment,
+	queryWaitlistExist,
+	queryCountWaitlist,
+	queryInsertWaitlist,
+	queryDeleteWaitlist,
+	queryCourseWaitlist,
+	queryNextWaitlisted,
+	queryGetLecturer,
+	queryAllLecturersByID,
+	queryInsertLecturer,
+	queryUpdateLecturer,
+	queryDeleteLecturer,
+	queryLecturerCourses,
+	queryShareLecturerByID,
+	queryShareLecturerName,
+	queryAllPrerequisites,
+	queryLockPrerequisites,
+	queryInsertPrerequisite,
+	queryDeletePrerequisite,
+	queryDependents,
+	queryDeleteCourseEdges,
+	queryGetDepartment,
+	queryLockDepartment,
+	queryAllDepartments,
+	queryInsertDepartment,
+	queryUpdateDepartment,
+	queryDeleteDepartment,
+	queryShareDepartmentID,
+	queryShareDepartment,
+	queryAllPrefixes,
+	queryDepartmentPrefixes,
+	queryPrefixDepartment,
+	queryInsertPrefix,
+	queryDeletePrefix,
+	queryPrefixInUse,
+	queryDepartmentCourses,
+	queryDepartmentLecturers,
+	queryDepartmentStats,
+	queryLecturerOutOfScope,
+	queryGetSemester,
+	queryShareSemester,
+	queryAllSemesters,
+	queryNextSemester,
+	queryInsertSemester,
+	queryUpdateSemester,
+	queryDeleteSemester,
+	queryGetOffering,
+	querySemesterOffering,
+	queryLockOfferings,
+	queryCourseOfferings,
+	queryInsertOffering,
+	queryUpdateOffering,
+	queryDeleteOffering,
+	queryGetRoom,
+	queryLockRoom,
+	queryAllRooms,
+	queryInsertRoom,
+	queryUpdateRoom,
+	queryDeleteRoom,
+	queryRoomOversized,
+	queryLockLecturer,
+	queryGetSession,
+	queryCourseSessions,
+	queryRoomSessions,
+	queryLecturerSessions,
+	queryInsertSession,
+	queryReinsertSession,
+	queryUpdateSession,
+	queryDeleteSession,
+	queryDeleteSessions,
+	queryCourseCalendar,
+	queryLecturerCalendar,
+	queryShareCourse,
+	queryCourseTags,
+	queryInsertTag,
+	queryDeleteTag,
+	queryDeleteTags,
+	queryAllTags,
+	queryRoomClash,
+	queryTeachClash,
+}
+
+//Prepare prepares every query used by this package on db. It should be called once at startup, before serving requests.
+func Prepare(ctx context.Context, db *sql.DB) error {
+	for _, query := range preparedQueries {
+		stmt, err := db.PrepareContext(ctx, query)
+		if err != nil {
+			Close()
+			return wrapError(ctx, "Prepare", err)
+		}
+		statements[query] = stmt
+	}
+	return nil
+}
+
+//Close releases the prepared statements.
+func Close() error {
+	var firstErr error
+	for query, stmt := range statements {
+		if err := stmt.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+		delete(statements, query)
+	}
+	return firstErr
+}
+
+//stmt returns the prepared statement for query bound to q, or nil when the query was not prepared.
+func stmt(ctx context.Context, q querier, query string) *sql.Stmt {
+	s, ok := statements[query]
+	if !ok {
+		return nil
+	}
+	if tx, ok := q.(*sql.Tx); ok {
+		return tx.StmtContext(ctx, s)
+	}
+	return s
+}
+
+func execContext(ctx context.Context, q querier, query string, args ...interface{}) (sql.Result, error) {
+	if s := stmt(ctx, q, query); s != nil {
+		return s.ExecContext(ctx, args...)
+	}
+	return q.ExecContext(ctx, query, args...)
+}
+
+func queryContext(ctx context.Context, q querier, query string, args ...interface{}) (*sql.Rows, error) {
+	if s := stmt(ctx, q, query); s != nil {
+		return s.QueryContext(ctx, args...)
+	}
+	return q.QueryContext(ctx, query, args...)
+}
+
+func queryRowContext(ctx context.Context, q querier, query string, args ...interface{}) *sql.Row {
+	if s := stmt(ctx, q, query); s != nil {
+		return s.QueryRowContext(ctx, args...)
+	}
+	return q.QueryRowContext(ctx, query, args...)
+}
+
+//affectOne checks that a write touched exactly one row, reporting ErrNotFound otherwise.
+//UPDATE relies on the clientFoundRows DSN option so that rewriting identical values still counts as a match.
+func affectOne(result sql.Result) error {
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
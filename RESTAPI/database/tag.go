@@ -0,0 +1,162 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+//CourseTags lists the tags of one course in alphabetical order.
+type CourseTags struct {
+	CourseID string   `yaml:"CourseID"`
+	Tags     []string `yaml:"Tags"`
+}
+
+//TagCount is a facet: a tag and how many of the courses considered carry it.
+type TagCount struct {
+	Tag   string `yaml:"Tag"`
+	Count int    `yaml:"Count"`
+}
+
+const (
+	queryShareCourse = "SELECT CourseID FROM Course WHERE CourseID=? LOCK IN SHARE MODE"
+	queryCourseTags  = "SELECT Tag FROM CourseTag WHERE CourseID=? ORDER BY Tag"
+	queryInsertTag   = "INSERT IGNORE INTO CourseTag (CourseID, Tag) VALUES (?, ?)"
+	queryDeleteTag   = "DELETE FROM CourseTag WHERE CourseID=? AND Tag=?"
+	queryDeleteTags  = "DELETE FROM CourseTag WHERE CourseID=?"
+	queryTagFacets   = "SELECT t.Tag, COUNT(*) FROM CourseTag t JOIN Course c ON c.CourseID=t.CourseID"
+	queryFacetOrder  = " GROUP BY t.Tag ORDER BY COUNT(*) DESC, t.Tag"
+	queryAllTags     = queryTagFacets + queryFacetOrder
+)
+
+//GetCourseTags returns the tags of a course. ErrNotFound is returned if there is no such course.
+func GetCourseTags(ctx context.Context, db *sql.DB, CourseID string) (CourseTags, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	tags := CourseTags{CourseID: CourseID, Tags: []string{}}
+	var exist int
+	if err := queryRowContext(ctx, db, queryCourseExist, CourseID).Scan(&exist); err != nil {
+		return tags, wrapError(ctx, "GetCourseTags", err)
+	}
+	if exist == 0 {
+		return tags, wrapError(ctx, "GetCourseTags", sql.ErrNoRows)
+	}
+	rows, err := queryContext(ctx, db, queryCourseTags, CourseID)
+	if err != nil {
+		return tags, wrapError(ctx, "GetCourseTags", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var tag string
+		if err = rows.Scan(&tag); err != nil {
+			return tags, wrapError(ctx, "GetCourseTags", err)
+		}
+		tags.Tags = append(tags.Tags, tag)
+	}
+	return tags, wrapError(ctx, "GetCourseTags", rows.Err())
+}
+
+//GetAllTags returns every tag in use with the number of courses carrying it, most used first.
+func GetAllTags(ctx context.Context, db *sql.DB) ([]TagCount, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	facets, err := scanFacets(ctx, db, queryAllTags)
+	return facets, wrapError(ctx, "GetAllTags", err)
+}
+
+//AddTag tags a course and reports whether the tag was new to it. Tags need not exist beforehand, a tag
+//is in use for as long as a course carries it. ErrNotFound is returned if there is no such course.
+func AddTag(ctx context.Context, db *sql.DB, CourseID string, Tag string) (created bool, err error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	err = withTx(ctx, db, func(tx *sql.Tx) error {
+		//the lock keeps the course from being deleted before its tag is in, INSERT IGNORE would not notice
+		var id string
+		if err := queryRowContext(ctx, tx, queryShareCourse, CourseID).Scan(&id); err != nil {
+			return err
+		}
+		result, err := execContext(ctx, tx, queryInsertTag, CourseID, Tag)
+		if err != nil {
+			return err
+		}
+		n, err := result.RowsAffected()
+		created = n == 1
+		return err
+	})
+	return created, wrapError(ctx, "AddTag", err)
+}
+
+//RemoveTag removes a tag from a course. ErrTagNotFound is returned if the course does not carry it.
+func RemoveTag(ctx context.Context, db *sql.DB, CourseID string, Tag string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	result, err := execContext(ctx, db, queryDeleteTag, CourseID, Tag)
+	if err == nil && affectOne(result) == sql.ErrNoRows {
+		err = ErrTagNotFound
+	}
+	return wrapError(ctx, "RemoveTag", err)
+}
+
+//FilterRecords returns the courses carrying every one of tags, ordered by CourseID, with the facet counts
+//of the tags among them. With no tags every course is returned. tags must not contain duplicates.
+func FilterRecords(ctx context.Context, db *sql.DB, tags []string) ([]Course, []TagCount, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	filter, args := tagFilter(tags)
+	courses := []Course{}
+	rows, err := queryContext(ctx, db, queryAllCourses+filter+" ORDER BY c.CourseID", args...)
+	if err != nil {
+		return nil, nil, wrapError(ctx, "FilterRecords", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var course Course
+		if err = rows.Scan(courseFields(&course)...); err != nil {
+			return nil, nil, wrapError(ctx, "FilterRecords", err)
+		}
+		courses = append(courses, course)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, wrapError(ctx, "FilterRecords", err)
+	}
+	facets, err := scanFacets(ctx, db, queryTagFacets+filter+queryFacetOrder, args...)
+	return courses, facets, wrapError(ctx, "FilterRecords", err)
+}
+
+//tagFilter builds the condition on c.CourseID matching the courses that carry all of tags, and its
+//arguments. The statement depends on the number of tags, so it is not prepared.
+func tagFilter(tags []string) (string, []interface{}) {
+	if len(tags) == 0 {
+		return "", nil
+	}
+	args := make([]interface{}, 0, len(tags)+1)
+	for _, tag := range tags {
+		args = append(args, tag)
+	}
+	args = append(args, len(tags))
+	return " WHERE c.CourseID IN (SELECT CourseID FROM CourseTag WHERE Tag IN (?" + strings.Repeat(", ?", len(tags)-1) +
+		") GROUP BY CourseID HAVING COUNT(*)=?)", args
+}
+
+//scanFacets reads the tag counts returned by query.
+func scanFacets(ctx context.Context, q querier, query string, args ...interface{}) ([]TagCount, error) {
+	facets := []TagCount{}
+	rows, err := queryContext(ctx, q, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var facet TagCount
+		if err = rows.Scan(&facet.Tag, &facet.Count); err != nil {
+			return nil, err
+		}
+		facets = append(facets, facet)
+	}
+	return facets, rows.Err()
+}
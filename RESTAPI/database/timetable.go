@@ -0,0 +1,450 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+)
+
+//Room is a place sessions are held in. Capacity is how many students it holds.
+type Room struct {
+	RoomID   string `yaml:"RoomID"`
+	Name     string `yaml:"Name"`
+	Capacity int    `yaml:"Capacity"`
+}
+
+//Session is a weekly meeting of a course in a room. Day is an English weekday name, StartTime and EndTime are
+//written as 15:04. The lecturer of a session is the lecturer of its course. SessionID is assigned on creation.
+type Session struct {
+	SessionID int    `yaml:"SessionID"`
+	CourseID  string `yaml:"CourseID"`
+	Day       string `yaml:"Day"`
+	StartTime string `yaml:"StartTime"`
+	EndTime   string `yaml:"EndTime"`
+	RoomID    string `yaml:"RoomID"`
+}
+
+//CalendarEntry is a session with the course title, lecturer and room name a calendar event shows.
+type CalendarEntry struct {
+	Session
+	Title    string
+	Lecturer string
+	RoomName string
+}
+
+const (
+	queryGetRoom       = "SELECT RoomID, Name, Capacity FROM Room WHERE RoomID=?"
+	queryLockRoom      = queryGetRoom + " FOR UPDATE"
+	queryAllRooms      = "SELECT RoomID, Name, Capacity FROM Room ORDER BY RoomID"
+	queryInsertRoom    = "INSERT INTO Room (RoomID, Name, Capacity) VALUES (?, ?, ?)"
+	queryUpdateRoom    = "UPDATE Room SET Name=?, Capacity=? WHERE RoomID=?"
+	queryDeleteRoom    = "DELETE FROM Room WHERE RoomID=?"
+	queryRoomOversized = "SELECT c.CourseID, c.ClassSize FROM Session s JOIN Course c ON c.CourseID=s.CourseID" +
+		" WHERE s.RoomID=? AND c.ClassSize>? ORDER BY c.ClassSize DESC, c.CourseID LIMIT 1"
+	queryLockLecturer = "SELECT LecturerID FROM Lecturer WHERE LecturerID=? FOR UPDATE"
+
+	querySessions         = "SELECT s.SessionID, s.CourseID, s.Day, TIME_FORMAT(s.StartTime, '%H:%i'), TIME_FORMAT(s.EndTime, '%H:%i'), s.RoomID FROM Session s"
+	queryTimetableOrder   = " ORDER BY s.Day, s.StartTime, s.CourseID, s.SessionID"
+	queryGetSession       = querySessions + " WHERE s.SessionID=? AND s.CourseID=?"
+	queryCourseSessions   = querySessions + " WHERE s.CourseID=?" + queryTimetableOrder
+	queryRoomSessions     = querySessions + " WHERE s.RoomID=?" + queryTimetableOrder
+	queryLecturerSessions = querySessions + " JOIN Course c ON c.CourseID=s.CourseID WHERE c.LecturerID=?" + queryTimetableOrder
+	queryInsertSession    = "INSERT INTO Session (CourseID, Day, StartTime, EndTime, RoomID) VALUES (?, ?, ?, ?, ?)"
+	queryReinsertSession  = "INSERT INTO Session (SessionID, CourseID, Day, StartTime, EndTime, RoomID) VALUES (?, ?, ?, ?, ?, ?)"
+	queryUpdateSession    = "UPDATE Session SET Day=?, StartTime=?, EndTime=?, RoomID=? WHERE SessionID=? AND CourseID=?"
+	queryDeleteSession    = "DELETE FROM Session WHERE SessionID=? AND CourseID=?"
+	queryDeleteSessions   = "DELETE FROM Session WHERE CourseID=?"
+
+	queryCalendar = "SELECT s.SessionID, s.CourseID, s.Day, TIME_FORMAT(s.StartTime, '%H:%i'), TIME_FORMAT(s.EndTime, '%H:%i'), s.RoomID, c.Title, l.Name, r.Name" +
+		" FROM Session s JOIN Course c ON c.CourseID=s.CourseID JOIN Lecturer l ON l.LecturerID=c.LecturerID JOIN Room r ON r.RoomID=s.RoomID"
+	queryCourseCalendar   = queryCalendar + " WHERE s.CourseID=?" + queryTimetableOrder
+	queryLecturerCalendar = queryCalendar + " WHERE c.LecturerID=?" + queryTimetableOrder
+
+	//sessions overlapping Day, StartTime and EndTime, other than the session being written
+	queryClash      = querySessions + " JOIN Course c ON c.CourseID=s.CourseID WHERE s.Day=? AND s.StartTime<? AND s.EndTime>? AND s.SessionID<>?"
+	queryRoomClash  = queryClash + " AND s.RoomID=? ORDER BY s.StartTime LIMIT 1"
+	queryTeachClash = queryClash + " AND c.LecturerID=? ORDER BY s.StartTime LIMIT 1"
+)
+
+//weekdays maps the Day of a session to the number stored in the table, Monday first so that timetables sort by day.
+var weekdays = map[string]int{
+	time.Monday.String(): 1, time.Tuesday.String(): 2, time.Wednesday.String(): 3, time.Thursday.String(): 4,
+	time.Friday.String(): 5, time.Saturday.String(): 6, time.Sunday.String(): 7,
+}
+
+//dayName is the inverse of weekdays.
+func dayName(day int) string {
+	for name, n := range weekdays {
+		if n == day {
+			return name
+		}
+	}
+	return strconv.Itoa(day)
+}
+
+//ValidDay reports whether day is a weekday name a Session accepts.
+func ValidDay(day string) bool {
+	_, ok := weekdays[day]
+	return ok
+}
+
+//GetRoom returns one room. ErrRoomNotFound is returned if there is no such room.
+func GetRoom(ctx context.Context, db *sql.DB, RoomID string) (Room, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	room, err := getRoom(ctx, db, queryGetRoom, RoomID)
+	return room, wrapError(ctx, "GetRoom", err)
+}
+
+//GetAllRooms returns every room ordered by RoomID.
+func GetAllRooms(ctx context.Context, db *sql.DB) ([]Room, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	rooms := []Room{}
+	rows, err := queryContext(ctx, db, queryAllRooms)
+	if err != nil {
+		return nil, wrapError(ctx, "GetAllRooms", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var room Room
+		if err = rows.Scan(&room.RoomID, &room.Name, &room.Capacity); err != nil {
+			return nil, wrapError(ctx, "GetAllRooms", err)
+		}
+		rooms = append(rooms, room)
+	}
+	return rooms, wrapError(ctx, "GetAllRooms", rows.Err())
+}
+
+//InsertRoom creates a new room. ErrConflict is returned if the RoomID is already taken.
+func InsertRoom(ctx context.Context, db *sql.DB, room Room) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	_, err := execContext(ctx, db, queryInsertRoom, room.RoomID, room.Name, room.Capacity)
+	return wrapError(ctx, "InsertRoom", err)
+}
+
+//UpdateRoom changes the name and capacity of a room. ErrRoomNotFound is returned if there is no such room, and
+//a RuleError of kind ErrConflict if a course meeting in the room has more students than the new capacity.
+func UpdateRoom(ctx context.Context, db *sql.DB, room Room) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	err := withTx(ctx, db, func(tx *sql.Tx) error {
+		if _, err := getRoom(ctx, tx, queryLockRoom, room.RoomID); err != nil {
+			return err
+		}
+		var courseID string
+		var classSize int
+		err := queryRowContext(ctx, tx, queryRoomOversized, room.RoomID, room.Capacity).Scan(&courseID, &classSize)
+		if err == nil {
+			return roomTooSmall(room, courseID, classSize)
+		} else if err != sql.ErrNoRows {
+			return err
+		}
+		_, err = execContext(ctx, tx, queryUpdateRoom, room.Name, room.Capacity, room.RoomID)
+		return err
+	})
+	return wrapError(ctx, "UpdateRoom", err)
+}
+
+//DeleteRoom removes a room. ErrRoomInUse is returned while sessions are held in it,
+//ErrRoomNotFound if there was nothing to delete.
+func DeleteRoom(ctx context.Context, db *sql.DB, RoomID string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	result, err := execContext(ctx, db, queryDeleteRoom, RoomID)
+	switch {
+	case err != nil && kindOf(err) == ErrConflict: //the Session foreign key refuses the delete
+		err = ErrRoomInUse
+	case err == nil && affectOne(result) == sql.ErrNoRows:
+		err = ErrRoomNotFound
+	}
+	return wrapError(ctx, "DeleteRoom", err)
+}
+
+//GetSession returns one session of a course. ErrSessionNotFound is returned if the course has no such session.
+func GetSession(ctx context.Context, db *sql.DB, CourseID string, SessionID int) (Session, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	var session Session
+	var day int
+	err := queryRowContext(ctx, db, queryGetSession, SessionID, CourseID).
+		Scan(&session.SessionID, &session.CourseID, &day, &session.StartTime, &session.EndTime, &session.RoomID)
+	if err == sql.ErrNoRows {
+		err = ErrSessionNotFound
+	}
+	session.Day = dayName(day)
+	return session, wrapError(ctx, "GetSession", err)
+}
+
+//GetCourseTimetable returns the sessions of a course in weekly order. ErrNotFound is returned if there is no such course.
+func GetCourseTimetable(ctx context.Context, db *sql.DB, CourseID string) ([]Session, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	var exist int
+	if err := queryRowContext(ctx, db, queryCourseExist, CourseID).Scan(&exist); err != nil {
+		return nil, wrapError(ctx, "GetCourseTimetable", err)
+	}
+	if exist == 0 {
+		return nil, wrapError(ctx, "GetCourseTimetable", sql.ErrNoRows)
+	}
+	sessions, err := scanSessions(ctx, db, queryCourseSessions, CourseID)
+	return sessions, wrapError(ctx, "GetCourseTimetable", err)
+}
+
+//GetRoomTimetable returns the sessions held in a room in weekly order. ErrRoomNotFound is returned if there is no such room.
+func GetRoomTimetable(ctx context.Context, db *sql.DB, RoomID string) ([]Session, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	if _, err := getRoom(ctx, db, queryGetRoom, RoomID); err != nil {
+		return nil, wrapError(ctx, "GetRoomTimetable", err)
+	}
+	sessions, err := scanSessions(ctx, db, queryRoomSessions, RoomID)
+	return sessions, wrapError(ctx, "GetRoomTimetable", err)
+}
+
+//GetLecturerTimetable returns the sessions of every course a lecturer teaches in weekly order.
+//ErrLecturerNotFound is returned if there is no such lecturer.
+func GetLecturerTimetable(ctx context.Context, db *sql.DB, LecturerID string) ([]Session, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	if _, err := getLecturer(ctx, db, LecturerID); err != nil {
+		return nil, wrapError(ctx, "GetLecturerTimetable", err)
+	}
+	sessions, err := scanSessions(ctx, db, queryLecturerSessions, LecturerID)
+	return sessions, wrapError(ctx, "GetLecturerTimetable", err)
+}
+
+//GetCourseCalendar returns the sessions of a course with the details of their calendar events.
+//ErrNotFound is returned if there is no such course.
+func GetCourseCalendar(ctx context.Context, db *sql.DB, CourseID string) ([]CalendarEntry, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	var exist int
+	if err := queryRowContext(ctx, db, queryCourseExist, CourseID).Scan(&exist); err != nil {
+		return nil, wrapError(ctx, "GetCourseCalendar", err)
+	}
+	if exist == 0 {
+		return nil, wrapError(ctx, "GetCourseCalendar", sql.ErrNoRows)
+	}
+	entries, err := scanCalendar(ctx, db, queryCourseCalendar, CourseID)
+	return entries, wrapError(ctx, "GetCourseCalendar", err)
+}
+
+//GetLecturerCalendar returns the sessions of every course a lecturer teaches with the details of their
+//calendar events. ErrLecturerNotFound is returned if there is no such lecturer.
+func GetLecturerCalendar(ctx context.Context, db *sql.DB, LecturerID string) ([]CalendarEntry, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	if _, err := getLecturer(ctx, db, LecturerID); err != nil {
+		return nil, wrapError(ctx, "GetLecturerCalendar", err)
+	}
+	entries, err := scanCalendar(ctx, db, queryLecturerCalendar, LecturerID)
+	return entries, wrapError(ctx, "GetLecturerCalendar", err)
+}
+
+//InsertSession schedules a new session of a course and returns it with its SessionID.
+//ErrNotFound is returned if there is no such course, ErrUnknownRoom if the room does not exist, and a RuleError
+//of kind ErrConflict if the room or the lecturer is already booked at the time or the room is too small.
+func InsertSession(ctx context.Context, db *sql.DB, session Session) (Session, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	err := withTx(ctx, db, func(tx *sql.Tx) error {
+		var err error
+		session, err = insertSession(ctx, tx, session)
+		return err
+	})
+	return session, wrapError(ctx, "InsertSession", err)
+}
+
+//UpdateSession moves a session of a course to another day, time or room. ErrSessionNotFound is returned if the
+//course has no such session; the other errors are those of InsertSession.
+func UpdateSession(ctx context.Context, db *sql.DB, session Session) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	err := withTx(ctx, db, func(tx *sql.Tx) error {
+		if err := checkSession(ctx, tx, session); err != nil {
+			return err
+		}
+		result, err := execContext(ctx, tx, queryUpdateSession, weekdays[session.Day], session.StartTime, session.EndTime, session.RoomID, session.SessionID, session.CourseID)
+		if err == nil && affectOne(result) == sql.ErrNoRows {
+			err = ErrSessionNotFound
+		}
+		return err
+	})
+	return wrapError(ctx, "UpdateSession", err)
+}
+
+//DeleteSession removes a session of a course. ErrSessionNotFound is returned if the course has no such session.
+func DeleteSession(ctx context.Context, db *sql.DB, CourseID string, SessionID int) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+
+	result, err := execContext(ctx, db, queryDeleteSession, SessionID, CourseID)
+	if err == nil && affectOne(result) == sql.ErrNoRows {
+		err = ErrSessionNotFound
+	}
+	return wrapError(ctx, "DeleteSession", err)
+}
+
+//insertSession checks and inserts a session within tx and returns it with its SessionID.
+func insertSession(ctx context.Context, tx *sql.Tx, session Session) (Session, error) {
+	if err := checkSession(ctx, tx, session); err != nil {
+		return session, err
+	}
+	result, err := execContext(ctx, tx, queryInsertSession, session.CourseID, weekdays[session.Day], session.StartTime, session.EndTime, session.RoomID)
+	if err != nil {
+		return session, err
+	}
+	id, err := result.LastInsertId()
+	session.SessionID = int(id)
+	return session, err
+}
+
+//reinsertSession inserts a session again under the SessionID it was given, after the transaction it was first
+//inserted in was rolled back as a deadlock victim. The ID was never committed, so no other session holds it.
+func reinsertSession(ctx context.Context, tx *sql.Tx, session Session) error {
+	if err := checkSession(ctx, tx, session); err != nil {
+		return err
+	}
+	_, err := execContext(ctx, tx, queryReinsertSession, session.SessionID, session.CourseID, weekdays[session.Day], session.StartTime, session.EndTime, session.RoomID)
+	return err
+}
+
+//checkSession locks the course, its lecturer and the room of a session being written and checks it against
+//the room capacity and the other bookings of the room and the lecturer. The locks serialise concurrent
+//bookings of the same lecturer or room, so two of them cannot both pass the check.
+func checkSession(ctx context.Context, tx *sql.Tx, session Session) error {
+	course, err := lockCourse(ctx, tx, session.CourseID)
+	if err != nil {
+		return err
+	}
+	var lecturerID string
+	if err = queryRowContext(ctx, tx, queryLockLecturer, course.LecturerID).Scan(&lecturerID); err != nil {
+		return err
+	}
+	room, err := getRoom(ctx, tx, queryLockRoom, session.RoomID)
+	if err == ErrRoomNotFound {
+		return ErrUnknownRoom
+	} else if err != nil {
+		return err
+	}
+	if course.ClassSize > room.Capacity {
+		return roomTooSmall(room, course.CourseID, course.ClassSize)
+	}
+
+	clash, err := findClash(ctx, tx, queryRoomClash, session, session.RoomID)
+	if err != nil {
+		return err
+	}
+	if clash != nil {
+		return &RuleError{ErrConflict, "room " + room.RoomID + " is already booked for " + clash.CourseID + " on " + describeSlot(*clash)}
+	}
+	clash, err = findClash(ctx, tx, queryTeachClash, session, course.LecturerID)
+	if err != nil {
+		return err
+	}
+	if clash != nil {
+		return &RuleError{ErrConflict, "lecturer " + course.LecturerID + " already teaches " + clash.CourseID + " on " + describeSlot(*clash)}
+	}
+	return nil
+}
+
+//checkCourseSessions checks the sessions of a course again after its lecturer or ClassSize changed,
+//returning the same errors as checkSession. The course must already be locked by tx.
+func checkCourseSessions(ctx context.Context, tx *sql.Tx, CourseID string) error {
+	sessions, err := scanSessions(ctx, tx, queryCourseSessions, CourseID)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if err = checkSession(ctx, tx, session); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//findClash returns a session overlapping session that matches the extra argument of query, or nil if none does.
+func findClash(ctx context.Context, tx *sql.Tx, query string, session Session, arg string) (*Session, error) {
+	clashes, err := scanSessions(ctx, tx, query, weekdays[session.Day], session.EndTime, session.StartTime, session.SessionID, arg)
+	if err != nil || len(clashes) == 0 {
+		return nil, err
+	}
+	return &clashes[0], nil
+}
+
+//describeSlot formats the day and time of a session for an error message.
+func describeSlot(session Session) string {
+	return session.Day + " " + session.StartTime + "-" + session.EndTime
+}
+
+//roomTooSmall is the error for a course with more students than the room it meets in holds.
+func roomTooSmall(room Room, CourseID string, classSize int) error {
+	return &RuleError{ErrConflict, "room " + room.RoomID + " holds " + strconv.Itoa(room.Capacity) +
+		" students but " + CourseID + " has a ClassSize of " + strconv.Itoa(classSize)}
+}
+
+func getRoom(ctx context.Context, q querier, query string, RoomID string) (Room, error) {
+	var room Room
+	err := queryRowContext(ctx, q, query, RoomID).Scan(&room.RoomID, &room.Name, &room.Capacity)
+	if err == sql.ErrNoRows {
+		err = ErrRoomNotFound
+	}
+	return room, err
+}
+
+//scanSessions reads every session returned by query.
+func scanSessions(ctx context.Context, q querier, query string, args ...interface{}) ([]Session, error) {
+	sessions := []Session{}
+	rows, err := queryContext(ctx, q, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var session Session
+		var day int
+		if err = rows.Scan(&session.SessionID, &session.CourseID, &day, &session.StartTime, &session.EndTime, &session.RoomID); err != nil {
+			return nil, err
+		}
+		session.Day = dayName(day)
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+//scanCalendar reads every calendar entry returned by query.
+func scanCalendar(ctx context.Context, q querier, query string, args ...interface{}) ([]CalendarEntry, error) {
+	entries := []CalendarEntry{}
+	rows, err := queryContext(ctx, q, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var entry CalendarEntry
+		var day int
+		err = rows.Scan(&entry.SessionID, &entry.CourseID, &day, &entry.StartTime, &entry.EndTime, &entry.RoomID, &entry.Title, &entry.Lecturer, &entry.RoomName)
+		if err != nil {
+			return nil, err
+		}
+		entry.Day = dayName(day)
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
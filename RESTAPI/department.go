@@ -0,0 +1,180 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	database "goMicroService1Assignment/RESTAPI/database"
+)
+
+//validateDepartment sanitizes a department in place and checks it against the rules for each field.
+//Prefixes are upper-cased and deduplicated; a department may own none yet.
+func validateDepartment(d *database.Department) error {
+	d.DepartmentID = Policy.Sanitize(strings.TrimSpace(d.DepartmentID))
+	if !regexDepartmentID.MatchString(d.DepartmentID) {
+		return errors.New("incorrect format for Department ID")
+	}
+	if d.Name == "" {
+		return errors.New("information supplied not complete")
+	}
+	d.Name = Policy.Sanitize(strings.TrimSpace(d.Name))
+	if !regexTitleLecturer.MatchString(d.Name) {
+		return errors.New("incorrect format for Department Name")
+	}
+	seen := map[string]bool{}
+	prefixes := []string{}
+	for _, prefix := range d.Prefixes {
+		prefix = strings.ToUpper(Policy.Sanitize(strings.TrimSpace(prefix)))
+		if !regexPrefix.MatchString(prefix) {
+			return errors.New("incorrect format for Prefix, it must be three letters")
+		}
+		if !seen[prefix] {
+			seen[prefix] = true
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	sort.Strings(prefixes)
+	d.Prefixes = prefixes
+	return nil
+}
+
+//departments lists every department with the prefixes it owns.
+func departments(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+
+	allDepartments, err := database.GetAllDepartments(r.Context(), db)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	writeResponse(w, r, http.StatusOK, &allDepartments)
+}
+
+//department gets, creates, updates or deletes the department named in the URL.
+//PUT replaces the name and the whole set of prefixes; a prefix can only be dropped once no course uses it.
+func department(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+	departmentID, ok := pathID(w, r, "departmentid", regexDepartmentID, "Department ID")
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		department, err := database.GetDepartment(r.Context(), db, departmentID)
+		if err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		writeResponse(w, r, http.StatusOK, &department)
+
+	case http.MethodPost, http.MethodPut:
+		var newDepartment database.Department
+		if !decodeRequest(w, r, &newDepartment) {
+			return
+		}
+		if newDepartment.DepartmentID != "" && newDepartment.DepartmentID != departmentID {
+			writeJSONError(w, r, http.StatusUnprocessableEntity, "422 - DepartmentID in the body does not match the URL")
+			log.Warning("Fail attempt to save department: 422 - DepartmentID in the body does not match the URL")
+			return
+		}
+		newDepartment.DepartmentID = departmentID
+		if err := validateDepartment(&newDepartment); err != nil {
+			writeJSONError(w, r, http.StatusUnprocessableEntity, "422 - "+err.Error())
+			log.Warning("Fail attempt to save department: 422 - ", err)
+			return
+		}
+
+		status := http.StatusCreated
+		var err error
+		if r.Method == http.MethodPost {
+			err = database.InsertDepartment(r.Context(), db, newDepartment)
+		} else {
+			status = http.StatusOK
+			err = database.UpdateDepartment(r.Context(), db, newDepartment)
+		}
+		var rule *database.RuleError
+		if errors.Is(err, database.ErrConflict) && !errors.As(err, &rule) {
+			writeJSONError(w, r, http.StatusConflict, "409 - Duplicate department ID or name")
+			log.Warning("Fail attempt to save department: 409 - Duplicate department ID or name")
+		} else if err != nil {
+			writeDBError(w, r, err)
+		} else {
+			writeResponse(w, r, status, &newDepartment)
+		}
+
+	case http.MethodDelete:
+		if err := database.DeleteDepartment(r.Context(), db, departmentID); err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+//departmentCourses lists the courses whose prefix belongs to the department named in the URL.
+func departmentCourses(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+	departmentID, ok := pathID(w, r, "departmentid", regexDepartmentID, "Department ID")
+	if !ok {
+		return
+	}
+
+	courses, err := database.GetDepartmentCourses(r.Context(), db, departmentID)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	writeResponse(w, r, http.StatusOK, &courses)
+}
+
+//departmentLecturers lists the lecturers belonging to the department named in the URL.
+func departmentLecturers(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+	departmentID, ok := pathID(w, r, "departmentid", regexDepartmentID, "Department ID")
+	if !ok {
+		return
+	}
+
+	lecturers, err := database.GetDepartmentLecturers(r.Context(), db, departmentID)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	writeResponse(w, r, http.StatusOK, &lecturers)
+}
+
+//departmentStats counts the courses, lecturers, seats and students of the department named in the URL.
+func departmentStats(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+	departmentID, ok := pathID(w, r, "departmentid", regexDepartmentID, "Department ID")
+	if !ok {
+		return
+	}
+
+	stats, err := database.GetDepartmentStats(r.Context(), db, departmentID)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	writeResponse(w, r, http.StatusOK, &stats)
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	database "goMicroService1Assignment/RESTAPI/database"
+)
+
+//regular expression pattern for student input.
+var (
+	regexStudentID = regexp.MustCompile(`^S[0-9]{7}$`)
+	regexEmail     = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+)
+
+//maxEmailLength is the width of the Email column.
+const maxEmailLength = 60
+
+//enrollmentRequest is the body of a POST to the enrolments of a course.
+type enrollmentRequest struct {
+	StudentID string `yaml:"StudentID"`
+}
+
+//courseEnrollments is the seat count of a course with the students holding the seats and those waiting for one.
+type courseEnrollments struct {
+	CourseID    string                `yaml:"CourseID"`
+	ClassSize   int                   `yaml:"ClassSize"`
+	Enrolled    int                   `yaml:"Enrolled"`
+	SeatsLeft   int                   `yaml:"SeatsLeft"`
+	Waitlisted  int                   `yaml:"Waitlisted"`
+	Enrollments []database.Enrollment `yaml:"Enrollments"`
+	Waitlist    []database.Enrollment `yaml:"Waitlist"`
+}
+
+//validateStudent sanitizes a student in place and checks it against the rules for each field.
+func validateStudent(s *database.Student) error {
+	s.StudentID = Policy.Sanitize(strings.TrimSpace(s.StudentID))
+	if !regexStudentID.MatchString(s.StudentID) {
+		return errors.New("incorrect format for Student ID")
+	}
+	if s.Name == "" || s.Email == "" {
+		return errors.New("information supplied not complete")
+	}
+	s.Name = Policy.Sanitize(strings.TrimSpace(s.Name))
+	if !regexTitleLecturer.MatchString(s.Name) {
+		return errors.New("incorrect format for Student Name")
+	}
+	s.Email = Policy.Sanitize(strings.TrimSpace(s.Email))
+	if len(s.Email) > maxEmailLength || !regexEmail.MatchString(s.Email) {
+		return errors.New("incorrect format for Student Email")
+	}
+	return nil
+}
+
+//pathID sanitizes and validates the path parameter name, answering 400 if it does not match re.
+func pathID(w http.ResponseWriter, r *http.Request, name string, re *regexp.Regexp, label string) (string, bool) {
+	id := Policy.Sanitize(mux.Vars(r)[name])
+	if !re.MatchString(id) {
+		writeJSONError(w, r, http.StatusBadRequest, "400 - incorrect format for "+label)
+		log.Warning("Fail attempt in path parameter: 400 - incorrect format for ", label)
+		return "", false
+	}
+	return id, true
+}
+
+//students lists every student.
+func students(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+
+	allStudents, err := database.GetAllStudents(r.Context(), db)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	writeResponse(w, r, http.StatusOK, &allStudents)
+}
+
+//student gets, creates or deletes the student named in the URL.
+func student(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+	studentID, ok := pathID(w, r, "studentid", regexStudentID, "Student ID")
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		student, err := database.GetStudent(r.Context(), db, studentID)
+		if err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		writeResponse(w, r, http.StatusOK, &student)
+
+	case http.MethodPost:
+		var newStudent database.Student
+		if !decodeRequest(w, r, &newStudent) {
+			return
+		}
+		if newStudent.StudentID != "" && newStudent.StudentID != studentID {
+			writeJSONError(w, r, http.StatusUnprocessableEntity, "422 - StudentID in the body does not match the URL")
+			log.Warning("Fail attempt to insert student: 422 - StudentID in the body does not match the URL")
+			return
+		}
+		newStudent.StudentID = studentID
+		if err := validateStudent(&newStudent); err != nil {
+			writeJSONError(w, r, http.StatusUnprocessableEntity, "422 - "+err.Error())
+			log.Warning("Fail attempt to insert student: 422 - ", err)
+			return
+		}
+
+		err := database.InsertStudent(r.Context(), db, newStudent)
+		if errors.Is(err, database.ErrConflict) {
+			writeJSONError(w, r, http.StatusConflict, "409 - Duplicate student ID")
+			log.Warning("Fail attempt to insert student: 409 - Duplicate student ID")
+		} else if err != nil {
+			writeDBError(w, r, err)
+		} else {
+			writeResponse(w, r, http.StatusCreated, &newStudent)
+		}
+
+	case http.MethodDelete:
+		if err := database.DeleteStudent(r.Context(), db, studentID); err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+//enrollments lists the students enrolled in or waiting for a course, or enrols one more.
+//A student who finds the course full is put on its waitlist and answered with 202 instead of 201.
+func enrollments(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+	courseID, ok := pathID(w, r, "courseid", regexCourseID, "Course ID")
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		course, list, waitlist, err := database.GetEnrollments(r.Context(), db, courseID)
+		if err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		writeResponse(w, r, http.StatusOK, &courseEnrollments{
+			CourseID:    course.CourseID,
+			ClassSize:   course.ClassSize,
+			Enrolled:    len(list),
+			SeatsLeft:   course.ClassSize - len(list),
+			Waitlisted:  len(waitlist),
+			Enrollments: list,
+			Waitlist:    waitlist,
+		})
+
+	case http.MethodPost:
+		var req enrollmentRequest
+		if !decodeRequest(w, r, &req) {
+			return
+		}
+		req.StudentID = Policy.Sanitize(strings.TrimSpace(req.StudentID))
+		if !regexStudentID.MatchString(req.StudentID) {
+			writeJSONError(w, r, http.StatusUnprocessableEntity, "422 - incorrect format for Student ID")
+			log.Warning("Fail attempt to enrol: 422 - incorrect format for Student ID")
+			return
+		}
+
+		// the seat is counted and taken in one transaction, so a full course can only put the student on its waitlist
+		enrollment, err := database.Enroll(r.Context(), db, courseID, req.StudentID)
+		if err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		if enrollment.Status == database.StatusWaitlisted {
+			log.Info("Student ", enrollment.StudentID, " waitlisted for ", courseID, " at position ", enrollment.Position)
+			writeResponse(w, r, http.StatusAccepted, &enrollment)
+			return
+		}
+		writeResponse(w, r, http.StatusCreated, &enrollment)
+	}
+}
+
+//enrollment shows the seat or waitlist position of the student named in the URL, or gives it up.
+//Giving up a seat promotes the first student on the waitlist into it.
+func enrollment(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+	courseID, ok := pathID(w, r, "courseid", regexCourseID, "Course ID")
+	if !ok {
+		return
+	}
+	studentID, ok := pathID(w, r, "studentid", regexStudentID, "Student ID")
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		enrollment, err := database.GetEnrollment(r.Context(), db, courseID, studentID)
+		if err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		writeResponse(w, r, http.StatusOK, &enrollment)
+
+	case http.MethodDelete:
+		promoted, err := database.Unenroll(r.Context(), db, courseID, studentID)
+		if err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		if len(promoted) > 0 {
+			log.Info("Promoted from the waitlist of ", courseID, ": ", strings.Join(promoted, ", "))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
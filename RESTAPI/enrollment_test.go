@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+//enrolmentCourse is the course the enrolment tests fill, with the students they create.
+const enrolmentCourse = "GOS9990"
+
+//testStudent is the ID of the i-th student the enrolment tests create.
+func testStudent(i int) string {
+	return fmt.Sprintf("S99990%02d", i)
+}
+
+//removeEnrolments deletes the test course with its seats and waitlist, and the test students.
+func removeEnrolments(t *testing.T) {
+	t.Helper()
+	for _, query := range []string{
+		"DELETE FROM Waitlist WHERE CourseID=?",
+		"DELETE FROM Enrollment WHERE CourseID=?",
+		"DELETE FROM Course WHERE CourseID=?",
+	} {
+		if _, err := db.Exec(query, enrolmentCourse); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Exec("DELETE FROM Student WHERE StudentID LIKE 'S99990%'"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+//setUpEnrolments creates the test course with classSize seats and the given number of students.
+func setUpEnrolments(t *testing.T, classSize, students int) {
+	t.Helper()
+	removeEnrolments(t)
+	body := fmt.Sprintf(`{"Title":"Go Enrolment","LecturerID":"L0003","ClassSize":%d}`, classSize)
+	if w := serve("PUT", "/api/v1/courses/"+enrolmentCourse+"?key="+testKey, "application/json", body); w.Code != http.StatusCreated {
+		t.Fatalf("creating %s answered %d: %s", enrolmentCourse, w.Code, w.Body.String())
+	}
+	for i := 0; i < students; i++ {
+		body := fmt.Sprintf(`{"Name":"Test Student %d","Email":"student%d@example.com"}`, i, i)
+		if w := serve("POST", "/api/v1/students/"+testStudent(i)+"?key="+testKey, "application/json", body); w.Code != http.StatusCreated {
+			t.Fatalf("creating %s answered %d: %s", testStudent(i), w.Code, w.Body.String())
+		}
+	}
+}
+
+//enrol asks for a seat for the i-th student and returns the status of the answer.
+func enrol(i int) int {
+	body := `{"StudentID":"` + testStudent(i) + `"}`
+	return serve("POST", "/api/v1/courses/"+enrolmentCourse+"/enrollments?key="+testKey, "application/json", body).Code
+}
+
+//countRows counts the rows of table that belong to the test course.
+func countRows(t *testing.T, table string) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE CourseID=?", enrolmentCourse).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+//TestConcurrentEnrol has more students than seats enrol at once. Exactly ClassSize of them may get a seat,
+//the rest must be waitlisted.
+func TestConcurrentEnrol(t *testing.T) {
+	needDB(t)
+	const classSize, students = 5, 20
+	setUpEnrolments(t, classSize, students)
+	defer removeEnrolments(t)
+
+	start := make(chan struct{})
+	statuses := make([]int, students)
+	var wg sync.WaitGroup
+	for i := 0; i < students; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			statuses[i] = enrol(i)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	seated, waiting := 0, 0
+	for i, status := range statuses {
+		switch status {
+		case http.StatusCreated:
+			seated++
+		case http.StatusAccepted:
+			waiting++
+		default:
+			t.Errorf("enrolling %s answered %d", testStudent(i), status)
+		}
+	}
+	if seated != classSize || waiting != students-classSize {
+		t.Errorf("%d students seated and %d waitlisted, want %d and %d", seated, waiting, classSize, students-classSize)
+	}
+	if enrolled := countRows(t, "Enrollment"); enrolled != classSize {
+		t.Errorf("%d seats taken in a course of %d", enrolled, classSize)
+	}
+	if waitlisted := countRows(t, "Waitlist"); waitlisted != students-classSize {
+		t.Errorf("%d students waitlisted, want %d", waitlisted, students-classSize)
+	}
+}
+
+//enrolledStudents returns the students holding a seat in the test course and those on its waitlist, in order.
+func enrolledStudents(t *testing.T) (enrolled map[string]bool, waitlist []string) {
+	t.Helper()
+	enrolled = map[string]bool{}
+	rows, err := db.Query("SELECT StudentID FROM Enrollment WHERE CourseID=?", enrolmentCourse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		if enrolled[id] {
+			t.Errorf("%s holds two seats", id)
+		}
+		enrolled[id] = true
+	}
+	rows.Close()
+
+	rows, err = db.Query("SELECT StudentID FROM Waitlist WHERE CourseID=? ORDER BY RequestedAt, StudentID", enrolmentCourse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			t.Fatal(err)
+		}
+		waitlist = append(waitlist, id)
+	}
+	return enrolled, waitlist
+}
+
+//TestConcurrentPromotion frees seats from several requests at once, by unenrolling students while the course
+//grows. Every free seat must go to the next student on the waitlist, and no student may be promoted twice.
+func TestConcurrentPromotion(t *testing.T) {
+	needDB(t)
+	const classSize, waiting, grown = 5, 10, 8
+	setUpEnrolments(t, classSize, classSize+waiting)
+	defer removeEnrolments(t)
+
+	for i := 0; i < classSize+waiting; i++ { //one at a time, so the waitlist is in student order
+		want := http.StatusCreated
+		if i >= classSize {
+			want = http.StatusAccepted
+		}
+		if status := enrol(i); status != want {
+			t.Fatalf("enrolling %s answered %d, want %d", testStudent(i), status, want)
+		}
+	}
+
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < classSize; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			target := "/api/v1/courses/" + enrolmentCourse + "/enrollments/" + testStudent(i) + "?key=" + testKey
+			if w := serve("DELETE", target, "", ""); w.Code != http.StatusNoContent {
+				t.Errorf("unenrolling %s answered %d: %s", testStudent(i), w.Code, w.Body.String())
+			}
+		}(i)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-start
+		body := fmt.Sprintf(`{"ClassSize":%d}`, grown)
+		if w := serve("PATCH", "/api/v1/courses/"+enrolmentCourse+"?key="+testKey, "application/json", body); w.Code != http.StatusOK {
+			t.Errorf("growing %s answered %d: %s", enrolmentCourse, w.Code, w.Body.String())
+		}
+	}()
+	close(start)
+	wg.Wait()
+
+	enrolled, waitlist := enrolledStudents(t)
+	if len(enrolled) != grown {
+		t.Errorf("%d seats taken in a course of %d", len(enrolled), grown)
+	}
+	for i := classSize; i < classSize+grown; i++ {
+		if !enrolled[testStudent(i)] {
+			t.Errorf("%s, number %d on the waitlist, was not promoted", testStudent(i), i-classSize+1)
+		}
+	}
+	wantWaitlist := []string{}
+	for i := classSize + grown; i < classSize+waiting; i++ {
+		wantWaitlist = append(wantWaitlist, testStudent(i))
+	}
+	if fmt.Sprint(waitlist) != fmt.Sprint(wantWaitlist) {
+		t.Errorf("waitlist is %v, want %v", waitlist, wantWaitlist)
+	}
+	for _, id := range waitlist {
+		if enrolled[id] {
+			t.Errorf("%s was promoted but is still waitlisted", id)
+		}
+	}
+}
@@ -0,0 +1,560 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	database "goMicroService1Assignment/RESTAPI/database"
+)
+
+//icsProdID identifies this API as the producer of the calendars it exports (RFC 5545 section 3.7.3).
+const icsProdID = "-//CourseListing REST API//Timetable//EN"
+
+//icsDateTime is the layout of a DATE-TIME value in floating local time. Session times have no time zone,
+//so exported events keep the same wall-clock time wherever the calendar is opened.
+const icsDateTime = "20060102T150405"
+
+//icsDays maps the Day of a session to its RFC 5545 weekday.
+var icsDays = map[string]string{
+	"Monday": "MO", "Tuesday": "TU", "Wednesday": "WE", "Thursday": "TH", "Friday": "FR", "Saturday": "SA", "Sunday": "SU",
+}
+
+//regexDuration matches the DURATION values an imported event may use instead of DTEND, e.g. PT1H30M.
+var regexDuration = regexp.MustCompile(`^PT(?:([0-9]+)H)?(?:([0-9]+)M)?(?:([0-9]+)S)?$`)
+
+//calendarRange is the span the weekly events of an exported calendar repeat over. Without a semester
+//they start in the current week and repeat without end.
+type calendarRange struct {
+	semesterID string
+	from       time.Time
+	until      time.Time //zero for no end
+}
+
+//requestRange reads the semester query parameter of a calendar export, writing the error response
+//itself and returning false if the semester is malformed or does not exist.
+func requestRange(w http.ResponseWriter, r *http.Request) (calendarRange, bool) {
+	semesterID := Policy.Sanitize(r.URL.Query().Get("semester"))
+	if semesterID == "" {
+		now := time.Now()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		return calendarRange{from: today.AddDate(0, 0, -(int(today.Weekday())+6)%7)}, true //Monday of this week
+	}
+	if !regexSemesterID.MatchString(semesterID) {
+		writeJSONError(w, r, http.StatusBadRequest, "400 - incorrect format for Semester ID in semester")
+		log.Warning("Fail attempt to export calendar: 400 - incorrect format for Semester ID in semester")
+		return calendarRange{}, false
+	}
+	semester, err := database.GetSemester(r.Context(), db, semesterID)
+	if err != nil {
+		writeDBError(w, r, err)
+		return calendarRange{}, false
+	}
+	from, _ := time.Parse(dateLayout, semester.StartDate)
+	until, _ := time.Parse(dateLayout, semester.EndDate)
+	return calendarRange{semesterID: semesterID, from: from, until: until.Add(24*time.Hour - time.Second)}, true
+}
+
+//courseCalendar exports the weekly sessions of the course named in the URL as an iCalendar file.
+func courseCalendar(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+	courseID, ok := pathID(w, r, "courseid", regexCourseID, "Course ID")
+	if !ok {
+		return
+	}
+	span, ok := requestRange(w, r)
+	if !ok {
+		return
+	}
+
+	entries, err := database.GetCourseCalendar(r.Context(), db, courseID)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	writeCalendar(w, r, courseID, entries, span)
+}
+
+//lecturerCalendar exports the weekly sessions of every course the lecturer named in the URL teaches
+//as an iCalendar file.
+func lecturerCalendar(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+	lecturerID, ok := pathID(w, r, "lecturerid", regexLecturerID, "Lecturer ID")
+	if !ok {
+		return
+	}
+	span, ok := requestRange(w, r)
+	if !ok {
+		return
+	}
+
+	entries, err := database.GetLecturerCalendar(r.Context(), db, lecturerID)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	writeCalendar(w, r, lecturerID, entries, span)
+}
+
+//writeCalendar writes one VEVENT per session, repeating weekly on its day over span. A session whose day
+//does not occur within a semester shorter than a week is left out.
+func writeCalendar(w http.ResponseWriter, r *http.Request, name string, entries []database.CalendarEntry, span calendarRange) {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	suffix := ""
+	if span.semesterID != "" {
+		name += " " + span.semesterID
+		suffix = "-" + span.semesterID
+	}
+	stamp := time.Now().UTC().Format(icsDateTime) + "Z"
+
+	lines := []string{"BEGIN:VCALENDAR", "VERSION:2.0", "PRODID:" + icsProdID, "CALSCALE:GREGORIAN", "METHOD:PUBLISH",
+		"X-WR-CALNAME:" + icsText(name+" timetable")}
+	for _, entry := range entries {
+		first := span.from.AddDate(0, 0, (int(weekday(entry.Day))-int(span.from.Weekday())+7)%7)
+		if !span.until.IsZero() && first.After(span.until) {
+			continue
+		}
+		start, _ := time.Parse(timeLayout, entry.StartTime)
+		end, _ := time.Parse(timeLayout, entry.EndTime)
+		rule := "FREQ=WEEKLY;BYDAY=" + icsDays[entry.Day]
+		if !span.until.IsZero() {
+			rule += ";UNTIL=" + span.until.Format(icsDateTime)
+		}
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:session-"+strconv.Itoa(entry.SessionID)+suffix+"@"+host,
+			"DTSTAMP:"+stamp,
+			"DTSTART:"+first.Add(time.Duration(start.Hour())*time.Hour+time.Duration(start.Minute())*time.Minute).Format(icsDateTime),
+			"DTEND:"+first.Add(time.Duration(end.Hour())*time.Hour+time.Duration(end.Minute())*time.Minute).Format(icsDateTime),
+			"RRULE:"+rule,
+			"SUMMARY:"+icsText(entry.CourseID+" "+strings.TrimSpace(entry.Title)),
+			"LOCATION:"+icsText(entry.RoomName+" ("+entry.RoomID+")"),
+			"DESCRIPTION:"+icsText("Lecturer: "+entry.Lecturer),
+			"END:VEVENT")
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+strings.Replace(name, " ", "-", -1)+`.ics"`)
+	for _, line := range lines {
+		io.WriteString(w, icsFold(line))
+	}
+}
+
+//weekday returns the time.Weekday named by the Day of a session.
+func weekday(day string) time.Weekday {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if d.String() == day {
+			return d
+		}
+	}
+	return time.Sunday
+}
+
+//icsText escapes a TEXT value (RFC 5545 section 3.3.11).
+func icsText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+//icsUnescape reverses icsText.
+func icsUnescape(s string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(s)
+}
+
+//icsFold ends a content line with CRLF, folding it into lines of at most 75 octets without splitting a
+//UTF-8 sequence (RFC 5545 section 3.1).
+func icsFold(line string) string {
+	var b strings.Builder
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 { //continuation byte
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74 //the leading space counts towards the next line
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+	return b.String()
+}
+
+//icsProperty is one content line of an uploaded calendar.
+type icsProperty struct {
+	params map[string]string
+	value  string
+}
+
+//icsEvent is one VEVENT of an uploaded calendar and the line its BEGIN:VEVENT is on.
+type icsEvent struct {
+	line  int
+	props map[string]icsProperty
+}
+
+//readCalendar parses the VEVENTs of an iCalendar upload. Components nested in an event, such as
+//VALARM, are skipped, as is everything outside the events.
+func readCalendar(body io.Reader) ([]icsEvent, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), int(maxBatchBodyBytes))
+
+	events := []icsEvent{}
+	var event *icsEvent
+	depth, started := 0, false //depth counts the components open inside the current event
+	handle := func(line int, content string) error {
+		name, prop := parseICSLine(content)
+		switch {
+		case name == "BEGIN" && strings.EqualFold(prop.value, "VCALENDAR"):
+			started = true
+		case !started:
+			return errors.New("the file is not an iCalendar file, it must start with BEGIN:VCALENDAR")
+		case name == "BEGIN" && event == nil && strings.EqualFold(prop.value, "VEVENT"):
+			if len(events) >= maxBatchItems {
+				return fmt.Errorf("the file has more than %d events", maxBatchItems)
+			}
+			event = &icsEvent{line: line, props: map[string]icsProperty{}}
+		case name == "BEGIN" && event != nil:
+			depth++
+		case name == "END" && event != nil && depth > 0:
+			depth--
+		case name == "END" && event != nil:
+			events = append(events, *event)
+			event = nil
+		case event != nil && depth == 0:
+			if _, seen := event.props[name]; !seen {
+				event.props[name] = prop
+			}
+		}
+		return nil
+	}
+
+	content, first, line := "", 0, 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if line == 1 {
+			text = strings.TrimPrefix(text, "\ufeff") //byte order mark written by some editors
+		}
+		if strings.HasPrefix(text, " ") || strings.HasPrefix(text, "\t") { //folded continuation
+			content += text[1:]
+			continue
+		}
+		if content != "" {
+			if err := handle(first, content); err != nil {
+				return nil, err
+			}
+		}
+		content, first = text, line
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if content != "" {
+		if err := handle(first, content); err != nil {
+			return nil, err
+		}
+	}
+	if !started {
+		return nil, errors.New("the file is empty, expected an iCalendar file")
+	}
+	if len(events) == 0 {
+		return nil, errors.New("the calendar has no events")
+	}
+	return events, nil
+}
+
+//parseICSLine splits a content line into its upper-cased name, its parameters and its value.
+func parseICSLine(content string) (string, icsProperty) {
+	prop := icsProperty{params: map[string]string{}}
+	quoted, colon := false, len(content)
+	for i, c := range content {
+		if c == '"' {
+			quoted = !quoted
+		} else if c == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < len(content) {
+		prop.value = content[colon+1:]
+	}
+	parts := strings.Split(content[:colon], ";")
+	for _, param := range parts[1:] {
+		if eq := strings.Index(param, "="); eq > 0 {
+			prop.params[strings.ToUpper(param[:eq])] = strings.Trim(param[eq+1:], `"`)
+		}
+	}
+	return strings.ToUpper(strings.TrimSpace(parts[0])), prop
+}
+
+//eventSessions turns an uploaded event into the weekly sessions of a course, one for each day its RRULE
+//repeats on. UTC times and times in a named TZID are converted to the server's time zone, which the timetable
+//is kept in, and the BYDAY days move with DTSTART when the conversion crosses midnight; floating times are
+//taken as written. The room is the RoomID in brackets at the end of LOCATION,
+//as exported, or the whole of LOCATION.
+func eventSessions(event icsEvent, courseID string) ([]database.Session, error) {
+	dtstart, ok := event.props["DTSTART"]
+	if !ok {
+		return nil, errors.New("the event has no DTSTART")
+	}
+	start, shift, err := parseICSTime(dtstart)
+	if err != nil {
+		return nil, err
+	}
+	var end time.Time
+	if dtend, ok := event.props["DTEND"]; ok {
+		if end, _, err = parseICSTime(dtend); err != nil {
+			return nil, err
+		}
+	} else if duration, ok := event.props["DURATION"]; ok {
+		match := regexDuration.FindStringSubmatch(strings.TrimSpace(duration.value))
+		if match == nil {
+			return nil, fmt.Errorf("DURATION %q must be hours and minutes, e.g. PT1H30M", duration.value)
+		}
+		hours, _ := strconv.Atoi(match[1])
+		minutes, _ := strconv.Atoi(match[2])
+		seconds, _ := strconv.Atoi(match[3])
+		end = start.Add(time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second)
+	} else {
+		return nil, errors.New("the event has no DTEND or DURATION")
+	}
+	if !end.After(start) || end.YearDay() != start.YearDay() || end.Year() != start.Year() {
+		return nil, errors.New("the event must end after it starts on the same day")
+	}
+
+	rrule, ok := event.props["RRULE"]
+	if !ok {
+		return nil, errors.New("the event does not repeat, only weekly events can become sessions")
+	}
+	rule := map[string]string{}
+	for _, part := range strings.Split(rrule.value, ";") {
+		if eq := strings.Index(part, "="); eq > 0 {
+			rule[strings.ToUpper(part[:eq])] = strings.ToUpper(part[eq+1:])
+		}
+	}
+	if rule["FREQ"] != "WEEKLY" || (rule["INTERVAL"] != "" && rule["INTERVAL"] != "1") {
+		return nil, errors.New("the event does not repeat every week, only weekly events can become sessions")
+	}
+	days := []string{start.Weekday().String()}
+	if rule["BYDAY"] != "" {
+		days = days[:0]
+		for _, code := range strings.Split(rule["BYDAY"], ",") {
+			day := ""
+			for name, c := range icsDays {
+				if c == code {
+					day = name
+				}
+			}
+			if day == "" {
+				return nil, fmt.Errorf("BYDAY %q is not a weekday such as MO", code)
+			}
+			// BYDAY names days in the zone of DTSTART, they move with it when it crosses midnight
+			days = append(days, time.Weekday((int(weekday(day))+shift+7)%7).String())
+		}
+	}
+
+	location, ok := event.props["LOCATION"]
+	if !ok || strings.TrimSpace(location.value) == "" {
+		return nil, errors.New("the event has no LOCATION naming the room")
+	}
+	roomID := strings.TrimSpace(icsUnescape(location.value))
+	if open := strings.LastIndex(roomID, "("); open >= 0 && strings.HasSuffix(roomID, ")") {
+		roomID = roomID[open+1 : len(roomID)-1]
+	}
+
+	sessions := make([]database.Session, 0, len(days))
+	for _, day := range days {
+		session := database.Session{CourseID: courseID, Day: day, StartTime: start.Format(timeLayout), EndTime: end.Format(timeLayout), RoomID: roomID}
+		if err := validateSession(&session); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, nil
+}
+
+//parseICSTime reads a DATE-TIME in the server's time zone. A value ending in Z is UTC and a TZID parameter
+//names the zone of the value, both are converted; a floating value is read as wall-clock time. shift is the
+//number of days the conversion moved the date by, -1, 0 or 1. All-day DATE values are refused since a session
+//needs a start and end time, and so are zones the server does not know.
+func parseICSTime(prop icsProperty) (t time.Time, shift int, err error) {
+	value := strings.TrimSpace(prop.value)
+	if prop.params["VALUE"] == "DATE" || len(value) == len("20060102") {
+		return time.Time{}, 0, errors.New("all-day events cannot become sessions")
+	}
+	zone := time.UTC //floating times keep their wall clock
+	convert := false
+	if strings.HasSuffix(value, "Z") {
+		value, convert = strings.TrimSuffix(value, "Z"), true
+	} else if tzid := prop.params["TZID"]; tzid != "" {
+		if zone, err = time.LoadLocation(tzid); err != nil {
+			return time.Time{}, 0, fmt.Errorf("time zone %q is not known, give the times in UTC or an IANA zone such as Asia/Singapore", tzid)
+		}
+		convert = true
+	}
+	written, err := time.ParseInLocation(icsDateTime, value, zone)
+	if err != nil {
+		return time.Time{}, 0, fmt.Errorf("%q is not an iCalendar date-time such as 20240115T090000", prop.value)
+	}
+	if !convert {
+		return written, 0, nil
+	}
+	t = written.In(time.Local)
+	shift = int(calendarDate(t).Sub(calendarDate(written)).Hours() / 24)
+	return t, shift, nil
+}
+
+//calendarDate returns the calendar date of t as midnight UTC, so dates in different zones can be subtracted.
+func calendarDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+//sessionImportResult reports the outcome for one session read from an iCalendar upload.
+type sessionImportResult struct {
+	Line    int    //line of the BEGIN:VEVENT the session was read from
+	UID     string `json:",omitempty"`
+	Session database.Session
+	Status  int
+	Error   string `json:",omitempty"`
+}
+
+//sessionImportResponse is the body returned by importSessionsICS.
+type sessionImportResponse struct {
+	Committed bool
+	Succeeded int
+	Failed    int
+	Results   []sessionImportResult
+}
+
+//importSessionsICS creates sessions of the course named in the URL from the weekly events of an iCalendar
+//upload. Like the CSV import of courses, every event is validated first and nothing is written if any is
+//rejected; otherwise all sessions are inserted in one transaction, so they are also checked for clashes
+//with each other. With dryRun=true the inserts are rolled back even when they all succeed.
+func importSessionsICS(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+	courseID, ok := pathID(w, r, "courseid", regexCourseID, "Course ID")
+	if !ok {
+		return
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "text/calendar" {
+		writeJSONError(w, r, http.StatusUnsupportedMediaType, "415 - Please supply sessions as text/calendar")
+		return
+	}
+	dryRun := r.URL.Query().Get("dryRun") == "true"
+
+	r.Body = limitBody(w, r.Body, maxBatchBodyBytes)
+	events, err := readCalendar(r.Body)
+	if isBodyTooLarge(r) {
+		writeJSONError(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("413 - Request body exceeds %d bytes", maxBatchBodyBytes))
+		return
+	} else if err != nil {
+		writeJSONError(w, r, http.StatusBadRequest, "400 - "+err.Error())
+		return
+	}
+	exist, err := database.CourseExist(r.Context(), db, courseID)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	} else if exist == 0 {
+		writeJSONError(w, r, http.StatusNotFound, "404 - No course found")
+		return
+	}
+
+	response := sessionImportResponse{Results: []sessionImportResult{}}
+	for _, event := range events {
+		uid := icsUnescape(event.props["UID"].value)
+		sessions, err := eventSessions(event, courseID)
+		if err != nil {
+			response.Results = append(response.Results, sessionImportResult{Line: event.line, UID: uid,
+				Session: database.Session{CourseID: courseID}, Status: http.StatusUnprocessableEntity, Error: err.Error()})
+			response.Failed++
+			continue
+		}
+		for _, session := range sessions {
+			response.Results = append(response.Results, sessionImportResult{Line: event.line, UID: uid, Session: session, Status: http.StatusCreated})
+		}
+	}
+
+	if response.Failed == 0 {
+		batch, err := database.BeginBatch(r.Context(), db, true)
+		if err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		for i := range response.Results {
+			result := &response.Results[i]
+			saved, err := batch.InsertSession(r.Context(), result.Session)
+			if err != nil {
+				result.Status, result.Error = statusFor(err), err.Error()
+				response.Failed++
+			} else {
+				result.Session = saved
+			}
+		}
+		if dryRun {
+			batch.Fail()
+		}
+		response.Committed, err = batch.Finish(r.Context())
+		if err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+	}
+
+	rejected := response.Failed
+	for i := range response.Results {
+		result := &response.Results[i]
+		if !response.Committed {
+			result.Session.SessionID = 0 //rolled back, the ID was never kept
+		}
+		if result.Error == "" && !response.Committed {
+			if dryRun && rejected == 0 {
+				result.Status = http.StatusOK
+			} else {
+				result.Status, result.Error = http.StatusFailedDependency, "not imported because other events failed"
+				response.Failed++
+			}
+		}
+		if result.Error == "" {
+			response.Succeeded++
+		}
+	}
+
+	status := http.StatusCreated
+	if rejected > 0 {
+		status = http.StatusUnprocessableEntity
+		log.WithField("requestID", requestID(r)).Warningf("iCalendar import into %s rejected with %d of %d sessions failed", courseID, rejected, len(response.Results))
+	} else if dryRun {
+		status = http.StatusOK
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&response)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	_ "time/tzdata" //the zones below must load on machines without a zoneinfo database
+
+	database "goMicroService1Assignment/RESTAPI/database"
+)
+
+//inZone runs fn with the server's time zone set to name.
+func inZone(t *testing.T, name string, fn func()) {
+	t.Helper()
+	zone, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	local := time.Local
+	time.Local = zone
+	defer func() { time.Local = local }()
+	fn()
+}
+
+//calendar wraps content lines in a VCALENDAR and ends every line with CRLF.
+func calendar(lines ...string) string {
+	lines = append(append([]string{"BEGIN:VCALENDAR", "VERSION:2.0"}, lines...), "END:VCALENDAR")
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+func TestParseICSTime(t *testing.T) {
+	cases := []struct {
+		name   string
+		local  string
+		prop   icsProperty
+		want   string //time in the server's zone, as 2006-01-02 15:04
+		shift  int
+		errors bool
+	}{
+		{"floating", "Asia/Singapore", icsProperty{map[string]string{}, "20240115T090000"}, "2024-01-15 09:00", 0, false},
+		{"utc same day", "Asia/Singapore", icsProperty{map[string]string{}, "20240115T010000Z"}, "2024-01-15 09:00", 0, false},
+		{"utc past midnight", "Asia/Singapore", icsProperty{map[string]string{}, "20240115T230000Z"}, "2024-01-16 07:00", 1, false},
+		{"utc before midnight", "America/New_York", icsProperty{map[string]string{}, "20240115T020000Z"}, "2024-01-14 21:00", -1, false},
+		{"tzid", "Asia/Singapore", icsProperty{map[string]string{"TZID": "Europe/London"}, "20240115T090000"}, "2024-01-15 17:00", 0, false},
+		{"tzid past midnight", "Asia/Singapore", icsProperty{map[string]string{"TZID": "America/New_York"}, "20240115T200000"}, "2024-01-16 09:00", 1, false},
+		{"tzid of the server", "Asia/Singapore", icsProperty{map[string]string{"TZID": "Asia/Singapore"}, "20240115T090000"}, "2024-01-15 09:00", 0, false},
+		{"unknown tzid", "Asia/Singapore", icsProperty{map[string]string{"TZID": "Singapore Standard Time"}, "20240115T090000"}, "", 0, true},
+		{"date", "Asia/Singapore", icsProperty{map[string]string{}, "20240115"}, "", 0, true},
+		{"date value", "Asia/Singapore", icsProperty{map[string]string{"VALUE": "DATE"}, "20240115T090000"}, "", 0, true},
+		{"malformed", "Asia/Singapore", icsProperty{map[string]string{}, "2024-01-15 09:00"}, "", 0, true},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			inZone(t, c.local, func() {
+				got, shift, err := parseICSTime(c.prop)
+				if c.errors {
+					if err == nil {
+						t.Errorf("parseICSTime(%q) = %v, want an error", c.prop.value, got)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("parseICSTime(%q): %v", c.prop.value, err)
+				}
+				if got.Format("2006-01-02 15:04") != c.want || shift != c.shift {
+					t.Errorf("parseICSTime(%q) = %s shifted %d, want %s shifted %d", c.prop.value, got.Format("2006-01-02 15:04"), shift, c.want, c.shift)
+				}
+			})
+		})
+	}
+}
+
+func TestEventSessions(t *testing.T) {
+	session := func(day, start, end string) database.Session {
+		return database.Session{CourseID: "GOS1000", Day: day, StartTime: start, EndTime: end, RoomID: "LT-1"}
+	}
+	cases := []struct {
+		name   string
+		lines  []string
+		want   []database.Session
+		errors bool
+	}{
+		{"floating with BYDAY", []string{"DTSTART:20240115T090000", "DTEND:20240115T110000", "RRULE:FREQ=WEEKLY;BYDAY=MO,WE", "LOCATION:Lecture Theatre (LT-1)"},
+			[]database.Session{session("Monday", "09:00", "11:00"), session("Wednesday", "09:00", "11:00")}, false},
+		{"day of DTSTART", []string{"DTSTART:20240116T090000", "DURATION:PT1H30M", "RRULE:FREQ=WEEKLY", "LOCATION:LT-1"},
+			[]database.Session{session("Tuesday", "09:00", "10:30")}, false},
+		{"utc past midnight", []string{"DTSTART:20240115T230000Z", "DTEND:20240116T010000Z", "RRULE:FREQ=WEEKLY", "LOCATION:LT-1"},
+			[]database.Session{session("Tuesday", "07:00", "09:00")}, false},
+		{"utc past midnight with BYDAY", []string{"DTSTART:20240115T230000Z", "DTEND:20240116T010000Z", "RRULE:FREQ=WEEKLY;BYDAY=MO,SU", "LOCATION:LT-1"},
+			[]database.Session{session("Tuesday", "07:00", "09:00"), session("Monday", "07:00", "09:00")}, false},
+		{"tzid past midnight with BYDAY", []string{"DTSTART;TZID=America/New_York:20240119T200000", "DTEND;TZID=America/New_York:20240119T210000", "RRULE:FREQ=WEEKLY;BYDAY=FR,SA", "LOCATION:LT-1"},
+			[]database.Session{session("Saturday", "09:00", "10:00"), session("Sunday", "09:00", "10:00")}, false},
+		{"no RRULE", []string{"DTSTART:20240115T090000", "DTEND:20240115T110000", "LOCATION:LT-1"}, nil, true},
+		{"fortnightly", []string{"DTSTART:20240115T090000", "DTEND:20240115T110000", "RRULE:FREQ=WEEKLY;INTERVAL=2", "LOCATION:LT-1"}, nil, true},
+		{"bad BYDAY", []string{"DTSTART:20240115T090000", "DTEND:20240115T110000", "RRULE:FREQ=WEEKLY;BYDAY=1MO", "LOCATION:LT-1"}, nil, true},
+		{"ends next day", []string{"DTSTART:20240115T230000", "DTEND:20240116T010000", "RRULE:FREQ=WEEKLY", "LOCATION:LT-1"}, nil, true},
+		{"no LOCATION", []string{"DTSTART:20240115T090000", "DTEND:20240115T110000", "RRULE:FREQ=WEEKLY"}, nil, true},
+	}
+	inZone(t, "Asia/Singapore", func() {
+		for _, c := range cases {
+			lines := append(append([]string{"BEGIN:VEVENT"}, c.lines...), "END:VEVENT")
+			events, err := readCalendar(strings.NewReader(calendar(lines...)))
+			if err != nil {
+				t.Fatalf("%s: %v", c.name, err)
+			}
+			got, err := eventSessions(events[0], "GOS1000")
+			if c.errors {
+				if err == nil {
+					t.Errorf("%s: eventSessions = %v, want an error", c.name, got)
+				}
+				continue
+			}
+			if err != nil {
+				t.Errorf("%s: %v", c.name, err)
+			} else if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("%s: eventSessions = %v, want %v", c.name, got, c.want)
+			}
+		}
+	})
+}
+
+func TestReadCalendar(t *testing.T) {
+	long := "SUMMARY:" + strings.Repeat("Go Microservice 1 – ", 8) //multi-byte dashes must not be split
+	cases := []struct {
+		name   string
+		body   string
+		props  map[string]string //value of each property of the only event
+		line   int               //line of its BEGIN:VEVENT
+		errors bool
+	}{
+		{"folded with space and tab", calendar("BEGIN:VEVENT", "SUMMARY:Go ", " Basic", "LOCATION:Lecture", "\t Theatre (LT-1)", "END:VEVENT"),
+			map[string]string{"SUMMARY": "Go Basic", "LOCATION": "Lecture Theatre (LT-1)"}, 3, false},
+		{"folded by icsFold", calendar("BEGIN:VEVENT", strings.TrimSuffix(icsFold(long), "\r\n"), "END:VEVENT"),
+			map[string]string{"SUMMARY": strings.TrimPrefix(long, "SUMMARY:")}, 3, false},
+		{"bare LF and byte order mark", "\ufeffBEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART;TZID=\"Asia/Singapore\":20240115T09\n 0000\nEND:VEVENT\nEND:VCALENDAR\n",
+			map[string]string{"DTSTART": "20240115T090000"}, 2, false},
+		{"nested alarm", calendar("BEGIN:VEVENT", "SUMMARY:Go Basic", "BEGIN:VALARM", "SUMMARY:Reminder", "END:VALARM", "END:VEVENT"),
+			map[string]string{"SUMMARY": "Go Basic"}, 3, false},
+		{"empty", "", nil, 0, true},
+		{"not a calendar", "BEGIN:VEVENT\r\nEND:VEVENT\r\n", nil, 0, true},
+		{"no events", calendar(), nil, 0, true},
+	}
+	for _, c := range cases {
+		events, err := readCalendar(strings.NewReader(c.body))
+		if c.errors {
+			if err == nil {
+				t.Errorf("%s: readCalendar read %d events, want an error", c.name, len(events))
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+		if len(events) != 1 {
+			t.Errorf("%s: readCalendar read %d events, want 1", c.name, len(events))
+			continue
+		}
+		if events[0].line != c.line {
+			t.Errorf("%s: event starts on line %d, want %d", c.name, events[0].line, c.line)
+		}
+		props := map[string]string{}
+		for name, prop := range events[0].props {
+			props[name] = prop.value
+		}
+		if !reflect.DeepEqual(props, c.props) {
+			t.Errorf("%s: properties %q, want %q", c.name, props, c.props)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	database "goMicroService1Assignment/RESTAPI/database"
+)
+
+//validateLecturer sanitizes a lecturer in place and checks it against the rules for each field.
+//Email may be left empty, and so may the department, which is given by ID or by name like the lecturer of a course.
+func validateLecturer(l *database.Lecturer) error {
+	l.LecturerID = Policy.Sanitize(strings.TrimSpace(l.LecturerID))
+	if !regexLecturerID.MatchString(l.LecturerID) {
+		return errors.New("incorrect format for Lecturer ID")
+	}
+	if l.Name == "" {
+		return errors.New("information supplied not complete")
+	}
+	l.Name = Policy.Sanitize(strings.TrimSpace(l.Name))
+	if !regexTitleLecturer.MatchString(l.Name) {
+		return errors.New("incorrect format for Lecturer Name")
+	}
+	l.Email = Policy.Sanitize(strings.TrimSpace(l.Email))
+	if l.Email != "" && (len(l.Email) > maxEmailLength || !regexEmail.MatchString(l.Email)) {
+		return errors.New("incorrect format for Lecturer Email")
+	}
+	if l.DepartmentID != "" {
+		l.DepartmentID = Policy.Sanitize(strings.TrimSpace(l.DepartmentID))
+		if !regexDepartmentID.MatchString(l.DepartmentID) {
+			return errors.New("incorrect format for Department ID")
+		}
+		return nil
+	}
+	l.Department = Policy.Sanitize(strings.TrimSpace(l.Department))
+	if l.Department != "" && !regexTitleLecturer.MatchString(l.Department) {
+		return errors.New("incorrect format for Lecturer Department")
+	}
+	return nil
+}
+
+//lecturers lists every lecturer.
+func lecturers(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+
+	allLecturers, err := database.GetAllLecturers(r.Context(), db)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	writeResponse(w, r, http.StatusOK, &allLecturers)
+}
+
+//lecturer gets, creates, updates or deletes the lecturer named in the URL.
+//An update renames the lecturer on every course at once, since courses only keep the Lecturer ID.
+func lecturer(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+	lecturerID, ok := pathID(w, r, "lecturerid", regexLecturerID, "Lecturer ID")
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		lecturer, err := database.GetLecturer(r.Context(), db, lecturerID)
+		if err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		writeResponse(w, r, http.StatusOK, &lecturer)
+
+	case http.MethodPost, http.MethodPut:
+		var newLecturer database.Lecturer
+		if !decodeRequest(w, r, &newLecturer) {
+			return
+		}
+		if newLecturer.LecturerID != "" && newLecturer.LecturerID != lecturerID {
+			writeJSONError(w, r, http.StatusUnprocessableEntity, "422 - LecturerID in the body does not match the URL")
+			log.Warning("Fail attempt to save lecturer: 422 - LecturerID in the body does not match the URL")
+			return
+		}
+		newLecturer.LecturerID = lecturerID
+		if err := validateLecturer(&newLecturer); err != nil {
+			writeJSONError(w, r, http.StatusUnprocessableEntity, "422 - "+err.Error())
+			log.Warning("Fail attempt to save lecturer: 422 - ", err)
+			return
+		}
+
+		status := http.StatusCreated
+		var err error
+		if r.Method == http.MethodPost {
+			err = database.InsertLecturer(r.Context(), db, newLecturer)
+		} else {
+			status = http.StatusOK
+			err = database.UpdateLecturer(r.Context(), db, newLecturer)
+		}
+		if errors.Is(err, database.ErrConflict) {
+			writeJSONError(w, r, http.StatusConflict, "409 - Duplicate lecturer ID or name")
+			log.Warning("Fail attempt to save lecturer: 409 - Duplicate lecturer ID or name")
+		} else if err != nil {
+			writeDBError(w, r, err)
+		} else {
+			writeResponse(w, r, status, &newLecturer)
+		}
+
+	case http.MethodDelete:
+		if err := database.DeleteLecturer(r.Context(), db, lecturerID); err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+//lecturerCourses lists the courses taught by the lecturer named in the URL.
+func lecturerCourses(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+	lecturerID, ok := pathID(w, r, "lecturerid", regexLecturerID, "Lecturer ID")
+	if !ok {
+		return
+	}
+
+	courses, err := database.GetLecturerCourses(r.Context(), db, lecturerID)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	writeResponse(w, r, http.StatusOK, &courses)
+}
@@ -1,15 +1,19 @@
 package main
 
 import (
+	"context"
 	"database/sql"
-	"encoding/json"
+	"errors"
+	"expvar"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"os"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/gorilla/mux"
@@ -23,8 +27,11 @@ import (
 var (
 	db                                             *sql.DB
 	dbPort, dbHost, dbUsername, dbPassword, dbName string
+	dbMaxOpenConns, dbMaxIdleConns                 int
+	dbConnMaxLifetime, dbConnMaxIdleTime           time.Duration
 	APIKey                                         string
 	Port                                           string
+	maxBodyBytes, maxBatchBodyBytes                int64
 	//Unique policy creation for the life of the program.
 	Policy = bluemonday.UGCPolicy()
 )
@@ -32,9 +39,67 @@ var (
 //regular expression pattern for user input.
 var (
 	regexCourseID      = regexp.MustCompile(`^[A-Z]{3}[0-9]{4}$`)
-	regexTitleLecturer = regexp.MustCompile(`^[\w\d\s]{3,30}$`) //same regex format can be used for course title and lecturer
+	regexTitleLecturer = regexp.MustCompile(`^[\w\d\s]{3,30}$`) //same regex format is used for lecturer and other names
+	regexCourseTitle   = regexp.MustCompile(`^[\w\d\s.,:()+/-]{3,100}$`)
+	regexLecturerID    = regexp.MustCompile(`^L[0-9]{4}$`)
+	regexDepartmentID  = regexp.MustCompile(`^D[0-9]{3}$`)
+	regexPrefix        = regexp.MustCompile(`^[A-Z]{3}$`) //the letters a Course ID starts with
+	regexSemesterID    = regexp.MustCompile(`^[0-9]{4}S[1-3]$`)
 )
 
+//Limits on the descriptive fields of a course.
+const (
+	maxDescriptionLength = 2000 //characters, after sanitizing
+	maxCredits           = 30
+)
+
+//validateCourse sanitizes a course in place and checks it against the same rules as the course handler.
+func validateCourse(c *database.Course) error {
+	c.CourseID = Policy.Sanitize(strings.TrimSpace(c.CourseID))
+	if !regexCourseID.MatchString(c.CourseID) {
+		return errors.New("incorrect format for Course ID")
+	}
+	if c.Title == "" || (c.Lecturer == "" && c.LecturerID == "") || c.ClassSize <= 0 {
+		return errors.New("information supplied not complete")
+	}
+	c.Title = Policy.Sanitize(strings.TrimSpace(c.Title))
+	if !regexCourseTitle.MatchString(c.Title) {
+		return errors.New("incorrect format for Course Title")
+	}
+	c.Description = Policy.Sanitize(strings.TrimSpace(c.Description))
+	if err := checkDetails(c.Description, c.Credits, c.Status); err != nil {
+		return err
+	}
+	// the lecturer is given by ID or by the name of an existing lecturer, the ID wins if both are given
+	if c.LecturerID != "" {
+		c.LecturerID = Policy.Sanitize(strings.TrimSpace(c.LecturerID))
+		if !regexLecturerID.MatchString(c.LecturerID) {
+			return errors.New("incorrect format for Lecturer ID")
+		}
+		return nil
+	}
+	c.Lecturer = Policy.Sanitize(strings.TrimSpace(c.Lecturer))
+	if !regexTitleLecturer.MatchString(c.Lecturer) {
+		return errors.New("incorrect format for Course Lecturer")
+	}
+	return nil
+}
+
+//checkDetails checks the sanitized description, the credits and the status of a course. An empty status is
+//allowed, the course then keeps its current one or starts as a draft.
+func checkDetails(description string, credits int, status string) error {
+	if utf8.RuneCountInString(description) > maxDescriptionLength {
+		return fmt.Errorf("Description must be at most %d characters", maxDescriptionLength)
+	}
+	if credits < 0 || credits > maxCredits {
+		return fmt.Errorf("Credits must be between 0 and %d", maxCredits)
+	}
+	if status != "" && !database.ValidStatus(status) {
+		return errors.New("Status must be draft, published or archived")
+	}
+	return nil
+}
+
 //validKey function verify the incoming API key in the request is valid.
 func validKey(w http.ResponseWriter, r *http.Request) bool {
 	v := r.URL.Query()
@@ -72,187 +137,240 @@ func allcourses(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	allCourses := database.GetAllRecords(db)
+	// tag filters and facets need the tags of every course, so they are answered from a separate query
+	tagFilters, ok := requestTags(w, r)
+	if !ok {
+		return
+	}
+	if facets := r.URL.Query().Get("facets") == "true"; len(tagFilters) > 0 || facets {
+		filterCourses(w, r, tagFilters, facets)
+		return
+	}
+
+	// CSV is streamed straight from the database, every other format goes through the codec registry
+	if mediaType, _ := negotiate(r, listMediaTypes()); mediaType == "text/csv" {
+		writeCoursesCSV(w, r)
+		return
+	}
+
+	allCourses, err := database.GetAllRecords(r.Context(), db)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+
+	// returns all the courses in the negotiated format
+	writeResponse(w, r, http.StatusOK, &allCourses)
+
+}
+
+//metrics exposes the expvar counters, including the database query failure counts.
+func metrics(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+
+	expvar.Handler().ServeHTTP(w, r)
+}
 
-	// returns all the courses in JSON
-	json.NewEncoder(w).Encode(&allCourses)
+//validatePatch sanitizes and checks the fields present in a partial update, using the same rules as validateCourse.
+func validatePatch(p *database.CoursePatch) error {
+	if p.Title == nil && p.LecturerID == nil && p.Lecturer == nil && p.ClassSize == nil &&
+		p.Description == nil && p.Credits == nil && p.Status == nil {
+		return errors.New("no fields to update")
+	}
+	if p.Title != nil {
+		*p.Title = Policy.Sanitize(strings.TrimSpace(*p.Title))
+		if !regexCourseTitle.MatchString(*p.Title) {
+			return errors.New("incorrect format for Course Title")
+		}
+	}
+	if p.LecturerID != nil {
+		*p.LecturerID = Policy.Sanitize(strings.TrimSpace(*p.LecturerID))
+		if !regexLecturerID.MatchString(*p.LecturerID) {
+			return errors.New("incorrect format for Lecturer ID")
+		}
+	} else if p.Lecturer != nil {
+		*p.Lecturer = Policy.Sanitize(strings.TrimSpace(*p.Lecturer))
+		if !regexTitleLecturer.MatchString(*p.Lecturer) {
+			return errors.New("incorrect format for Course Lecturer")
+		}
+	}
+	if p.ClassSize != nil && *p.ClassSize <= 0 {
+		return errors.New("ClassSize must be greater than zero")
+	}
+	var description, status string
+	var credits int
+	if p.Description != nil {
+		*p.Description = Policy.Sanitize(strings.TrimSpace(*p.Description))
+		description = *p.Description
+	}
+	if p.Credits != nil {
+		credits = *p.Credits
+	}
+	if p.Status != nil {
+		if *p.Status == "" {
+			return errors.New("Status must be draft, published or archived")
+		}
+		status = *p.Status
+	}
+	return checkDetails(description, credits, status)
+}
+
+//decodeCourse reads the course in the body of a POST or PUT request and validates it against the course ID in the URL.
+//It writes the error response itself and returns false if the course cannot be used.
+func decodeCourse(w http.ResponseWriter, r *http.Request, courseID string, caller string) (database.Course, bool) {
+	var newCourse database.Course
+	if !decodeRequest(w, r, &newCourse) {
+		return newCourse, false
+	}
 
+	courseID = Policy.Sanitize(courseID)
+	if newCourse.CourseID != "" && newCourse.CourseID != courseID {
+		writeJSONError(w, r, http.StatusUnprocessableEntity, "422 - CourseID in the body does not match the URL")
+		log.Warning("Fail attempt to decode course: 422 - CourseID in the body does not match the URL ", caller)
+		return newCourse, false
+	}
+	newCourse.CourseID = courseID
+
+	// input validation and sanitization before sent to insert into a sql query
+	if err := validateCourse(&newCourse); err != nil {
+		writeJSONError(w, r, http.StatusUnprocessableEntity, "422 - "+err.Error())
+		log.Warning("Fail attempt to decode course: 422 - ", err, " ", caller)
+		return newCourse, false
+	}
+	return newCourse, true
 }
 
 //course function will perform the necessary CRUD operation based on the HTTP method in the request.
 func course(w http.ResponseWriter, r *http.Request) {
 	params := mux.Vars(r)
 
-	defer func() { //to handle any possible panic
-		if err := recover(); err != nil {
-			log.Panic("Panic occured at console menu and recovered.", err)
-		}
-	}()
-
 	if !validKey(w, r) {
 		return
 	}
 
 	if r.Method == "GET" {
 
-		params["courseid"] = Policy.Sanitize(params["courseid"]) // input validation and sanitization
-		if !regexCourseID.MatchString(params["courseid"]) {
-			log.Error("Incorrect format for Course ID detected. --getRecord")
+		courseID, ok := pathID(w, r, "courseid", regexCourseID, "Course ID")
+		if !ok {
 			return
 		}
 
-		course, err := database.GetRecord(db, params["courseid"])
+		course, err := database.GetRecord(r.Context(), db, courseID)
 		//fmt.Println(course)
 		if err == nil {
-			json.NewEncoder(w).Encode(&course)
-		} else if err == sql.ErrNoRows {
+			writeResponse(w, r, http.StatusOK, &course)
+		} else if errors.Is(err, database.ErrNotFound) {
 			w.WriteHeader(http.StatusNotFound)
 			w.Write([]byte("404 - No course found"))
 			log.Warning("Fail attempt to get record: 404 - No course found")
 		} else {
-			log.Panic(err.Error())
+			writeDBError(w, r, err)
 		}
 	}
 
 	if r.Method == "DELETE" {
 
-		params["courseid"] = Policy.Sanitize(params["courseid"]) // input validation and sanitization
-		if !regexCourseID.MatchString(params["courseid"]) {
-			log.Error("Incorrect format for Course ID detected. --deleteRecord")
+		courseID, ok := pathID(w, r, "courseid", regexCourseID, "Course ID")
+		if !ok {
 			return
 		}
 
-		exist, err := database.CourseExist(db, params["courseid"])
-		if err != nil {
-			log.Panic(err.Error())
-		} else if exist != 0 {
-			err := database.DeleteRecord(db, params["courseid"])
-			if err != nil {
-				w.WriteHeader(http.StatusUnprocessableEntity)
-				w.Write([]byte("422 - Error in deleteing course!"))
-				log.Error("Fail attempt to delete record: 422 - Error in deleteing course!")
-			} else {
-				w.WriteHeader(http.StatusAccepted)
-				w.Write([]byte("202 - Course deleted: " + params["courseid"]))
-			}
-		} else {
+		// force=true also removes the course from the prerequisites of the courses that require it
+		force := r.URL.Query().Get("force") == "true"
+		err := database.DeleteRecord(r.Context(), db, courseID, force)
+		var rule *database.RuleError
+		if errors.Is(err, database.ErrNotFound) {
 			w.WriteHeader(http.StatusNotFound)
 			w.Write([]byte("404 - No course found"))
 			log.Warning("Fail attempt to delete record: 404 - No course found")
+		} else if errors.As(err, &rule) {
+			writeDBError(w, r, err)
+		} else if errors.Is(err, database.ErrConflict) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte("422 - Error in deleteing course!"))
+			log.Error("Fail attempt to delete record: 422 - Error in deleteing course!")
+		} else if err != nil {
+			writeDBError(w, r, err)
+		} else {
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte("202 - Course deleted: " + courseID))
 		}
 	}
 
-	if r.Header.Get("Content-type") == "application/json" { //only incoming POST and PUT is expected to be in JSON format
-		// POST is for creating new course
-		if r.Method == "POST" {
-			var newCourse database.Course
-			// read the string sent to the service
-			reqBody, err := ioutil.ReadAll(r.Body)
-			if err == nil {
-				// convert JSON to object
-				json.Unmarshal(reqBody, &newCourse)
-
-				// input validation and sanitization before sent to insert into a sql query
-				params["courseid"] = Policy.Sanitize(params["courseid"])
-				if !regexCourseID.MatchString(params["courseid"]) {
-					log.Error("Incorrect format for Course ID detected. --insertRecord")
-					return
-				}
-
-				if newCourse.Title == "" || newCourse.Lecturer == "" || newCourse.ClassSize <= 0 {
-					w.WriteHeader(http.StatusUnprocessableEntity)
-					w.Write([]byte("422 - Information supplied not complete. Please supply course information in JSON format."))
-					log.Warning("Fail attempt to insert record: 422 - Please supply course information in JSON format")
-					return
-				}
-				// check if course exists; add only if course does not exist
-				exist, err := database.CourseExist(db, params["courseid"])
-				if err != nil {
-					log.Panic(err.Error())
-				} else if exist == 0 {
-					// input validation and sanitization before sent to insert into a sql query
-					newCourse.Title = Policy.Sanitize(strings.TrimSpace(newCourse.Title))
-					if !regexTitleLecturer.MatchString(newCourse.Title) {
-						log.Error("Incorrect format for Course Title detected. --insertRecord")
-						return
-					}
-					// input validation and sanitization before sent to insert into a sql query
-					newCourse.Lecturer = Policy.Sanitize(strings.TrimSpace(newCourse.Lecturer))
-					if !regexTitleLecturer.MatchString(newCourse.Lecturer) {
-						log.Error("Incorrect format for Course Lecturer detected. --insertRecord")
-						return
-					}
-
-					database.InsertRecord(db, params["courseid"], newCourse.Title, newCourse.Lecturer, newCourse.ClassSize)
-					w.WriteHeader(http.StatusCreated)
-					w.Write([]byte("201 - Course added: " + params["courseid"]))
-				} else {
-					w.WriteHeader(http.StatusConflict)
-					w.Write([]byte("409 - Duplicate course ID"))
-					log.Warning("Fail attempt to insert record: 409 - Duplicate course ID")
-				}
-			} else {
-				w.WriteHeader(http.StatusUnprocessableEntity)
-				w.Write([]byte("422 - Please supply course information in JSON format"))
-				log.Warning("Fail attempt to insert record: 422 - Please supply course information in JSON format")
-			}
+	// POST is for creating new course
+	if r.Method == "POST" {
+		newCourse, ok := decodeCourse(w, r, params["courseid"], "--insertRecord")
+		if !ok {
+			return
 		}
 
-		// PUT is for creating or updating existing course
-		if r.Method == "PUT" {
-			var newCourse database.Course
-			reqBody, err := ioutil.ReadAll(r.Body)
-			if err == nil {
-				json.Unmarshal(reqBody, &newCourse)
-
-				// input validation and sanitization before sent to insert into a sql query
-				params["courseid"] = Policy.Sanitize(params["courseid"])
-				if !regexCourseID.MatchString(params["courseid"]) {
-					log.Error("Incorrect format for Course ID detected. --insertRecord")
-					return
-				}
-
-				if newCourse.Title == "" || newCourse.Lecturer == "" || newCourse.ClassSize <= 0 {
-					w.WriteHeader(http.StatusUnprocessableEntity)
-					w.Write([]byte("422 - Please supply course information in JSON format"))
-					log.Warning("Fail attempt to insert record: 422 - Please supply course information in JSON format")
-					return
-				}
-
-				// check if course exists; add only if course does not exist
-				exist, err := database.CourseExist(db, params["courseid"])
-				if err != nil {
-					log.Panic(err.Error())
-				} else if exist != 0 {
-					// input validation and sanitization before sent to insert into a sql query
-					newCourse.Title = Policy.Sanitize(strings.TrimSpace(newCourse.Title))
-					if !regexTitleLecturer.MatchString(newCourse.Title) {
-						log.Error("Incorrect format for Course Title detected. --editRecord")
-						return
-					}
-					// input validation and sanitization before sent to insert into a sql query
-					newCourse.Lecturer = Policy.Sanitize(strings.TrimSpace(newCourse.Lecturer))
-					if !regexTitleLecturer.MatchString(newCourse.Lecturer) {
-						log.Error("Incorrect format for Course Lecturer detected. --editRecord")
-						return
-					}
-
-					database.EditRecord(db, params["courseid"], newCourse.Title, newCourse.Lecturer, newCourse.ClassSize)
-					w.WriteHeader(http.StatusAccepted)
-					w.Write([]byte("202 - Course updated: " + params["courseid"]))
-				} else if exist == 0 {
-					database.InsertRecord(db, params["courseid"], newCourse.Title, newCourse.Lecturer, newCourse.ClassSize)
-					w.WriteHeader(http.StatusCreated)
-					w.Write([]byte("201 - Course added: " + params["courseid"]))
-				}
-			} else {
-				w.WriteHeader(http.StatusUnprocessableEntity)
-				w.Write([]byte("422 - Please supply course information in JSON format"))
-				log.Warning("Fail attempt to insert record: 422 - Please supply course information in JSON format")
-			}
+		// the insert itself detects an existing course, so concurrent requests cannot both succeed
+		err := database.InsertRecord(r.Context(), db, newCourse)
+		if errors.Is(err, database.ErrConflict) {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte("409 - Duplicate course ID"))
+			log.Warning("Fail attempt to insert record: 409 - Duplicate course ID")
+		} else if err != nil {
+			writeDBError(w, r, err)
+		} else {
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("201 - Course added: " + newCourse.CourseID))
+		}
+	}
+
+	// PUT is for creating or updating existing course
+	if r.Method == "PUT" {
+		newCourse, ok := decodeCourse(w, r, params["courseid"], "--editRecord")
+		if !ok {
+			return
+		}
+
+		// create or update in one transaction; created tells which of the two happened
+		created, err := database.UpsertRecord(r.Context(), db, newCourse)
+		if err != nil {
+			writeDBError(w, r, err)
+		} else if created {
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("201 - Course added: " + newCourse.CourseID))
+		} else {
+			w.WriteHeader(http.StatusAccepted)
+			w.Write([]byte("202 - Course updated: " + newCourse.CourseID))
 		}
 	}
 
+	// PATCH is for changing some fields of an existing course
+	if r.Method == "PATCH" {
+		params["courseid"] = Policy.Sanitize(params["courseid"]) // input validation and sanitization
+		if !regexCourseID.MatchString(params["courseid"]) {
+			writeJSONError(w, r, http.StatusBadRequest, "400 - incorrect format for Course ID")
+			log.Error("Incorrect format for Course ID detected. --patchRecord")
+			return
+		}
+
+		var patch database.CoursePatch
+		if !decodeRequest(w, r, &patch) {
+			return
+		}
+		if err := validatePatch(&patch); err != nil {
+			writeJSONError(w, r, http.StatusUnprocessableEntity, "422 - "+err.Error())
+			log.Warning("Fail attempt to patch record: 422 - ", err)
+			return
+		}
+
+		course, err := database.PatchRecord(r.Context(), db, params["courseid"], patch)
+		if errors.Is(err, database.ErrNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("404 - No course found"))
+			log.Warning("Fail attempt to patch record: 404 - No course found")
+		} else if err != nil {
+			writeDBError(w, r, err)
+		} else {
+			writeResponse(w, r, http.StatusOK, &course)
+		}
+	}
 }
 
 func init() {
@@ -281,13 +399,79 @@ func init() {
 	dbPort = goDotEnvVariable("dbPort")
 	dbName = goDotEnvVariable("dbName")
 	Port = goDotEnvVariable("port")
+
+	database.QueryTimeout = envDuration("dbQueryTimeout", database.QueryTimeout)
+
+	//connection pool sizing, zero leaves the database/sql default in place
+	dbMaxOpenConns = envInt("dbMaxOpenConns", 0)
+	dbMaxIdleConns = envInt("dbMaxIdleConns", 0)
+	dbConnMaxLifetime = envDuration("dbConnMaxLifetime", 0)
+	dbConnMaxIdleTime = envDuration("dbConnMaxIdleTime", 0)
+
+	//request body limits for single courses and for batch or CSV imports
+	maxBodyBytes = int64(envInt("maxBodyBytes", 1<<20))
+	maxBatchBodyBytes = int64(envInt("maxBatchBodyBytes", 16<<20))
+}
+
+//newRouter registers every route of the API. Each route must also be described in openapi.json.
+func newRouter() *mux.Router {
+	router := mux.NewRouter()
+	router.Use(withRequestID, withRecovery)
+	router.HandleFunc("/api/v1/", home).Methods("GET").Schemes("https")
+	router.HandleFunc("/api/v1/openapi.json", openAPISpec).Methods("GET").Schemes("https")
+	router.HandleFunc("/api/v1/courses", allcourses).Methods("GET").Schemes("https")
+	router.HandleFunc("/api/v1/courses:batch", batchCourses).Methods("POST").Schemes("https")
+	router.HandleFunc("/api/v1/courses:export", exportCourses).Methods("GET").Schemes("https")
+	router.HandleFunc("/api/v1/courses:import", importCoursesCSV).Methods("POST").Schemes("https")
+	router.HandleFunc("/api/v1/metrics", metrics).Methods("GET").Schemes("https")
+	router.HandleFunc("/api/v1/courses/{courseid}", course).Methods("GET", "PUT", "POST", "PATCH", "DELETE").Schemes("https")
+	router.HandleFunc("/api/v1/courses/{courseid}/enrollments", enrollments).Methods("GET", "POST").Schemes("https")
+	router.HandleFunc("/api/v1/courses/{courseid}/enrollments/{studentid}", enrollment).Methods("GET", "DELETE").Schemes("https")
+	router.HandleFunc("/api/v1/courses/{courseid}/prerequisites", prerequisites).Methods("GET").Schemes("https")
+	router.HandleFunc("/api/v1/courses/{courseid}/prerequisites/{prereqid}", prerequisite).Methods("PUT", "DELETE").Schemes("https")
+	router.HandleFunc("/api/v1/courses/{courseid}/offerings", courseOfferings).Methods("GET").Schemes("https")
+	router.HandleFunc("/api/v1/courses/{courseid}/tags", courseTags).Methods("GET").Schemes("https")
+	router.HandleFunc("/api/v1/courses/{courseid}/tags/{tag}", courseTag).Methods("PUT", "DELETE").Schemes("https")
+	router.HandleFunc("/api/v1/tags", tags).Methods("GET").Schemes("https")
+	router.HandleFunc("/api/v1/courses/{courseid}/sessions", courseSessions).Methods("GET", "POST").Schemes("https")
+	router.HandleFunc("/api/v1/courses/{courseid}/sessions/{sessionid}", session).Methods("GET", "PUT", "DELETE").Schemes("https")
+	router.HandleFunc("/api/v1/courses/{courseid}/sessions:import", importSessionsICS).Methods("POST").Schemes("https")
+	router.HandleFunc("/api/v1/courses/{courseid}/calendar.ics", courseCalendar).Methods("GET").Schemes("https")
+	router.HandleFunc("/api/v1/semesters", semesters).Methods("GET").Schemes("https")
+	router.HandleFunc("/api/v1/semesters/{semesterid}", semester).Methods("GET", "POST", "PUT", "DELETE").Schemes("https")
+	router.HandleFunc("/api/v1/semesters/{semesterid}/offerings", semesterOfferings).Methods("GET").Schemes("https")
+	router.HandleFunc("/api/v1/semesters/{semesterid}/offerings:rollforward", rollForward).Methods("POST").Schemes("https")
+	router.HandleFunc("/api/v1/semesters/{semesterid}/offerings/{courseid}", offering).Methods("GET", "PUT", "DELETE").Schemes("https")
+	router.HandleFunc("/api/v1/lecturers", lecturers).Methods("GET").Schemes("https")
+	router.HandleFunc("/api/v1/lecturers/{lecturerid}", lecturer).Methods("GET", "POST", "PUT", "DELETE").Schemes("https")
+	router.HandleFunc("/api/v1/lecturers/{lecturerid}/courses", lecturerCourses).Methods("GET").Schemes("https")
+	router.HandleFunc("/api/v1/lecturers/{lecturerid}/timetable", lecturerTimetable).Methods("GET").Schemes("https")
+	router.HandleFunc("/api/v1/lecturers/{lecturerid}/calendar.ics", lecturerCalendar).Methods("GET").Schemes("https")
+	router.HandleFunc("/api/v1/rooms", rooms).Methods("GET").Schemes("https")
+	router.HandleFunc("/api/v1/rooms/{roomid}", room).Methods("GET", "POST", "PUT", "DELETE").Schemes("https")
+	router.HandleFunc("/api/v1/rooms/{roomid}/timetable", roomTimetable).Methods("GET").Schemes("https")
+	router.HandleFunc("/api/v1/departments", departments).Methods("GET").Schemes("https")
+	router.HandleFunc("/api/v1/departments/{departmentid}", department).Methods("GET", "POST", "PUT", "DELETE").Schemes("https")
+	router.HandleFunc("/api/v1/departments/{departmentid}/courses", departmentCourses).Methods("GET").Schemes("https")
+	router.HandleFunc("/api/v1/departments/{departmentid}/lecturers", departmentLecturers).Methods("GET").Schemes("https")
+	router.HandleFunc("/api/v1/departments/{departmentid}/stats", departmentStats).Methods("GET").Schemes("https")
+	router.HandleFunc("/api/v1/students", students).Methods("GET").Schemes("https")
+	router.HandleFunc("/api/v1/students/{studentid}", student).Methods("GET", "POST", "DELETE").Schemes("https")
+	return router
 }
 
 func main() {
 
+	// an empty key would let any request that sends key= through
+	if APIKey == "" {
+		log.Fatal("APIKEY is not set, add it to the .env file")
+	}
+
 	// Use mysql as driverName and a valid DSN as dataSourceName:
 	var err error
-	dataSourceName := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s", dbUsername, dbPassword, dbHost, dbPort, dbName)
+	// clientFoundRows makes UPDATE report matched rather than changed rows, which the database package relies on.
+	// parseTime scans DATETIME columns such as Enrollment.EnrolledAt into time.Time.
+	dataSourceName := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?clientFoundRows=true&parseTime=true", dbUsername, dbPassword, dbHost, dbPort, dbName)
 	db, err = sql.Open("mysql", dataSourceName)
 
 	// handle error
@@ -296,12 +480,27 @@ func main() {
 	}
 	defer db.Close()
 
+	if dbMaxOpenConns > 0 {
+		db.SetMaxOpenConns(dbMaxOpenConns)
+	}
+	if dbMaxIdleConns > 0 {
+		db.SetMaxIdleConns(dbMaxIdleConns)
+	}
+	if dbConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(dbConnMaxLifetime)
+	}
+	if dbConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(dbConnMaxIdleTime)
+	}
+
+	if err = database.Prepare(context.Background(), db); err != nil {
+		log.Fatal("Error preparing database statements: ", err)
+	}
+	defer database.Close()
+
 	//database.GetAllRecords(db)
 
-	router := mux.NewRouter()
-	router.HandleFunc("/api/v1/", home).Schemes("https")
-	router.HandleFunc("/api/v1/courses", allcourses).Schemes("https")
-	router.HandleFunc("/api/v1/courses/{courseid}", course).Methods("GET", "PUT", "POST", "DELETE").Schemes("https")
+	router := newRouter()
 
 	fmt.Println("Listening at port 5000")
 	//log.Fatal(http.ListenAndServe(":5000", router))
@@ -315,14 +514,41 @@ func main() {
 }
 
 // use godot package to load/read the .env file and return the value of the key
+// without a .env file the value comes from the environment alone, as it does under go test
 func goDotEnvVariable(key string) string {
 	// load .env file
 	err := godotenv.Load(".env")
 
-	if err != nil {
+	if err != nil && !os.IsNotExist(err) {
 		log.Fatalf("Error loading .env file")
 	}
 
 	return os.Getenv(key)
 
 }
+
+//envDuration reads a duration such as "5s" from the .env file, falling back to def when the key is empty.
+func envDuration(key string, def time.Duration) time.Duration {
+	value := goDotEnvVariable(key)
+	if value == "" {
+		return def
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid %s in .env file: %v", key, err)
+	}
+	return d
+}
+
+//envInt reads an integer from the .env file, falling back to def when the key is empty.
+func envInt(key string, def int) int {
+	value := goDotEnvVariable(key)
+	if value == "" {
+		return def
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("Invalid %s in .env file: %v", key, err)
+	}
+	return n
+}
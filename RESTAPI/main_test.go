@@ -0,0 +1,524 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	database "goMicroService1Assignment/RESTAPI/database"
+)
+
+//testKey is the API key the handlers expect for the duration of the tests.
+const testKey = "testkey"
+
+//unreachableDSN points at a port nothing listens on, so every query fails as if the database were down.
+const unreachableDSN = "test:test@tcp(127.0.0.1:1)/test?clientFoundRows=true&parseTime=true"
+
+//TestMain keeps the tests out of the log file and gives the handlers a key and a database. The database is
+//the one in TEST_DSN, a DSN in the same form main builds; without it the database is unreachable.
+func TestMain(m *testing.M) {
+	log.SetOutput(ioutil.Discard)
+	APIKey = testKey
+
+	dsn := os.Getenv("TEST_DSN")
+	if dsn == "" {
+		dsn = unreachableDSN
+	}
+	var err error
+	db, err = sql.Open("mysql", dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if dsn != unreachableDSN {
+		if err = database.Prepare(context.Background(), db); err != nil {
+			log.Fatal("Error preparing database statements: ", err)
+		}
+	}
+
+	code := m.Run()
+	database.Close()
+	db.Close()
+	os.Exit(code)
+}
+
+//needDB skips a test that needs rows from a real database when TEST_DSN is not set.
+func needDB(t *testing.T) {
+	t.Helper()
+	if os.Getenv("TEST_DSN") == "" {
+		t.Skip("TEST_DSN is not set")
+	}
+}
+
+//serve sends a request through the router the way the TLS listener hands it over.
+func serve(method, target, contentType, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "https://localhost"+target, strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	newRouter().ServeHTTP(w, r)
+	return w
+}
+
+//specSchemas pairs the schemas in openapi.json with the Go types the handlers actually encode.
+var specSchemas = map[string]reflect.Type{
+	"Course":                reflect.TypeOf(database.Course{}),
+	"CoursePatch":           reflect.TypeOf(database.CoursePatch{}),
+	"Error":                 reflect.TypeOf(errorResponse{}),
+	"BatchResult":           reflect.TypeOf(batchResult{}),
+	"BatchResponse":         reflect.TypeOf(batchResponse{}),
+	"Student":               reflect.TypeOf(database.Student{}),
+	"Enrollment":            reflect.TypeOf(database.Enrollment{}),
+	"EnrollmentRequest":     reflect.TypeOf(enrollmentRequest{}),
+	"CourseEnrollments":     reflect.TypeOf(courseEnrollments{}),
+	"Lecturer":              reflect.TypeOf(database.Lecturer{}),
+	"Department":            reflect.TypeOf(database.Department{}),
+	"DepartmentStats":       reflect.TypeOf(database.DepartmentStats{}),
+	"Semester":              reflect.TypeOf(database.Semester{}),
+	"CourseOffering":        reflect.TypeOf(database.CourseOffering{}),
+	"RollForward":           reflect.TypeOf(database.RollForward{}),
+	"Room":                  reflect.TypeOf(database.Room{}),
+	"Session":               reflect.TypeOf(database.Session{}),
+	"SessionImportResult":   reflect.TypeOf(sessionImportResult{}),
+	"SessionImportResponse": reflect.TypeOf(sessionImportResponse{}),
+	"CourseTags":            reflect.TypeOf(database.CourseTags{}),
+	"TagCount":              reflect.TypeOf(database.TagCount{}),
+	"CourseSearch":          reflect.TypeOf(courseSearch{}),
+}
+
+//openAPI is the subset of an OpenAPI document that the route and schema tests compare against the code.
+type openAPI struct {
+	Paths      map[string]map[string]json.RawMessage
+	Components struct {
+		Schemas map[string]struct {
+			Properties map[string]json.RawMessage
+		}
+	}
+}
+
+//TestSpecRoutes checks that openapi.json and the router describe the same routes and methods.
+func TestSpecRoutes(t *testing.T) {
+	var spec openAPI
+	if err := json.Unmarshal(openAPIDocument, &spec); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+
+	inSpec := map[string]bool{}
+	for path, item := range spec.Paths {
+		for method := range item {
+			if method != "parameters" {
+				inSpec[strings.ToUpper(method)+" "+path] = true
+			}
+		}
+	}
+	inRouter := map[string]bool{}
+	err := newRouter().Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil //not a path route
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			t.Errorf("route %s does not restrict its methods", path)
+			return nil
+		}
+		for _, method := range methods {
+			inRouter[method+" "+path] = true
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, op := range sortedKeys(inRouter) {
+		if !inSpec[op] {
+			t.Errorf("%s is routed but missing from openapi.json", op)
+		}
+	}
+	for _, op := range sortedKeys(inSpec) {
+		if !inRouter[op] {
+			t.Errorf("%s is in openapi.json but not routed", op)
+		}
+	}
+}
+
+//TestSpecSchemas checks that the schemas list the same fields as the Go types behind them.
+func TestSpecSchemas(t *testing.T) {
+	var spec openAPI
+	if err := json.Unmarshal(openAPIDocument, &spec); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+
+	for name, typ := range specSchemas {
+		schema, ok := spec.Components.Schemas[name]
+		if !ok {
+			t.Errorf("schema %s is missing from openapi.json", name)
+			continue
+		}
+		fields := map[string]bool{}
+		for _, field := range jsonFieldNames(typ) {
+			fields[field] = true
+			if _, ok := schema.Properties[field]; !ok {
+				t.Errorf("schema %s is missing property %s", name, field)
+			}
+		}
+		for property := range schema.Properties {
+			if !fields[property] {
+				t.Errorf("schema %s has property %s that %s does not encode", name, property, typ.Name())
+			}
+		}
+	}
+}
+
+//jsonFieldNames lists the JSON keys encoding/json produces for the exported fields of struct type t.
+func jsonFieldNames(t reflect.Type) []string {
+	names := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" { //unexported
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+//responseCase is one request sent through the router together with the operation in openapi.json that
+//documents it and the status it must answer with.
+type responseCase struct {
+	name        string
+	method      string
+	target      string
+	contentType string
+	body        string
+	path        string //path template in openapi.json
+	status      int
+}
+
+//TestResponses checks the handlers that answer before reaching the database.
+func TestResponses(t *testing.T) {
+	tooManyTags := strings.Repeat("&tag=go", maxTagFilters+1)
+	checkResponses(t, []responseCase{
+		{"spec", "GET", "/api/v1/openapi.json", "", "", "/api/v1/openapi.json", http.StatusOK},
+		{"no key", "GET", "/api/v1/courses", "", "", "/api/v1/courses", http.StatusNotFound},
+		{"wrong key", "GET", "/api/v1/courses/GOS1000?key=wrong", "", "", "/api/v1/courses/{courseid}", http.StatusNotFound},
+		{"bad tag", "GET", "/api/v1/courses?key=" + testKey + "&tag=no%20spaces", "", "", "/api/v1/courses", http.StatusBadRequest},
+		{"too many tags", "GET", "/api/v1/courses?key=" + testKey + tooManyTags, "", "", "/api/v1/courses", http.StatusBadRequest},
+		{"get bad id", "GET", "/api/v1/courses/GOS10?key=" + testKey, "", "", "/api/v1/courses/{courseid}", http.StatusBadRequest},
+		{"delete bad id", "DELETE", "/api/v1/courses/gos1000?key=" + testKey, "", "", "/api/v1/courses/{courseid}", http.StatusBadRequest},
+		{"patch bad id", "PATCH", "/api/v1/courses/GOS1000X?key=" + testKey, "application/json", `{"ClassSize":30}`, "/api/v1/courses/{courseid}", http.StatusBadRequest},
+		{"post unsupported type", "POST", "/api/v1/courses/GOS9999?key=" + testKey, "text/plain", "GOS9999", "/api/v1/courses/{courseid}", http.StatusUnsupportedMediaType},
+		{"post trailing data", "POST", "/api/v1/courses/GOS9999?key=" + testKey, "application/json", `{"Title":"Go Testing"}]`, "/api/v1/courses/{courseid}", http.StatusBadRequest},
+		{"post mismatched id", "POST", "/api/v1/courses/GOS9999?key=" + testKey, "application/json", `{"CourseID":"GOS9998","Title":"Go Testing","LecturerID":"L0001","ClassSize":30}`, "/api/v1/courses/{courseid}", http.StatusUnprocessableEntity},
+		{"post incomplete", "POST", "/api/v1/courses/GOS9999?key=" + testKey, "application/json", `{"Title":"Go Testing"}`, "/api/v1/courses/{courseid}", http.StatusUnprocessableEntity},
+		{"batch bad mode", "POST", "/api/v1/courses:batch?mode=all&key=" + testKey, "application/json", "[]", "/api/v1/courses:batch", http.StatusBadRequest},
+		{"batch unsupported type", "POST", "/api/v1/courses:batch?key=" + testKey, "text/plain", "[]", "/api/v1/courses:batch", http.StatusUnsupportedMediaType},
+		{"import unsupported type", "POST", "/api/v1/courses:import?key=" + testKey, "application/json", "[]", "/api/v1/courses:import", http.StatusUnsupportedMediaType},
+		{"import missing column", "POST", "/api/v1/courses:import?key=" + testKey, "text/csv", "CourseID,Title\nGOS9999,Go Testing\n", "/api/v1/courses:import", http.StatusBadRequest},
+		{"sessions unsupported type", "POST", "/api/v1/courses/GOS1000/sessions:import?key=" + testKey, "text/plain", "", "/api/v1/courses/{courseid}/sessions:import", http.StatusUnsupportedMediaType},
+	})
+}
+
+//TestResponsesUnavailable checks that handlers which reach an unreachable database answer 503 as documented.
+func TestResponsesUnavailable(t *testing.T) {
+	if os.Getenv("TEST_DSN") != "" {
+		t.Skip("TEST_DSN is set, the database is reachable")
+	}
+	checkResponses(t, []responseCase{
+		{"courses", "GET", "/api/v1/courses?key=" + testKey, "", "", "/api/v1/courses", http.StatusServiceUnavailable},
+		{"course", "GET", "/api/v1/courses/GOS1000?key=" + testKey, "", "", "/api/v1/courses/{courseid}", http.StatusServiceUnavailable},
+		{"delete course", "DELETE", "/api/v1/courses/GOS1000?key=" + testKey, "", "", "/api/v1/courses/{courseid}", http.StatusServiceUnavailable},
+		{"lecturers", "GET", "/api/v1/lecturers?key=" + testKey, "", "", "/api/v1/lecturers", http.StatusServiceUnavailable},
+	})
+}
+
+//TestResponsesFromDB checks the bodies of successful answers against their schemas. It reads the rows of
+//my-mysql/sql-scripts/InsertData.sql and changes nothing.
+func TestResponsesFromDB(t *testing.T) {
+	needDB(t)
+	checkResponses(t, []responseCase{
+		{"courses", "GET", "/api/v1/courses?key=" + testKey, "", "", "/api/v1/courses", http.StatusOK},
+		{"courses with facets", "GET", "/api/v1/courses?facets=true&key=" + testKey, "", "", "/api/v1/courses", http.StatusOK},
+		{"course", "GET", "/api/v1/courses/GOS1000?key=" + testKey, "", "", "/api/v1/courses/{courseid}", http.StatusOK},
+		{"prerequisites", "GET", "/api/v1/courses/GOS1002/prerequisites?key=" + testKey, "", "", "/api/v1/courses/{courseid}/prerequisites", http.StatusOK},
+		{"tags", "GET", "/api/v1/tags?key=" + testKey, "", "", "/api/v1/tags", http.StatusOK},
+		{"lecturers", "GET", "/api/v1/lecturers?key=" + testKey, "", "", "/api/v1/lecturers", http.StatusOK},
+		{"lecturer", "GET", "/api/v1/lecturers/L0001?key=" + testKey, "", "", "/api/v1/lecturers/{lecturerid}", http.StatusOK},
+		{"departments", "GET", "/api/v1/departments?key=" + testKey, "", "", "/api/v1/departments", http.StatusOK},
+		{"department stats", "GET", "/api/v1/departments/D001/stats?key=" + testKey, "", "", "/api/v1/departments/{departmentid}/stats", http.StatusOK},
+		{"semesters", "GET", "/api/v1/semesters?key=" + testKey, "", "", "/api/v1/semesters", http.StatusOK},
+		{"rooms", "GET", "/api/v1/rooms?key=" + testKey, "", "", "/api/v1/rooms", http.StatusOK},
+		{"students", "GET", "/api/v1/students?key=" + testKey, "", "", "/api/v1/students", http.StatusOK},
+	})
+}
+
+//checkResponses sends each case through the router, then checks that the status is the expected one and
+//that openapi.json documents it for the operation, with a media type and schema the body conforms to.
+func checkResponses(t *testing.T, cases []responseCase) {
+	t.Helper()
+	var spec map[string]interface{}
+	if err := json.Unmarshal(openAPIDocument, &spec); err != nil {
+		t.Fatalf("openapi.json is not valid JSON: %v", err)
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			w := serve(c.method, c.target, c.contentType, c.body)
+			if w.Code != c.status {
+				t.Fatalf("%s %s answered %d, want %d: %s", c.method, c.target, w.Code, c.status, w.Body.String())
+			}
+
+			operation := lookup(spec, "paths", c.path, strings.ToLower(c.method))
+			if operation == nil {
+				t.Fatalf("%s %s is not in openapi.json", c.method, c.path)
+			}
+			response := lookup(operation, "responses", strconv.Itoa(w.Code))
+			if response == nil {
+				t.Fatalf("%s %s answered %d, which openapi.json does not document", c.method, c.path, w.Code)
+			}
+			response = resolve(spec, response)
+
+			//net/http sniffs the type of a body written without a Content-Type, the recorder does not
+			contentType := w.Header().Get("Content-Type")
+			if contentType == "" && w.Body.Len() > 0 {
+				contentType = http.DetectContentType(w.Body.Bytes())
+			}
+			mediaType, _, err := mime.ParseMediaType(contentType)
+			if err != nil {
+				t.Fatalf("%s %s answered without a Content-Type", c.method, c.path)
+			}
+			media := lookup(response, "content", mediaType)
+			if media == nil {
+				t.Fatalf("%s %s answered %d as %s, which openapi.json does not document", c.method, c.path, w.Code, mediaType)
+			}
+			schema, _ := media["schema"].(map[string]interface{})
+
+			var body interface{} = w.Body.String()
+			if mediaType == "application/json" {
+				decoder := json.NewDecoder(w.Body)
+				decoder.UseNumber()
+				if err := decoder.Decode(&body); err != nil {
+					t.Fatalf("%s %s answered invalid JSON: %v", c.method, c.path, err)
+				}
+			}
+			for _, problem := range conform(spec, schema, body, "body") {
+				t.Error(problem)
+			}
+		})
+	}
+}
+
+//lookup follows keys through nested JSON objects, returning nil if any of them is missing.
+func lookup(object map[string]interface{}, keys ...string) map[string]interface{} {
+	for _, key := range keys {
+		next, ok := object[key].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		object = next
+	}
+	return object
+}
+
+//resolve replaces a {"$ref": "#/components/..."} object by the object it refers to.
+func resolve(spec, object map[string]interface{}) map[string]interface{} {
+	for object != nil {
+		ref, ok := object["$ref"].(string)
+		if !ok {
+			return object
+		}
+		object = lookup(spec, strings.Split(strings.TrimPrefix(ref, "#/"), "/")...)
+	}
+	return object
+}
+
+//conform lists the ways value breaks schema, covering the keywords openapi.json uses. Unlike JSON Schema,
+//a property the schema does not list is reported unless additionalProperties describes it, so a field added
+//to a response without documenting it is caught.
+func conform(spec, schema map[string]interface{}, value interface{}, where string) []string {
+	schema = resolve(spec, schema)
+	if schema == nil {
+		return []string{where + " refers to a schema missing from openapi.json"}
+	}
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok {
+		for _, alternative := range anyOf {
+			alternative, _ := alternative.(map[string]interface{})
+			if len(conform(spec, alternative, value, where)) == 0 {
+				return nil
+			}
+		}
+		return []string{where + " matches none of the anyOf schemas"}
+	}
+
+	problems := []string{}
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return []string{where + " is not an object"}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		required, _ := schema["required"].([]interface{})
+		for _, name := range required {
+			if _, ok := object[name.(string)]; !ok {
+				problems = append(problems, where+" is missing required property "+name.(string))
+			}
+		}
+		if min, ok := schema["minProperties"].(float64); ok && float64(len(object)) < min {
+			problems = append(problems, where+" has too few properties")
+		}
+		for name, property := range object {
+			if propertySchema, ok := properties[name].(map[string]interface{}); ok {
+				problems = append(problems, conform(spec, propertySchema, property, where+"."+name)...)
+			} else if additional, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+				problems = append(problems, conform(spec, additional, property, where+"."+name)...)
+			} else if properties != nil {
+				problems = append(problems, where+" has undocumented property "+name)
+			}
+		}
+	case "array":
+		array, ok := value.([]interface{})
+		if !ok {
+			return []string{where + " is not an array"}
+		}
+		items, _ := schema["items"].(map[string]interface{})
+		for i, item := range array {
+			problems = append(problems, conform(spec, items, item, where+"["+strconv.Itoa(i)+"]")...)
+		}
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return []string{where + " is not a string"}
+		}
+		if pattern, ok := schema["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(s) {
+			problems = append(problems, where+" does not match "+pattern+": "+strconv.Quote(s))
+		}
+		if max, ok := schema["maxLength"].(float64); ok && float64(utf8.RuneCountInString(s)) > max {
+			problems = append(problems, where+" is too long")
+		}
+		if enum, ok := schema["enum"].([]interface{}); ok && !inEnum(enum, s) {
+			problems = append(problems, where+" is not one of the enum values: "+strconv.Quote(s))
+		}
+	case "integer", "number":
+		n, ok := value.(json.Number)
+		if !ok {
+			return []string{where + " is not a number"}
+		}
+		if _, err := n.Int64(); err != nil && schema["type"] == "integer" {
+			return []string{where + " is not an integer"}
+		}
+		f, _ := n.Float64()
+		if min, ok := schema["minimum"].(float64); ok && f < min {
+			problems = append(problems, where+" is below the minimum")
+		}
+		if max, ok := schema["maximum"].(float64); ok && f > max {
+			problems = append(problems, where+" is above the maximum")
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			problems = append(problems, where+" is not a boolean")
+		}
+	}
+	return problems
+}
+
+func inEnum(enum []interface{}, s string) bool {
+	for _, value := range enum {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
+
+//TestConcurrentCreate races POST and PUT requests for one new course. Exactly one of them may create it:
+//the other POSTs must answer 409 and the other PUTs 202, and a single row must be stored.
+func TestConcurrentCreate(t *testing.T) {
+	needDB(t)
+	const courseID, requests, rounds = "GOS9999", 10, 5
+	body := `{"Title":"Go Concurrency","LecturerID":"L0003","ClassSize":30}`
+	removeCourse := func() {
+		if _, err := db.Exec("DELETE FROM Course WHERE CourseID=?", courseID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer removeCourse()
+
+	for round := 0; round < rounds; round++ {
+		removeCourse()
+
+		start := make(chan struct{})
+		statuses := make([]int, requests)
+		var wg sync.WaitGroup
+		for i := 0; i < requests; i++ {
+			method := "POST"
+			if i%2 == 1 {
+				method = "PUT"
+			}
+			wg.Add(1)
+			go func(i int, method string) {
+				defer wg.Done()
+				<-start
+				statuses[i] = serve(method, "/api/v1/courses/"+courseID+"?key="+testKey, "application/json", body).Code
+			}(i, method)
+		}
+		close(start)
+		wg.Wait()
+
+		created := 0
+		for i, status := range statuses {
+			switch {
+			case status == http.StatusCreated:
+				created++
+			case i%2 == 0 && status == http.StatusConflict, i%2 == 1 && status == http.StatusAccepted: //lost the race to create it
+			default:
+				t.Errorf("round %d: request %d answered %d", round, i, status)
+			}
+		}
+		if created != 1 {
+			t.Errorf("round %d: %d requests created the course, want 1", round, created)
+		}
+
+		var rows int
+		if err := db.QueryRow("SELECT COUNT(*) FROM Course WHERE CourseID=?", courseID).Scan(&rows); err != nil {
+			t.Fatal(err)
+		}
+		if rows != 1 {
+			t.Errorf("round %d: %d rows stored for %s, want 1", round, rows, courseID)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"runtime/debug"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type contextKey int
+
+const requestIDKey contextKey = iota
+
+//requestIDHeader carries the request ID in both directions so clients can quote it when reporting a problem.
+const requestIDHeader = "X-Request-ID"
+
+//requestID returns the ID assigned to the request by withRequestID.
+func requestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDKey).(string)
+	return id
+}
+
+//newRequestID generates a random 16 hex character ID.
+func newRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+//withRequestID tags every request with an ID, reusing the one supplied by the client if present.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if id == "" || len(id) > 64 {
+			id = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, id)))
+	})
+}
+
+//withRecovery turns a panic in any handler into a 500 response so a single bad request cannot take the server down.
+func withRecovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			if err == http.ErrAbortHandler { //deliberate abort, let net/http deal with it
+				panic(err)
+			}
+			log.WithFields(log.Fields{
+				"requestID": requestID(r),
+				"method":    r.Method,
+				"path":      r.URL.Path,
+			}).Errorf("Panic recovered: %v\n%s", err, debug.Stack())
+			writeJSONError(w, r, http.StatusInternalServerError, "500 - Internal server error")
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
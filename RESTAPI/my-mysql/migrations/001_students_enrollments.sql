@@ -0,0 +1,5 @@
+-- Adds students and their enrolments to a database created before they existed.
+-- New databases get these tables from sql-scripts/CreateTable.sql instead.
+-- Run with: mysql -P 54812 --protocol=tcp -u root -p my_db_goMicroservice1 < 001_students_enrollments.sql
+CREATE TABLE Student (StudentID VARCHAR(8) NOT NULL PRIMARY KEY, Name VARCHAR(30), Email VARCHAR(60));
+CREATE TABLE Enrollment (CourseID VARCHAR(7) NOT NULL, StudentID VARCHAR(8) NOT NULL, EnrolledAt DATETIME NOT NULL, PRIMARY KEY (CourseID, StudentID), INDEX (StudentID), FOREIGN KEY (CourseID) REFERENCES Course (CourseID), FOREIGN KEY (StudentID) REFERENCES Student (StudentID));
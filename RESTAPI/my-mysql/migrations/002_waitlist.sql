@@ -0,0 +1,4 @@
+-- Adds the ordered waitlist of full courses to a database created before it existed.
+-- New databases get this table from sql-scripts/CreateTable.sql instead.
+-- Run with: mysql -P 54812 --protocol=tcp -u root -p my_db_goMicroservice1 < 002_waitlist.sql
+CREATE TABLE Waitlist (CourseID VARCHAR(7) NOT NULL, StudentID VARCHAR(8) NOT NULL, RequestedAt DATETIME(6) NOT NULL, PRIMARY KEY (CourseID, StudentID), INDEX (CourseID, RequestedAt), INDEX (StudentID), FOREIGN KEY (CourseID) REFERENCES Course (CourseID), FOREIGN KEY (StudentID) REFERENCES Student (StudentID));
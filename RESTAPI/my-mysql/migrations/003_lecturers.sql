@@ -0,0 +1,12 @@
+-- Moves the free-text Course.Lecturer column into a Lecturer table referenced by Course.LecturerID.
+-- New databases get this layout from sql-scripts/CreateTable.sql instead. Needs MySQL 8 for ROW_NUMBER.
+-- Run with: mysql -P 54812 --protocol=tcp -u root -p my_db_goMicroservice1 < 003_lecturers.sql
+-- Every distinct name becomes one lecturer with IDs L0001, L0002... in name order. Names differing only in
+-- case or surrounding spaces are merged; other typos still show up as separate lecturers, so review the list
+-- afterwards, point the courses of a duplicate at the right lecturer with UPDATE Course SET LecturerID and
+-- delete the duplicate. Email and Department start empty and can be filled in through PUT /api/v1/lecturers/{id}.
+CREATE TABLE Lecturer (LecturerID VARCHAR(5) NOT NULL PRIMARY KEY, Name VARCHAR(30) NOT NULL UNIQUE, Email VARCHAR(60) NOT NULL DEFAULT '', Department VARCHAR(30) NOT NULL DEFAULT '');
+INSERT INTO Lecturer (LecturerID, Name) SELECT CONCAT('L', LPAD(ROW_NUMBER() OVER (ORDER BY Name), 4, '0')), Name FROM (SELECT DISTINCT TRIM(Lecturer) AS Name FROM Course) AS Names;
+ALTER TABLE Course ADD COLUMN LecturerID VARCHAR(5) AFTER Title;
+UPDATE Course JOIN Lecturer ON Lecturer.Name = TRIM(Course.Lecturer) SET Course.LecturerID = Lecturer.LecturerID;
+ALTER TABLE Course MODIFY LecturerID VARCHAR(5) NOT NULL, DROP COLUMN Lecturer, ADD INDEX (LecturerID), ADD FOREIGN KEY (LecturerID) REFERENCES Lecturer (LecturerID);
@@ -0,0 +1,4 @@
+-- Adds prerequisites between courses to a database created before they existed.
+-- New databases get this table from sql-scripts/CreateTable.sql instead.
+-- Run with: mysql -P 54812 --protocol=tcp -u root -p my_db_goMicroservice1 < 004_prerequisites.sql
+CREATE TABLE Prerequisite (CourseID VARCHAR(7) NOT NULL, PrereqID VARCHAR(7) NOT NULL, PRIMARY KEY (CourseID, PrereqID), INDEX (PrereqID), FOREIGN KEY (CourseID) REFERENCES Course (CourseID), FOREIGN KEY (PrereqID) REFERENCES Course (CourseID));
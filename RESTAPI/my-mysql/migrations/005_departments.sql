@@ -0,0 +1,13 @@
+-- Adds departments owning course-code prefixes, and turns the free-text Lecturer.Department into a reference.
+-- New databases get this layout from sql-scripts/CreateTable.sql instead. Needs MySQL 8 for ROW_NUMBER.
+-- Run with: mysql -P 54812 --protocol=tcp -u root -p my_db_goMicroservice1 < 005_departments.sql
+-- Every distinct department name of a lecturer becomes one department with IDs D001, D002... in name order.
+-- A prefix is given to a department only when every lecturer teaching a course with it belongs to that
+-- department. Register the others with PUT /api/v1/departments/{id}, since new courses need a registered prefix.
+CREATE TABLE Department (DepartmentID VARCHAR(4) NOT NULL PRIMARY KEY, Name VARCHAR(30) NOT NULL UNIQUE);
+CREATE TABLE CoursePrefix (Prefix CHAR(3) NOT NULL PRIMARY KEY, DepartmentID VARCHAR(4) NOT NULL, INDEX (DepartmentID), FOREIGN KEY (DepartmentID) REFERENCES Department (DepartmentID));
+INSERT INTO Department (DepartmentID, Name) SELECT CONCAT('D', LPAD(ROW_NUMBER() OVER (ORDER BY Name), 3, '0')), Name FROM (SELECT DISTINCT TRIM(Department) AS Name FROM Lecturer WHERE TRIM(Department) <> '') AS Names;
+ALTER TABLE Lecturer ADD COLUMN DepartmentID VARCHAR(4) AFTER Email;
+UPDATE Lecturer JOIN Department ON Department.Name = TRIM(Lecturer.Department) SET Lecturer.DepartmentID = Department.DepartmentID;
+ALTER TABLE Lecturer DROP COLUMN Department, ADD INDEX (DepartmentID), ADD FOREIGN KEY (DepartmentID) REFERENCES Department (DepartmentID);
+INSERT INTO CoursePrefix (Prefix, DepartmentID) SELECT LEFT(c.CourseID, 3), MIN(l.DepartmentID) FROM Course c JOIN Lecturer l ON l.LecturerID = c.LecturerID WHERE c.CourseID REGEXP '^[A-Z]{3}[0-9]' GROUP BY LEFT(c.CourseID, 3) HAVING COUNT(*) = COUNT(l.DepartmentID) AND COUNT(DISTINCT l.DepartmentID) = 1;
@@ -0,0 +1,7 @@
+-- Adds semesters and course offerings to a database created before they existed.
+-- New databases get these tables from sql-scripts/CreateTable.sql instead.
+-- Run with: mysql -P 54812 --protocol=tcp -u root -p my_db_goMicroservice1 < 006_offerings.sql
+-- Course.ClassSize stays as the default capacity. Add the semesters through POST /api/v1/semesters/{id}, offer
+-- the courses of the first one through PUT .../offerings/{courseid} and roll them forward from there.
+CREATE TABLE Semester (SemesterID VARCHAR(6) NOT NULL PRIMARY KEY, Name VARCHAR(30) NOT NULL, StartDate DATE NOT NULL, EndDate DATE NOT NULL, INDEX (StartDate));
+CREATE TABLE CourseOffering (CourseID VARCHAR(7) NOT NULL, SemesterID VARCHAR(6) NOT NULL, LecturerID VARCHAR(5) NOT NULL, ClassSize INT NOT NULL, PRIMARY KEY (CourseID, SemesterID), INDEX (SemesterID), INDEX (LecturerID), FOREIGN KEY (CourseID) REFERENCES Course (CourseID), FOREIGN KEY (SemesterID) REFERENCES Semester (SemesterID), FOREIGN KEY (LecturerID) REFERENCES Lecturer (LecturerID));
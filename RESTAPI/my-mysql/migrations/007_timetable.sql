@@ -0,0 +1,7 @@
+-- Adds rooms and weekly course sessions to a database created before they existed.
+-- New databases get these tables from sql-scripts/CreateTable.sql instead.
+-- Run with: mysql -P 54812 --protocol=tcp -u root -p my_db_goMicroservice1 < 007_timetable.sql
+-- Day is stored as 1 for Monday to 7 for Sunday. Add the rooms through POST /api/v1/rooms/{id} and the sessions
+-- through POST /api/v1/courses/{courseid}/sessions, which check them for clashes.
+CREATE TABLE Room (RoomID VARCHAR(10) NOT NULL PRIMARY KEY, Name VARCHAR(30) NOT NULL, Capacity INT NOT NULL);
+CREATE TABLE Session (SessionID INT NOT NULL AUTO_INCREMENT PRIMARY KEY, CourseID VARCHAR(7) NOT NULL, Day TINYINT NOT NULL, StartTime TIME NOT NULL, EndTime TIME NOT NULL, RoomID VARCHAR(10) NOT NULL, INDEX (CourseID), INDEX (RoomID, Day), FOREIGN KEY (CourseID) REFERENCES Course (CourseID), FOREIGN KEY (RoomID) REFERENCES Room (RoomID));
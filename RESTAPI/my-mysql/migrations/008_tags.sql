@@ -0,0 +1,6 @@
+-- Adds course tags to a database created before they existed.
+-- New databases get this table from sql-scripts/CreateTable.sql instead.
+-- Run with: mysql -P 54812 --protocol=tcp -u root -p my_db_goMicroservice1 < 008_tags.sql
+-- Tags need no table of their own, a tag exists while a course carries it. Tag the courses through
+-- PUT /api/v1/courses/{courseid}/tags/{tag}.
+CREATE TABLE CourseTag (CourseID VARCHAR(7) NOT NULL, Tag VARCHAR(30) NOT NULL, PRIMARY KEY (CourseID, Tag), INDEX (Tag), FOREIGN KEY (CourseID) REFERENCES Course (CourseID));
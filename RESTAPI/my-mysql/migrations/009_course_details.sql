@@ -0,0 +1,7 @@
+-- Adds the description, credits, status and timestamps of a course, and lengthens its title, on a database
+-- created before they existed. New databases get these columns from sql-scripts/CreateTable.sql instead.
+-- Run with: mysql -P 54812 --protocol=tcp -u root -p my_db_goMicroservice1 < 009_course_details.sql
+-- Existing courses are already in use, so they are marked published while new ones start as drafts. Their
+-- timestamps start at the time of the migration. Fill in the credits through PATCH /api/v1/courses/{courseid}.
+ALTER TABLE Course MODIFY Title VARCHAR(100), ADD COLUMN Description VARCHAR(2000) NOT NULL DEFAULT '', ADD COLUMN Credits INT NOT NULL DEFAULT 0, ADD COLUMN Status ENUM('draft', 'published', 'archived') NOT NULL DEFAULT 'published', ADD COLUMN CreatedAt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP, ADD COLUMN UpdatedAt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE Course ALTER COLUMN Status SET DEFAULT 'draft';
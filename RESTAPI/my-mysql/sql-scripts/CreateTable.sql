@@ -1 +1,13 @@
-CREATE TABLE Course (CourseID VARCHAR(7) NOT NULL PRIMARY KEY, Title VARCHAR(30), Lecturer VARCHAR(30), ClassSize INT);
\ No newline at end of file
+CREATE TABLE Department (DepartmentID VARCHAR(4) NOT NULL PRIMARY KEY, Name VARCHAR(30) NOT NULL UNIQUE);
+CREATE TABLE CoursePrefix (Prefix CHAR(3) NOT NULL PRIMARY KEY, DepartmentID VARCHAR(4) NOT NULL, INDEX (DepartmentID), FOREIGN KEY (DepartmentID) REFERENCES Department (DepartmentID));
+CREATE TABLE Lecturer (LecturerID VARCHAR(5) NOT NULL PRIMARY KEY, Name VARCHAR(30) NOT NULL UNIQUE, Email VARCHAR(60) NOT NULL DEFAULT '', DepartmentID VARCHAR(4), INDEX (DepartmentID), FOREIGN KEY (DepartmentID) REFERENCES Department (DepartmentID));
+CREATE TABLE Course (CourseID VARCHAR(7) NOT NULL PRIMARY KEY, Title VARCHAR(100), LecturerID VARCHAR(5) NOT NULL, ClassSize INT, Description VARCHAR(2000) NOT NULL DEFAULT '', Credits INT NOT NULL DEFAULT 0, Status ENUM('draft', 'published', 'archived') NOT NULL DEFAULT 'draft', CreatedAt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP, UpdatedAt DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP, INDEX (LecturerID), FOREIGN KEY (LecturerID) REFERENCES Lecturer (LecturerID));
+CREATE TABLE Semester (SemesterID VARCHAR(6) NOT NULL PRIMARY KEY, Name VARCHAR(30) NOT NULL, StartDate DATE NOT NULL, EndDate DATE NOT NULL, INDEX (StartDate));
+CREATE TABLE CourseOffering (CourseID VARCHAR(7) NOT NULL, SemesterID VARCHAR(6) NOT NULL, LecturerID VARCHAR(5) NOT NULL, ClassSize INT NOT NULL, PRIMARY KEY (CourseID, SemesterID), INDEX (SemesterID), INDEX (LecturerID), FOREIGN KEY (CourseID) REFERENCES Course (CourseID), FOREIGN KEY (SemesterID) REFERENCES Semester (SemesterID), FOREIGN KEY (LecturerID) REFERENCES Lecturer (LecturerID));
+CREATE TABLE Room (RoomID VARCHAR(10) NOT NULL PRIMARY KEY, Name VARCHAR(30) NOT NULL, Capacity INT NOT NULL);
+CREATE TABLE Session (SessionID INT NOT NULL AUTO_INCREMENT PRIMARY KEY, CourseID VARCHAR(7) NOT NULL, Day TINYINT NOT NULL, StartTime TIME NOT NULL, EndTime TIME NOT NULL, RoomID VARCHAR(10) NOT NULL, INDEX (CourseID), INDEX (RoomID, Day), FOREIGN KEY (CourseID) REFERENCES Course (CourseID), FOREIGN KEY (RoomID) REFERENCES Room (RoomID));
+CREATE TABLE CourseTag (CourseID VARCHAR(7) NOT NULL, Tag VARCHAR(30) NOT NULL, PRIMARY KEY (CourseID, Tag), INDEX (Tag), FOREIGN KEY (CourseID) REFERENCES Course (CourseID));
+CREATE TABLE Prerequisite (CourseID VARCHAR(7) NOT NULL, PrereqID VARCHAR(7) NOT NULL, PRIMARY KEY (CourseID, PrereqID), INDEX (PrereqID), FOREIGN KEY (CourseID) REFERENCES Course (CourseID), FOREIGN KEY (PrereqID) REFERENCES Course (CourseID));
+CREATE TABLE Student (StudentID VARCHAR(8) NOT NULL PRIMARY KEY, Name VARCHAR(30), Email VARCHAR(60));
+CREATE TABLE Enrollment (CourseID VARCHAR(7) NOT NULL, StudentID VARCHAR(8) NOT NULL, EnrolledAt DATETIME NOT NULL, PRIMARY KEY (CourseID, StudentID), INDEX (StudentID), FOREIGN KEY (CourseID) REFERENCES Course (CourseID), FOREIGN KEY (StudentID) REFERENCES Student (StudentID));
+CREATE TABLE Waitlist (CourseID VARCHAR(7) NOT NULL, StudentID VARCHAR(8) NOT NULL, RequestedAt DATETIME(6) NOT NULL, PRIMARY KEY (CourseID, StudentID), INDEX (CourseID, RequestedAt), INDEX (StudentID), FOREIGN KEY (CourseID) REFERENCES Course (CourseID), FOREIGN KEY (StudentID) REFERENCES Student (StudentID));
\ No newline at end of file
@@ -1,13 +1,81 @@
-INSERT INTO Course (`CourseID`,`Title`,`Lecturer`,`ClassSize`) VALUES ('CS3001','Software Development','Jackson Ong',80);
-INSERT INTO Course (`CourseID`,`Title`,`Lecturer`,`ClassSize`) VALUES ('GOS1000','Go Basic','Low Kheng Hian',25);
-INSERT INTO Course (`CourseID`,`Title`,`Lecturer`,`ClassSize`) VALUES ('GOS1001','Database Management','Matthew Lee',80);
-INSERT INTO Course (`CourseID`,`Title`,`Lecturer`,`ClassSize`) VALUES ('GOS1002','Go Advanced','Lee Ching Yun',23);
-INSERT INTO Course (`CourseID`,`Title`,`Lecturer`,`ClassSize`) VALUES ('GOS1010','Operating System','Ken Tan',100);
-INSERT INTO Course (`CourseID`,`Title`,`Lecturer`,`ClassSize`) VALUES ('GOS2001','Go In Action 1 ','Low Kheng Hian',22);
-INSERT INTO Course (`CourseID`,`Title`,`Lecturer`,`ClassSize`) VALUES ('GOS2002','Go In Action 2','Lee Ching Yun',22);
-INSERT INTO Course (`CourseID`,`Title`,`Lecturer`,`ClassSize`) VALUES ('GOS3001','Go Microservice 1','Lee Ching Yun',22);
-INSERT INTO Course (`CourseID`,`Title`,`Lecturer`,`ClassSize`) VALUES ('GOS3002','Go Microservice 2','Low Kheng Hian',22);
-INSERT INTO Course (`CourseID`,`Title`,`Lecturer`,`ClassSize`) VALUES ('GOS4000','Go Live Project','Matthew Lee',50);
-INSERT INTO Course (`CourseID`,`Title`,`Lecturer`,`ClassSize`) VALUES ('IOT2000','Basic Automation','Ken Tan',100);
-INSERT INTO Course (`CourseID`,`Title`,`Lecturer`,`ClassSize`) VALUES ('IOT3000','Advanced Automation','Jackson Ong',50);
-INSERT INTO Course (`CourseID`,`Title`,`Lecturer`,`ClassSize`) VALUES ('IOT4000','Industry 4.0','Michael Lim',150);
\ No newline at end of file
+INSERT INTO Department (`DepartmentID`,`Name`) VALUES ('D001','School of Computing');
+INSERT INTO Department (`DepartmentID`,`Name`) VALUES ('D002','School of Engineering');
+INSERT INTO CoursePrefix (`Prefix`,`DepartmentID`) VALUES ('GOS','D001');
+INSERT INTO CoursePrefix (`Prefix`,`DepartmentID`) VALUES ('IOT','D002');
+INSERT INTO Lecturer (`LecturerID`,`Name`,`Email`,`DepartmentID`) VALUES ('L0001','Jackson Ong','jackson.ong@example.com','D002');
+INSERT INTO Lecturer (`LecturerID`,`Name`,`Email`,`DepartmentID`) VALUES ('L0002','Ken Tan','ken.tan@example.com',NULL);
+INSERT INTO Lecturer (`LecturerID`,`Name`,`Email`,`DepartmentID`) VALUES ('L0003','Lee Ching Yun','chingyun.lee@example.com','D001');
+INSERT INTO Lecturer (`LecturerID`,`Name`,`Email`,`DepartmentID`) VALUES ('L0004','Low Kheng Hian','khenghian.low@example.com','D001');
+INSERT INTO Lecturer (`LecturerID`,`Name`,`Email`,`DepartmentID`) VALUES ('L0005','Matthew Lee','matthew.lee@example.com','D001');
+INSERT INTO Lecturer (`LecturerID`,`Name`,`Email`,`DepartmentID`) VALUES ('L0006','Michael Lim','michael.lim@example.com','D002');
+INSERT INTO Course (`CourseID`,`Title`,`LecturerID`,`ClassSize`,`Credits`,`Status`) VALUES ('CS3001','Software Development','L0001',80,6,'published');
+INSERT INTO Course (`CourseID`,`Title`,`LecturerID`,`ClassSize`,`Credits`,`Status`) VALUES ('GOS1000','Go Basic','L0004',25,4,'published');
+INSERT INTO Course (`CourseID`,`Title`,`LecturerID`,`ClassSize`,`Credits`,`Status`) VALUES ('GOS1001','Database Management','L0005',80,4,'published');
+INSERT INTO Course (`CourseID`,`Title`,`LecturerID`,`ClassSize`,`Credits`,`Status`) VALUES ('GOS1002','Go Advanced','L0003',23,4,'published');
+INSERT INTO Course (`CourseID`,`Title`,`LecturerID`,`ClassSize`,`Credits`,`Status`) VALUES ('GOS1010','Operating System','L0002',100,4,'published');
+INSERT INTO Course (`CourseID`,`Title`,`LecturerID`,`ClassSize`,`Credits`,`Status`) VALUES ('GOS2001','Go In Action 1 ','L0004',22,4,'published');
+INSERT INTO Course (`CourseID`,`Title`,`LecturerID`,`ClassSize`,`Credits`,`Status`) VALUES ('GOS2002','Go In Action 2','L0003',22,4,'published');
+INSERT INTO Course (`CourseID`,`Title`,`LecturerID`,`ClassSize`,`Credits`,`Status`) VALUES ('GOS3001','Go Microservice 1','L0003',22,4,'published');
+INSERT INTO Course (`CourseID`,`Title`,`LecturerID`,`ClassSize`,`Credits`,`Status`) VALUES ('GOS3002','Go Microservice 2','L0004',22,4,'published');
+INSERT INTO Course (`CourseID`,`Title`,`LecturerID`,`ClassSize`,`Credits`,`Status`) VALUES ('GOS4000','Go Live Project','L0005',50,8,'published');
+INSERT INTO Course (`CourseID`,`Title`,`LecturerID`,`ClassSize`,`Credits`,`Status`) VALUES ('IOT2000','Basic Automation','L0002',100,4,'published');
+INSERT INTO Course (`CourseID`,`Title`,`LecturerID`,`ClassSize`,`Credits`,`Status`) VALUES ('IOT3000','Advanced Automation','L0001',50,4,'published');
+INSERT INTO Course (`CourseID`,`Title`,`LecturerID`,`ClassSize`,`Credits`,`Status`) VALUES ('IOT4000','Industry 4.0','L0006',150,6,'published');
+INSERT INTO Prerequisite (`CourseID`,`PrereqID`) VALUES ('GOS1002','GOS1000');
+INSERT INTO Prerequisite (`CourseID`,`PrereqID`) VALUES ('GOS2001','GOS1002');
+INSERT INTO Prerequisite (`CourseID`,`PrereqID`) VALUES ('GOS2002','GOS2001');
+INSERT INTO Prerequisite (`CourseID`,`PrereqID`) VALUES ('GOS3001','GOS2002');
+INSERT INTO Prerequisite (`CourseID`,`PrereqID`) VALUES ('GOS3002','GOS3001');
+INSERT INTO Prerequisite (`CourseID`,`PrereqID`) VALUES ('GOS4000','GOS3002');
+INSERT INTO Prerequisite (`CourseID`,`PrereqID`) VALUES ('IOT3000','IOT2000');
+INSERT INTO Prerequisite (`CourseID`,`PrereqID`) VALUES ('IOT4000','IOT3000');
+INSERT INTO Semester (`SemesterID`,`Name`,`StartDate`,`EndDate`) VALUES ('2024S1','Semester 1 2024','2024-01-15','2024-05-10');
+INSERT INTO Semester (`SemesterID`,`Name`,`StartDate`,`EndDate`) VALUES ('2024S2','Semester 2 2024','2024-07-15','2024-11-08');
+INSERT INTO CourseOffering (`CourseID`,`SemesterID`,`LecturerID`,`ClassSize`) VALUES ('GOS1000','2024S1','L0004',25);
+INSERT INTO CourseOffering (`CourseID`,`SemesterID`,`LecturerID`,`ClassSize`) VALUES ('GOS1002','2024S1','L0003',23);
+INSERT INTO CourseOffering (`CourseID`,`SemesterID`,`LecturerID`,`ClassSize`) VALUES ('IOT2000','2024S1','L0002',100);
+INSERT INTO CourseOffering (`CourseID`,`SemesterID`,`LecturerID`,`ClassSize`) VALUES ('GOS1000','2024S2','L0005',30);
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('GOS1000','golang');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('GOS1000','level:beginner');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('GOS1001','databases');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('GOS1001','level:beginner');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('GOS1002','golang');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('GOS1002','level:intermediate');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('GOS1010','operating-systems');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('GOS1010','level:intermediate');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('GOS2001','golang');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('GOS2001','level:intermediate');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('GOS2002','golang');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('GOS2002','level:intermediate');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('GOS3001','golang');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('GOS3001','microservices');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('GOS3001','level:advanced');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('GOS3002','golang');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('GOS3002','microservices');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('GOS3002','level:advanced');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('GOS4000','golang');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('GOS4000','project');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('GOS4000','level:advanced');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('IOT2000','automation');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('IOT2000','iot');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('IOT2000','level:beginner');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('IOT3000','automation');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('IOT3000','iot');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('IOT3000','level:advanced');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('IOT4000','industry-4.0');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('IOT4000','iot');
+INSERT INTO CourseTag (`CourseID`,`Tag`) VALUES ('IOT4000','level:advanced');
+INSERT INTO Room (`RoomID`,`Name`,`Capacity`) VALUES ('R101','Lecture Room 1',30);
+INSERT INTO Room (`RoomID`,`Name`,`Capacity`) VALUES ('R102','Lecture Room 2',60);
+INSERT INTO Room (`RoomID`,`Name`,`Capacity`) VALUES ('LT1','Lecture Theatre 1',200);
+INSERT INTO Session (`CourseID`,`Day`,`StartTime`,`EndTime`,`RoomID`) VALUES ('GOS1000',1,'09:00','11:00','R101');
+INSERT INTO Session (`CourseID`,`Day`,`StartTime`,`EndTime`,`RoomID`) VALUES ('GOS1002',1,'14:00','16:00','R101');
+INSERT INTO Session (`CourseID`,`Day`,`StartTime`,`EndTime`,`RoomID`) VALUES ('GOS3001',3,'10:00','12:00','R102');
+INSERT INTO Session (`CourseID`,`Day`,`StartTime`,`EndTime`,`RoomID`) VALUES ('IOT2000',2,'09:00','12:00','LT1');
+INSERT INTO Session (`CourseID`,`Day`,`StartTime`,`EndTime`,`RoomID`) VALUES ('IOT4000',4,'13:00','16:00','LT1');
+INSERT INTO Student (`StudentID`,`Name`,`Email`) VALUES ('S1000001','Tan Mei Ling','meiling.tan@example.com');
+INSERT INTO Student (`StudentID`,`Name`,`Email`) VALUES ('S1000002','Rajesh Kumar','rajesh.kumar@example.com');
+INSERT INTO Student (`StudentID`,`Name`,`Email`) VALUES ('S1000003','Nur Aisyah','nur.aisyah@example.com');
+INSERT INTO Enrollment (`CourseID`,`StudentID`,`EnrolledAt`) VALUES ('GOS1000','S1000001','2021-04-01 09:00:00');
+INSERT INTO Enrollment (`CourseID`,`StudentID`,`EnrolledAt`) VALUES ('GOS1000','S1000002','2021-04-01 09:05:00');
+INSERT INTO Enrollment (`CourseID`,`StudentID`,`EnrolledAt`) VALUES ('GOS3001','S1000003','2021-04-02 10:30:00');
\ No newline at end of file
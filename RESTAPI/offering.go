@@ -0,0 +1,269 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	database "goMicroService1Assignment/RESTAPI/database"
+)
+
+//dateLayout is the format of semester dates.
+const dateLayout = "2006-01-02"
+
+//validateSemester sanitizes a semester in place and checks it against the rules for each field.
+//The semester must end after it starts.
+func validateSemester(s *database.Semester) error {
+	s.SemesterID = Policy.Sanitize(strings.TrimSpace(s.SemesterID))
+	if !regexSemesterID.MatchString(s.SemesterID) {
+		return errors.New("incorrect format for Semester ID")
+	}
+	if s.Name == "" || s.StartDate == "" || s.EndDate == "" {
+		return errors.New("information supplied not complete")
+	}
+	s.Name = Policy.Sanitize(strings.TrimSpace(s.Name))
+	if !regexTitleLecturer.MatchString(s.Name) {
+		return errors.New("incorrect format for Semester Name")
+	}
+	start, err := time.Parse(dateLayout, strings.TrimSpace(s.StartDate))
+	if err != nil {
+		return errors.New("incorrect format for StartDate, use YYYY-MM-DD")
+	}
+	end, err := time.Parse(dateLayout, strings.TrimSpace(s.EndDate))
+	if err != nil {
+		return errors.New("incorrect format for EndDate, use YYYY-MM-DD")
+	}
+	if !end.After(start) {
+		return errors.New("EndDate must be after StartDate")
+	}
+	s.StartDate, s.EndDate = start.Format(dateLayout), end.Format(dateLayout)
+	return nil
+}
+
+//validateOffering sanitizes an offering in place and checks it against the same rules as a course.
+func validateOffering(o *database.CourseOffering) error {
+	if (o.Lecturer == "" && o.LecturerID == "") || o.ClassSize <= 0 {
+		return errors.New("information supplied not complete")
+	}
+	if o.LecturerID != "" {
+		o.LecturerID = Policy.Sanitize(strings.TrimSpace(o.LecturerID))
+		if !regexLecturerID.MatchString(o.LecturerID) {
+			return errors.New("incorrect format for Lecturer ID")
+		}
+		return nil
+	}
+	o.Lecturer = Policy.Sanitize(strings.TrimSpace(o.Lecturer))
+	if !regexTitleLecturer.MatchString(o.Lecturer) {
+		return errors.New("incorrect format for Course Lecturer")
+	}
+	return nil
+}
+
+//semesters lists every semester in term order.
+func semesters(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+
+	allSemesters, err := database.GetAllSemesters(r.Context(), db)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	writeResponse(w, r, http.StatusOK, &allSemesters)
+}
+
+//semester gets, creates, updates or deletes the semester named in the URL.
+func semester(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+	semesterID, ok := pathID(w, r, "semesterid", regexSemesterID, "Semester ID")
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		semester, err := database.GetSemester(r.Context(), db, semesterID)
+		if err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		writeResponse(w, r, http.StatusOK, &semester)
+
+	case http.MethodPost, http.MethodPut:
+		var newSemester database.Semester
+		if !decodeRequest(w, r, &newSemester) {
+			return
+		}
+		if newSemester.SemesterID != "" && newSemester.SemesterID != semesterID {
+			writeJSONError(w, r, http.StatusUnprocessableEntity, "422 - SemesterID in the body does not match the URL")
+			log.Warning("Fail attempt to save semester: 422 - SemesterID in the body does not match the URL")
+			return
+		}
+		newSemester.SemesterID = semesterID
+		if err := validateSemester(&newSemester); err != nil {
+			writeJSONError(w, r, http.StatusUnprocessableEntity, "422 - "+err.Error())
+			log.Warning("Fail attempt to save semester: 422 - ", err)
+			return
+		}
+
+		status := http.StatusCreated
+		var err error
+		if r.Method == http.MethodPost {
+			err = database.InsertSemester(r.Context(), db, newSemester)
+		} else {
+			status = http.StatusOK
+			err = database.UpdateSemester(r.Context(), db, newSemester)
+		}
+		if errors.Is(err, database.ErrConflict) {
+			writeJSONError(w, r, http.StatusConflict, "409 - Duplicate semester ID")
+			log.Warning("Fail attempt to save semester: 409 - Duplicate semester ID")
+		} else if err != nil {
+			writeDBError(w, r, err)
+		} else {
+			writeResponse(w, r, status, &newSemester)
+		}
+
+	case http.MethodDelete:
+		if err := database.DeleteSemester(r.Context(), db, semesterID); err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+//semesterOfferings lists the courses offered in the semester named in the URL.
+func semesterOfferings(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+	semesterID, ok := pathID(w, r, "semesterid", regexSemesterID, "Semester ID")
+	if !ok {
+		return
+	}
+
+	offerings, err := database.GetSemesterOfferings(r.Context(), db, semesterID)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	writeResponse(w, r, http.StatusOK, &offerings)
+}
+
+//offering gets, creates or updates, or deletes the offering of a course in a semester.
+//PUT returns 201 for a new offering and 200 when the lecturer or class size of an existing one changed.
+func offering(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+	semesterID, ok := pathID(w, r, "semesterid", regexSemesterID, "Semester ID")
+	if !ok {
+		return
+	}
+	courseID, ok := pathID(w, r, "courseid", regexCourseID, "Course ID")
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		offering, err := database.GetOffering(r.Context(), db, courseID, semesterID)
+		if err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		writeResponse(w, r, http.StatusOK, &offering)
+
+	case http.MethodPut:
+		var newOffering database.CourseOffering
+		if !decodeRequest(w, r, &newOffering) {
+			return
+		}
+		if (newOffering.CourseID != "" && newOffering.CourseID != courseID) ||
+			(newOffering.SemesterID != "" && newOffering.SemesterID != semesterID) {
+			writeJSONError(w, r, http.StatusUnprocessableEntity, "422 - CourseID or SemesterID in the body does not match the URL")
+			log.Warning("Fail attempt to save offering: 422 - CourseID or SemesterID in the body does not match the URL")
+			return
+		}
+		newOffering.CourseID, newOffering.SemesterID = courseID, semesterID
+		if err := validateOffering(&newOffering); err != nil {
+			writeJSONError(w, r, http.StatusUnprocessableEntity, "422 - "+err.Error())
+			log.Warning("Fail attempt to save offering: 422 - ", err)
+			return
+		}
+
+		saved, created, err := database.UpsertOffering(r.Context(), db, newOffering)
+		if err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		status := http.StatusOK
+		if created {
+			status = http.StatusCreated
+		}
+		writeResponse(w, r, status, &saved)
+
+	case http.MethodDelete:
+		if err := database.DeleteOffering(r.Context(), db, courseID, semesterID); err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+//courseOfferings lists the semesters the course named in the URL is offered in, in term order.
+func courseOfferings(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+	courseID, ok := pathID(w, r, "courseid", regexCourseID, "Course ID")
+	if !ok {
+		return
+	}
+
+	offerings, err := database.GetCourseOfferings(r.Context(), db, courseID)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	writeResponse(w, r, http.StatusOK, &offerings)
+}
+
+//rollForward copies the offerings of the semester named in the URL into the next semester, or into the one
+//given by the to query parameter. Offerings the target already has are kept and reported as skipped.
+func rollForward(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+	semesterID, ok := pathID(w, r, "semesterid", regexSemesterID, "Semester ID")
+	if !ok {
+		return
+	}
+	to := Policy.Sanitize(r.URL.Query().Get("to"))
+	if to != "" && !regexSemesterID.MatchString(to) {
+		writeJSONError(w, r, http.StatusBadRequest, "400 - incorrect format for Semester ID in to")
+		log.Warning("Fail attempt to roll forward offerings: 400 - incorrect format for Semester ID in to")
+		return
+	}
+
+	result, err := database.RollForwardOfferings(r.Context(), db, semesterID, to)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	log.Info("Offerings of ", semesterID, " rolled forward into ", result.To, ": ", len(result.Copied), " copied, ", len(result.Skipped), " skipped")
+	writeResponse(w, r, http.StatusOK, &result)
+}
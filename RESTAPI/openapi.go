@@ -0,0 +1,16 @@
+package main
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+//openAPIDocument is the OpenAPI 3 contract of this API, kept next to the code in openapi.json.
+//go:embed openapi.json
+var openAPIDocument []byte
+
+//openAPISpec serves the OpenAPI document. It needs no key so client teams can always fetch the contract.
+func openAPISpec(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPIDocument)
+}
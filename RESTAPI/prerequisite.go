@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	database "goMicroService1Assignment/RESTAPI/database"
+)
+
+//prerequisites lists the courses to take before the course in the URL, each after its own prerequisites.
+//transitive=true lists the prerequisites of the prerequisites too, down to the first courses of the progression.
+func prerequisites(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+	courseID, ok := pathID(w, r, "courseid", regexCourseID, "Course ID")
+	if !ok {
+		return
+	}
+
+	transitive := r.URL.Query().Get("transitive") == "true"
+	courses, err := database.GetPrerequisites(r.Context(), db, courseID, transitive)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	writeResponse(w, r, http.StatusOK, &courses)
+}
+
+//prerequisite adds or removes one prerequisite of the course in the URL. Adding is refused with 409
+//if the prerequisite already depends on the course, since that would make the course require itself.
+func prerequisite(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+	courseID, ok := pathID(w, r, "courseid", regexCourseID, "Course ID")
+	if !ok {
+		return
+	}
+	prereqID, ok := pathID(w, r, "prereqid", regexCourseID, "Prerequisite Course ID")
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		created, err := database.AddPrerequisite(r.Context(), db, courseID, prereqID)
+		if err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		if created {
+			log.Info("Prerequisite added: ", prereqID, " before ", courseID)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case http.MethodDelete:
+		if err := database.RemovePrerequisite(r.Context(), db, courseID, prereqID); err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+
+	database "goMicroService1Assignment/RESTAPI/database"
+)
+
+//errorResponse is the JSON body sent back for server side failures.
+type errorResponse struct {
+	Status    int
+	Message   string
+	RequestID string
+}
+
+//writeJSONError writes an errorResponse with the given status code.
+func writeJSONError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(errorResponse{Status: status, Message: message, RequestID: requestID(r)})
+}
+
+//writeDBError maps an error returned by the database package onto an HTTP status and logs it.
+func writeDBError(w http.ResponseWriter, r *http.Request, err error) {
+	var status int
+	var message string
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		status, message = http.StatusNotFound, "404 - No course found"
+	case errors.Is(err, database.ErrConflict):
+		status, message = http.StatusConflict, "409 - Conflict with existing record"
+	case errors.Is(err, database.ErrInvalid):
+		status, message = http.StatusUnprocessableEntity, "422 - Refers to a record that does not exist"
+	case errors.Is(err, database.ErrUnavailable):
+		status, message = http.StatusServiceUnavailable, "503 - Database unavailable, please try again later"
+	case errors.Is(err, database.ErrTimeout):
+		status, message = http.StatusGatewayTimeout, "504 - Database query timed out"
+	default:
+		status, message = http.StatusInternalServerError, "500 - Internal server error"
+	}
+	//a broken rule carries its own explanation, e.g. that the student is already enrolled
+	var rule *database.RuleError
+	if errors.As(err, &rule) {
+		message = strconv.Itoa(status) + " - " + rule.Message
+	}
+
+	entry := log.WithFields(log.Fields{"requestID": requestID(r), "status": status})
+	if status >= http.StatusInternalServerError {
+		entry.Error(err.Error())
+	} else {
+		entry.Warning(err.Error())
+	}
+	writeJSONError(w, r, status, message)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/csv"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	database "goMicroService1Assignment/RESTAPI/database"
+)
+
+//regexTag is the format of a tag, e.g. golang or level:beginner. Tags are lower-cased before matching.
+var regexTag = regexp.MustCompile(`^[a-z0-9][a-z0-9.:-]{0,29}$`)
+
+//maxTagFilters caps the number of tag parameters accepted by the course list.
+const maxTagFilters = 10
+
+//courseSearch is the course list returned with facets=true: the matching courses and the tag counts among them.
+type courseSearch struct {
+	Courses []database.Course   `yaml:"Courses"`
+	Facets  []database.TagCount `yaml:"Facets"`
+}
+
+//normalizeTag sanitizes and lower-cases a tag, reporting whether it is well formed.
+func normalizeTag(tag string) (string, bool) {
+	tag = strings.ToLower(Policy.Sanitize(strings.TrimSpace(tag)))
+	return tag, regexTag.MatchString(tag)
+}
+
+//requestTags reads the tag query parameters of the course list, dropping duplicates. It writes the error
+//response itself and returns false if a tag is malformed or there are too many.
+func requestTags(w http.ResponseWriter, r *http.Request) ([]string, bool) {
+	values := r.URL.Query()["tag"]
+	if len(values) > maxTagFilters {
+		writeJSONError(w, r, http.StatusBadRequest, "400 - at most "+strconv.Itoa(maxTagFilters)+" tag filters are allowed")
+		return nil, false
+	}
+	seen := map[string]bool{}
+	tags := []string{}
+	for _, value := range values {
+		tag, ok := normalizeTag(value)
+		if !ok {
+			writeJSONError(w, r, http.StatusBadRequest, "400 - incorrect format for tag "+strconv.Quote(tag))
+			log.Warning("Fail attempt to filter courses: 400 - incorrect format for tag")
+			return nil, false
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags, true
+}
+
+//filterCourses answers the course list when it is filtered by tag or asks for facets. Only courses carrying
+//every tag are listed. CSV has no room for facets, so a CSV list is filtered but never carries them.
+func filterCourses(w http.ResponseWriter, r *http.Request, tags []string, facets bool) {
+	courses, counts, err := database.FilterRecords(r.Context(), db, tags)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+
+	if mediaType, _ := negotiate(r, listMediaTypes()); mediaType == "text/csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="courses.csv"`)
+		out := csv.NewWriter(w)
+		out.Write(csvColumns)
+		for _, course := range courses {
+			out.Write(csvRecord(course))
+		}
+		out.Flush()
+		return
+	}
+	if facets {
+		writeResponse(w, r, http.StatusOK, &courseSearch{Courses: courses, Facets: counts})
+		return
+	}
+	writeResponse(w, r, http.StatusOK, &courses)
+}
+
+//tags lists every tag in use with the number of courses carrying it, most used first.
+func tags(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+
+	allTags, err := database.GetAllTags(r.Context(), db)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	writeResponse(w, r, http.StatusOK, &allTags)
+}
+
+//courseTags lists the tags of the course named in the URL.
+func courseTags(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+	courseID, ok := pathID(w, r, "courseid", regexCourseID, "Course ID")
+	if !ok {
+		return
+	}
+
+	tags, err := database.GetCourseTags(r.Context(), db, courseID)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	writeResponse(w, r, http.StatusOK, &tags)
+}
+
+//courseTag tags or untags the course named in the URL. Tagging is idempotent: 201 when the tag is new
+//to the course, 204 when it already had it.
+func courseTag(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+	courseID, ok := pathID(w, r, "courseid", regexCourseID, "Course ID")
+	if !ok {
+		return
+	}
+	tag, ok := normalizeTag(mux.Vars(r)["tag"])
+	if !ok {
+		writeJSONError(w, r, http.StatusBadRequest, "400 - incorrect format for Tag")
+		log.Warning("Fail attempt in path parameter: 400 - incorrect format for Tag")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		created, err := database.AddTag(r.Context(), db, courseID, tag)
+		if err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		if created {
+			log.Info("Course ", courseID, " tagged ", tag)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case http.MethodDelete:
+		if err := database.RemoveTag(r.Context(), db, courseID, tag); err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	database "goMicroService1Assignment/RESTAPI/database"
+)
+
+//regular expression pattern for room and session input.
+var (
+	regexRoomID    = regexp.MustCompile(`^[A-Z0-9-]{2,10}$`)
+	regexSessionID = regexp.MustCompile(`^[1-9][0-9]{0,8}$`)
+)
+
+//timeLayout is the format of session times.
+const timeLayout = "15:04"
+
+//validateRoom sanitizes a room in place and checks it against the rules for each field.
+func validateRoom(room *database.Room) error {
+	room.RoomID = Policy.Sanitize(strings.TrimSpace(room.RoomID))
+	if !regexRoomID.MatchString(room.RoomID) {
+		return errors.New("incorrect format for Room ID")
+	}
+	if room.Name == "" || room.Capacity <= 0 {
+		return errors.New("information supplied not complete")
+	}
+	room.Name = Policy.Sanitize(strings.TrimSpace(room.Name))
+	if !regexTitleLecturer.MatchString(room.Name) {
+		return errors.New("incorrect format for Room Name")
+	}
+	return nil
+}
+
+//validateSession sanitizes a session in place and checks it against the rules for each field.
+//Day is matched regardless of case; the session must end after it starts on the same day.
+func validateSession(s *database.Session) error {
+	if s.Day == "" || s.StartTime == "" || s.EndTime == "" || s.RoomID == "" {
+		return errors.New("information supplied not complete")
+	}
+	day := strings.ToLower(Policy.Sanitize(strings.TrimSpace(s.Day)))
+	if day != "" {
+		day = strings.ToUpper(day[:1]) + day[1:]
+	}
+	if !database.ValidDay(day) {
+		return errors.New("incorrect format for Day, use a weekday name such as Monday")
+	}
+	s.Day = day
+	start, err := time.Parse(timeLayout, strings.TrimSpace(s.StartTime))
+	if err != nil {
+		return errors.New("incorrect format for StartTime, use HH:MM")
+	}
+	end, err := time.Parse(timeLayout, strings.TrimSpace(s.EndTime))
+	if err != nil {
+		return errors.New("incorrect format for EndTime, use HH:MM")
+	}
+	if !end.After(start) {
+		return errors.New("EndTime must be after StartTime")
+	}
+	s.StartTime, s.EndTime = start.Format(timeLayout), end.Format(timeLayout)
+	s.RoomID = Policy.Sanitize(strings.TrimSpace(s.RoomID))
+	if !regexRoomID.MatchString(s.RoomID) {
+		return errors.New("incorrect format for Room ID")
+	}
+	return nil
+}
+
+//rooms lists every room.
+func rooms(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+
+	allRooms, err := database.GetAllRooms(r.Context(), db)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	writeResponse(w, r, http.StatusOK, &allRooms)
+}
+
+//room gets, creates, updates or deletes the room named in the URL.
+//A room cannot be made smaller than a course meeting in it, nor deleted while sessions are held in it.
+func room(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+	roomID, ok := pathID(w, r, "roomid", regexRoomID, "Room ID")
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		room, err := database.GetRoom(r.Context(), db, roomID)
+		if err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		writeResponse(w, r, http.StatusOK, &room)
+
+	case http.MethodPost, http.MethodPut:
+		var newRoom database.Room
+		if !decodeRequest(w, r, &newRoom) {
+			return
+		}
+		if newRoom.RoomID != "" && newRoom.RoomID != roomID {
+			writeJSONError(w, r, http.StatusUnprocessableEntity, "422 - RoomID in the body does not match the URL")
+			log.Warning("Fail attempt to save room: 422 - RoomID in the body does not match the URL")
+			return
+		}
+		newRoom.RoomID = roomID
+		if err := validateRoom(&newRoom); err != nil {
+			writeJSONError(w, r, http.StatusUnprocessableEntity, "422 - "+err.Error())
+			log.Warning("Fail attempt to save room: 422 - ", err)
+			return
+		}
+
+		status := http.StatusCreated
+		var err error
+		if r.Method == http.MethodPost {
+			err = database.InsertRoom(r.Context(), db, newRoom)
+		} else {
+			status = http.StatusOK
+			err = database.UpdateRoom(r.Context(), db, newRoom)
+		}
+		var rule *database.RuleError
+		if errors.Is(err, database.ErrConflict) && !errors.As(err, &rule) {
+			writeJSONError(w, r, http.StatusConflict, "409 - Duplicate room ID")
+			log.Warning("Fail attempt to save room: 409 - Duplicate room ID")
+		} else if err != nil {
+			writeDBError(w, r, err)
+		} else {
+			writeResponse(w, r, status, &newRoom)
+		}
+
+	case http.MethodDelete:
+		if err := database.DeleteRoom(r.Context(), db, roomID); err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+//roomTimetable lists the sessions held in the room named in the URL, Monday first.
+func roomTimetable(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+	roomID, ok := pathID(w, r, "roomid", regexRoomID, "Room ID")
+	if !ok {
+		return
+	}
+
+	sessions, err := database.GetRoomTimetable(r.Context(), db, roomID)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	writeResponse(w, r, http.StatusOK, &sessions)
+}
+
+//lecturerTimetable lists the sessions of every course the lecturer named in the URL teaches, Monday first.
+func lecturerTimetable(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+	lecturerID, ok := pathID(w, r, "lecturerid", regexLecturerID, "Lecturer ID")
+	if !ok {
+		return
+	}
+
+	sessions, err := database.GetLecturerTimetable(r.Context(), db, lecturerID)
+	if err != nil {
+		writeDBError(w, r, err)
+		return
+	}
+	writeResponse(w, r, http.StatusOK, &sessions)
+}
+
+//courseSessions lists the weekly sessions of a course, or schedules one more.
+//A session that double-books its room or the lecturer of the course, or a room too small for the
+//course, is refused with 409.
+func courseSessions(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+	courseID, ok := pathID(w, r, "courseid", regexCourseID, "Course ID")
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		sessions, err := database.GetCourseTimetable(r.Context(), db, courseID)
+		if err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		writeResponse(w, r, http.StatusOK, &sessions)
+
+	case http.MethodPost:
+		var newSession database.Session
+		if !decodeRequest(w, r, &newSession) {
+			return
+		}
+		if newSession.SessionID != 0 || (newSession.CourseID != "" && newSession.CourseID != courseID) {
+			writeJSONError(w, r, http.StatusUnprocessableEntity, "422 - SessionID is assigned by the server and CourseID must match the URL")
+			log.Warning("Fail attempt to schedule session: 422 - SessionID is assigned by the server and CourseID must match the URL")
+			return
+		}
+		newSession.CourseID = courseID
+		if err := validateSession(&newSession); err != nil {
+			writeJSONError(w, r, http.StatusUnprocessableEntity, "422 - "+err.Error())
+			log.Warning("Fail attempt to schedule session: 422 - ", err)
+			return
+		}
+
+		// the course, its lecturer and the room are locked while the clashes are checked
+		saved, err := database.InsertSession(r.Context(), db, newSession)
+		if err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		writeResponse(w, r, http.StatusCreated, &saved)
+	}
+}
+
+//session gets, moves or cancels one weekly session of a course. PUT is checked for clashes like a new session.
+func session(w http.ResponseWriter, r *http.Request) {
+
+	if !validKey(w, r) {
+		return
+	}
+	courseID, ok := pathID(w, r, "courseid", regexCourseID, "Course ID")
+	if !ok {
+		return
+	}
+	id, ok := pathID(w, r, "sessionid", regexSessionID, "Session ID")
+	if !ok {
+		return
+	}
+	sessionID, _ := strconv.Atoi(id)
+
+	switch r.Method {
+	case http.MethodGet:
+		session, err := database.GetSession(r.Context(), db, courseID, sessionID)
+		if err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		writeResponse(w, r, http.StatusOK, &session)
+
+	case http.MethodPut:
+		var newSession database.Session
+		if !decodeRequest(w, r, &newSession) {
+			return
+		}
+		if (newSession.SessionID != 0 && newSession.SessionID != sessionID) ||
+			(newSession.CourseID != "" && newSession.CourseID != courseID) {
+			writeJSONError(w, r, http.StatusUnprocessableEntity, "422 - SessionID or CourseID in the body does not match the URL")
+			log.Warning("Fail attempt to save session: 422 - SessionID or CourseID in the body does not match the URL")
+			return
+		}
+		newSession.SessionID, newSession.CourseID = sessionID, courseID
+		if err := validateSession(&newSession); err != nil {
+			writeJSONError(w, r, http.StatusUnprocessableEntity, "422 - "+err.Error())
+			log.Warning("Fail attempt to save session: 422 - ", err)
+			return
+		}
+
+		if err := database.UpdateSession(r.Context(), db, newSession); err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		writeResponse(w, r, http.StatusOK, &newSession)
+
+	case http.MethodDelete:
+		if err := database.DeleteSession(r.Context(), db, courseID, sessionID); err != nil {
+			writeDBError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -2,41 +2,103 @@ package main
 
 import (
 	"bufio"
-	"bytes"
+	"context"
 	"crypto/tls"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"regexp"
 	"strconv"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
+
+	"goMicroService1Assignment/RESTAPI/client"
 )
 
-var client = &http.Client{
-	Transport: &http.Transport{
-		TLSClientConfig: &tls.Config{RootCAs: loadCA("cert/ca.crt")},
-	},
+//api is the course API client shared by every operation. It is created in main once the configuration is loaded.
+var api *client.Client
+
+//newAPIClient returns a client for the configured server that trusts the certificate signed by the configured root CA.
+func newAPIClient(cfg config) *client.Client {
+	httpClient := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: loadCA(cfg.CAPath)},
+		},
+	}
+	c := client.New(cfg.ServerURL, cfg.APIKey, httpClient)
+	c.Timeout = cfg.RequestTimeout
+	c.Retries = cfg.RetryCount
+	return c
+}
+
+//reportError prints a failed API call in a form suitable for the user.
+func reportError(err error, caller string) {
+	fmt.Println(describeError(err))
+	log.Error("The HTTP request failed with error: ", err, " ", caller)
+}
+
+//describeError explains a failed API call to the user.
+func describeError(err error) string {
+	switch {
+	case errors.Is(err, client.ErrNotFound):
+		return ruleMessage(err, "No course found.")
+	case errors.Is(err, client.ErrConflict):
+		return ruleMessage(err, "The course ID already exists.")
+	case errors.Is(err, client.ErrUnauthorized):
+		return "The API key was rejected, please check the APIKEY of your profile or .env."
+	case errors.Is(err, client.ErrCircuitOpen):
+		return "The server keeps failing, so requests are paused for a while (" + err.Error() + ")."
+	case errors.Is(err, context.DeadlineExceeded):
+		return "The server did not answer in time, please try again later."
+	case errors.Is(err, client.ErrUnavailable):
+		return "The server is unavailable, please try again later (" + err.Error() + ")."
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return "Cannot reach the server, please check the serverURL of your profile (" + urlErr.Err.Error() + ")."
+	}
+	return err.Error()
 }
 
-const baseURL = "https://localhost:5000/api/v1/courses"
+//genericMessages are the server messages that do not say more than the kind of error.
+var genericMessages = map[string]bool{
+	"No course found":               true,
+	"Duplicate course ID":           true,
+	"Conflict with existing record": true,
+}
 
-//course structure that is stored in the database.
-type course struct {
-	CourseID  string
-	Title     string
-	Lecturer  string
-	ClassSize int
+//ruleMessage returns the server's explanation of a broken rule, such as a student enrolling twice,
+//or fallback when the server only named the kind of error.
+func ruleMessage(err error, fallback string) string {
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) {
+		return fallback
+	}
+	msg := apiErr.Message
+	if i := strings.Index(msg, " - "); i >= 0 {
+		msg = msg[i+3:]
+	}
+	if msg == "" || genericMessages[msg] {
+		return fallback
+	}
+	return strings.ToUpper(msg[:1]) + msg[1:] + "."
 }
 
 //regular expression pattern for user input.
 var (
 	regexCourseID      = regexp.MustCompile(`^[A-Z]{3}[0-9]{4}$`)
-	regexTitleLecturer = regexp.MustCompile(`^[\w\d\s]{3,30}$`) //same regex format can be used for course title and lecturer
+	regexTitleLecturer = regexp.MustCompile(`^[\w\d\s]{3,30}$`) //same regex format is used for lecturer and other names
+	regexCourseTitle   = regexp.MustCompile(`^[\w\d\s.,:()+/-]{3,100}$`)
 	regexClassSize     = regexp.MustCompile(`^[0-9]{1,4}$`)
+	regexStudentID     = regexp.MustCompile(`^S[0-9]{7}$`)
+	regexLecturerID    = regexp.MustCompile(`^L[0-9]{4}$`)
+	regexDepartmentID  = regexp.MustCompile(`^D[0-9]{3}$`)
+	regexSemesterID    = regexp.MustCompile(`^[0-9]{4}S[1-3]$`)
+	regexRoomID        = regexp.MustCompile(`^[A-Z0-9-]{2,10}$`)
+	regexTag           = regexp.MustCompile(`^[a-z0-9][a-z0-9.:-]{0,29}$`)
 )
 
 //addCourse take in all four required inputs  from user. Empty input is not allowed.
@@ -60,7 +122,7 @@ func addCourse() {
 		title = strings.TrimRight(title, "\n")
 	}
 	title = Policy.Sanitize(strings.TrimSpace(title)) // input validation and sanitization
-	if !regexTitleLecturer.MatchString(title) {
+	if !regexCourseTitle.MatchString(title) {
 		log.Error("Incorrect input format for Course Title detected. --addCourse")
 		return
 	}
@@ -93,47 +155,34 @@ func addCourse() {
 		return
 	}
 
-	jsonData := course{courseID, title, lecturer, classsizeInt}
-	fmt.Println(jsonData)
-	jsonValue, _ := json.Marshal(jsonData)
-	//response, err := http.Post(baseURL+"/"+courseID+"?key="+key, "application/json", bytes.NewBuffer(jsonValue))
-	response, err := client.Post(baseURL+"/"+courseID+"?key="+key, "application/json", bytes.NewBuffer(jsonValue))
-
-	if err != nil {
-		log.Error("The HTTP request failed with error %s\n", err, "--addCourse")
-	} else {
-		defer response.Body.Close()
-		data, _ := ioutil.ReadAll(response.Body)
-		fmt.Println(response.StatusCode)
-		fmt.Println(string(data))
+	newCourse := client.Course{CourseID: courseID, Title: title, Lecturer: lecturer, ClassSize: classsizeInt}
+	if err := api.CreateCourse(context.Background(), newCourse); err != nil {
+		reportError(err, "--addCourse")
+		return
 	}
+	fmt.Println("Course added:", courseID)
 }
 
-//getCourse take in course ID as input. If no course ID is specified, it will be show the user the whole list of course.
-//The user input was obtained upfront in console menu function.
-func getCourse(courseID string) []byte {
-
-	var url string
-	if courseID != "" {
-		url = baseURL + "/" + courseID + "?key=" + key
-	} else {
-		url = baseURL + "?key=" + key
+//getCourse retrieves and prints the course with the given ID. The user input was obtained upfront in console menu function.
+func getCourse(courseID string) (client.Course, bool) {
+	course, err := api.GetCourse(context.Background(), courseID)
+	if err != nil {
+		reportError(err, "--getCourse")
+		return course, false
 	}
+	writeCourse(os.Stdout, course)
+	return course, true
+}
 
-	response, err := client.Get(url)
-	//response, err := http.Get(url)
-	var data []byte
+//listCourses prints the whole list of courses.
+func listCourses() {
+	courses, err := api.ListCourses(context.Background())
 	if err != nil {
-		log.Error("The HTTP request failed with error: ", err, "  --getCourse")
-	} else {
-		defer response.Body.Close()
-		data, _ = ioutil.ReadAll(response.Body)
-		if courseID != "" {
-			fmt.Println(response.StatusCode)
-			fmt.Println(string(data))
-		}
+		reportError(err, "--listCourses")
+		return
 	}
-	return data
+	fmt.Println("\nBelow is the list of available course.")
+	writeCourses(os.Stdout, courses)
 }
 
 //updateCourse check with the user which field required to be updated,
@@ -141,7 +190,7 @@ func getCourse(courseID string) []byte {
 func updateCourse() {
 
 	var courseID, titleUpdated, lecturerUpdated, classsizeUpdated string
-	var jsonData course
+	var patch client.CoursePatch
 
 	fmt.Println("Please provide the course ID.")
 	fmt.Scanln(&courseID)
@@ -152,8 +201,9 @@ func updateCourse() {
 	}
 
 	//Retrieve course details once obtained courseID
-	data := getCourse(courseID)
-	json.Unmarshal(data, &jsonData)
+	if _, ok := getCourse(courseID); !ok {
+		return
+	}
 
 	fmt.Println("Please provide the course title. Please enter if there is no change.")
 	input := bufio.NewReader(os.Stdin)
@@ -161,11 +211,11 @@ func updateCourse() {
 	titleUpdated = strings.TrimRight(titleUpdated, "\n")
 	if titleUpdated != "" {
 		titleUpdated = Policy.Sanitize(strings.TrimSpace(titleUpdated)) // input validation and sanitization
-		if !regexTitleLecturer.MatchString(titleUpdated) {
+		if !regexCourseTitle.MatchString(titleUpdated) {
 			log.Error("Incorrect input format for Course Title detected. --updateCourse")
 			return
 		}
-		jsonData.Title = titleUpdated
+		patch.Title = &titleUpdated
 	}
 
 	fmt.Println("Please provide the lecturer name of the course.Please enter if there is no change.")
@@ -178,7 +228,7 @@ func updateCourse() {
 			log.Error("Incorrect input format for Course Lecturer detected. --updateCourse")
 			return
 		}
-		jsonData.Lecturer = lecturerUpdated
+		patch.Lecturer = &lecturerUpdated
 	}
 
 	fmt.Println("Please provide expected class size.Please enter if there is no change.")
@@ -194,27 +244,22 @@ func updateCourse() {
 			log.Error("Class Size must be greater than zero. --updateCourse")
 			return
 		}
-		jsonData.ClassSize = classsizeUpdatedInt
+		patch.ClassSize = &classsizeUpdatedInt
 	}
 
-	jsonValue, _ := json.Marshal(jsonData)
-	request, err := http.NewRequest(http.MethodPut, baseURL+"/"+courseID+"?key="+key, bytes.NewBuffer(jsonValue))
-	if err != nil {
-		log.Error("The HTTP request failed with error: ", err, "--updateCourse")
+	if patch == (client.CoursePatch{}) {
+		fmt.Println("Nothing to update.")
+		return
 	}
-	request.Header.Set("Content-Type", "application/json")
-
-	//client := &http.Client{}
-	response, err := client.Do(request)
 
+	//only the fields that were changed are sent
+	updated, err := api.PatchCourse(context.Background(), courseID, patch)
 	if err != nil {
-		log.Error("The HTTP request failed with error: ", err, "--updateCourse")
-	} else {
-		defer response.Body.Close()
-		data, _ := ioutil.ReadAll(response.Body)
-		fmt.Println(response.StatusCode)
-		fmt.Println(string(data))
+		reportError(err, "--updateCourse")
+		return
 	}
+	fmt.Println("Course updated.")
+	writeCourse(os.Stdout, updated)
 }
 
 //deleteCourse perform course deletion through the input of course ID by user.
@@ -229,18 +274,9 @@ func deleteCourse() {
 		return
 	}
 
-	request, err := http.NewRequest(http.MethodDelete, baseURL+"/"+courseID+"?key="+key, nil)
-	if err != nil {
-		log.Error("The HTTP request failed with error: ", err, "--deleteCourse")
-	}
-	//client := &http.Client{}
-	response, err := client.Do(request)
-	if err != nil {
-		log.Error("The HTTP request failed with error: ", err, "--deleteCourse")
-	} else {
-		data, _ := ioutil.ReadAll(response.Body)
-		fmt.Println(response.StatusCode)
-		fmt.Println(string(data))
-		response.Body.Close()
+	if err := api.DeleteCourse(context.Background(), courseID); err != nil {
+		reportError(err, "--deleteCourse")
+		return
 	}
+	fmt.Println("Course deleted:", courseID)
 }
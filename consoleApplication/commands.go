@@ -0,0 +1,915 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"goMicroService1Assignment/RESTAPI/client"
+)
+
+//Exit codes of the subcommands, so shell scripts can tell the failures apart.
+const (
+	exitOK           = 0
+	exitError        = 1 //any other failure
+	exitUsage        = 2 //bad command line or input rejected as invalid
+	exitNotFound     = 3
+	exitConflict     = 4
+	exitUnavailable  = 5 //server unreachable or failing
+	exitUnauthorized = 6
+)
+
+const commandsUsage = `
+Commands (the interactive menu starts when none is given):
+  tui    (full-screen course browser and editor)
+  courses list [--tag <tag>]...  (only the courses carrying every tag given)
+  courses get <course ID>
+  courses create --id <course ID> --title <title> --lecturer <lecturer> --size <class size>
+                 [--credits <credits>] [--status draft|published|archived] [--description <text>]
+  courses update <course ID> [--title <title>] [--lecturer <lecturer>] [--size <class size>]
+                 [--credits <credits>] [--status draft|published|archived] [--description <text>]
+  courses delete <course ID> [--force]  (--force also removes it from the prerequisites of other courses)
+  lecturers list
+  lecturers courses <lecturer ID>
+  departments list  (with the course ID prefixes each one owns)
+  departments courses <department ID>
+  departments lecturers <department ID>
+  departments stats <department ID>
+  semesters list
+  offerings list <semester ID>
+  offerings course <course ID>  (the semesters the course runs in)
+  offerings rollforward <semester ID> [--to <semester ID>]  (copies the offerings into the next semester)
+  rooms list
+  timetable course <course ID>  (the weekly sessions of the course)
+  timetable lecturer <lecturer ID>
+  timetable room <room ID>
+  enrollments list <course ID>  (enrolled students and the waitlist)
+  enrollments status <course ID> <student ID>
+  enrollments add <course ID> <student ID>  (the student joins the waitlist if the course is full)
+  enrollments drop <course ID> <student ID>
+  prerequisites list <course ID> [--transitive]  (in the order they must be taken)
+  prerequisites add <course ID> <prerequisite ID>
+  prerequisites remove <course ID> <prerequisite ID>
+  tags list  (every tag in use with its number of courses)
+  tags course <course ID>
+  tags add <course ID> <tag>
+  tags remove <course ID> <tag>
+
+Every command also accepts --output table|json|csv|yaml, --sort id|title|lecturer|size and --desc.
+Exit codes: 0 success, 1 error, 2 usage or invalid input, 3 not found, 4 conflict, 5 unavailable, 6 API key rejected.
+`
+
+//errUsage is returned for a malformed command line; the usage text has already been printed.
+var errUsage = errors.New("usage")
+
+//runCommand runs one non-interactive subcommand and returns the exit code of the program.
+//Results are written to stdout, errors and the log to stderr, so the output can be piped.
+func runCommand(args []string) int {
+	if args[0] == "tui" {
+		log.SetOutput(logFile) //the screen belongs to the UI, so the log only goes to the file
+		return exitCode(runTUI())
+	}
+	log.SetOutput(io.MultiWriter(logFile, os.Stderr))
+
+	commands, ok := commandGroups[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n%s", args[0], commandsUsage)
+		return exitUsage
+	}
+	if len(args) < 2 {
+		fmt.Fprint(os.Stderr, commandsUsage)
+		return exitUsage
+	}
+
+	switch args[1] {
+	case "help", "-h", "--help":
+		fmt.Print(commandsUsage)
+		return exitOK
+	}
+	command, ok := commands[args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command \"%s %s\"\n%s", args[0], args[1], commandsUsage)
+		return exitUsage
+	}
+	return exitCode(command(args[2:]))
+}
+
+//commandGroups maps each group of subcommands to its commands.
+var commandGroups = map[string]map[string]func(args []string) error{
+	"courses": {
+		"list":   listCommand,
+		"get":    getCommand,
+		"create": createCommand,
+		"update": updateCommand,
+		"delete": deleteCommand,
+	},
+	"lecturers": {
+		"list":    listLecturersCommand,
+		"courses": lecturerCoursesCommand,
+	},
+	"departments": {
+		"list":      listDepartmentsCommand,
+		"courses":   departmentCoursesCommand,
+		"lecturers": departmentLecturersCommand,
+		"stats":     departmentStatsCommand,
+	},
+	"semesters": {
+		"list": listSemestersCommand,
+	},
+	"offerings": {
+		"list":        listOfferingsCommand,
+		"course":      courseOfferingsCommand,
+		"rollforward": rollForwardCommand,
+	},
+	"rooms": {
+		"list": listRoomsCommand,
+	},
+	"timetable": {
+		"course":   courseTimetableCommand,
+		"lecturer": lecturerTimetableCommand,
+		"room":     roomTimetableCommand,
+	},
+	"enrollments": {
+		"list":   listEnrollmentsCommand,
+		"status": enrollmentStatusCommand,
+		"add":    enrollCommand,
+		"drop":   unenrollCommand,
+	},
+	"prerequisites": {
+		"list":   listPrerequisitesCommand,
+		"add":    addPrerequisiteCommand,
+		"remove": removePrerequisiteCommand,
+	},
+	"tags": {
+		"list":   listTagsCommand,
+		"course": courseTagsCommand,
+		"add":    addTagCommand,
+		"remove": removeTagCommand,
+	},
+}
+
+//exitCode maps the error of a subcommand to the exit code of the program, reporting it on stderr.
+func exitCode(err error) int {
+	switch {
+	case err == nil:
+		return exitOK
+	case err == flag.ErrHelp:
+		return exitOK
+	case err == errUsage:
+		return exitUsage
+	}
+
+	fmt.Fprintln(os.Stderr, "Error:", describeError(err))
+	switch {
+	case errors.Is(err, errInvalidInput), errors.Is(err, client.ErrInvalid):
+		return exitUsage
+	case errors.Is(err, client.ErrUnauthorized):
+		return exitUnauthorized
+	case errors.Is(err, client.ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, client.ErrConflict):
+		return exitConflict
+	case errors.Is(err, client.ErrUnavailable):
+		return exitUnavailable
+	}
+	var urlErr *url.Error //the request never got an answer from the server
+	if errors.As(err, &urlErr) {
+		return exitUnavailable
+	}
+	return exitError
+}
+
+//newCommandFlags returns the flag set of a subcommand. Flag errors are returned, not fatal.
+//The output flags may also be given after the subcommand, defaulting to the values given before it.
+func newCommandFlags(name, args string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.StringVar(&outputFormat, "output", outputFormat, "output format: "+strings.Join(outputFormats, ", "))
+	flags.StringVar(&sortBy, "sort", sortBy, "sort course lists by id, title, lecturer or size")
+	flags.BoolVar(&sortDesc, "desc", sortDesc, "sort in descending order")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s %s\n", name, args)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+//parseCommand parses the flags of a subcommand, allowing them before or after its positional arguments,
+//and checks that exactly want positional arguments were given.
+func parseCommand(flags *flag.FlagSet, args []string, want int) ([]string, error) {
+	positional := []string{}
+	for {
+		if err := flags.Parse(args); err != nil {
+			if err == flag.ErrHelp {
+				return nil, err
+			}
+			return nil, errUsage
+		}
+		if flags.NArg() == 0 {
+			break
+		}
+		positional = append(positional, flags.Arg(0))
+		args = flags.Args()[1:]
+	}
+	if err := checkOutput(); err != nil {
+		return nil, err
+	}
+	if len(positional) != want {
+		fmt.Fprintf(flags.Output(), "expected %d argument(s), got %d\n", want, len(positional))
+		flags.Usage()
+		return nil, errUsage
+	}
+	return positional, nil
+}
+
+//tagList collects a flag that may be repeated, such as --tag.
+type tagList []string
+
+func (t *tagList) String() string { return strings.Join(*t, ",") }
+
+func (t *tagList) Set(v string) error {
+	*t = append(*t, v)
+	return nil
+}
+
+func listCommand(args []string) error {
+	flags := newCommandFlags("courses list", "[--tag <tag>]...")
+	var tags tagList
+	flags.Var(&tags, "tag", "only list courses carrying this tag, may be repeated")
+	if _, err := parseCommand(flags, args, 0); err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		courses, err := api.ListCourses(context.Background())
+		if err != nil {
+			return err
+		}
+		return writeCourses(os.Stdout, courses)
+	}
+
+	for i, tag := range tags {
+		var err error
+		if tags[i], err = checkTag(tag); err != nil {
+			return err
+		}
+	}
+	search, err := api.FilterCourses(context.Background(), tags)
+	if err != nil {
+		return err
+	}
+	return writeCourses(os.Stdout, search.Courses)
+}
+
+func getCommand(args []string) error {
+	positional, err := parseCommand(newCommandFlags("courses get", "<course ID>"), args, 1)
+	if err != nil {
+		return err
+	}
+	courseID, err := checkCourseID(positional[0])
+	if err != nil {
+		return err
+	}
+	course, err := api.GetCourse(context.Background(), courseID)
+	if err != nil {
+		return err
+	}
+	return writeCourse(os.Stdout, course)
+}
+
+func createCommand(args []string) error {
+	flags := newCommandFlags("courses create", "--id <course ID> --title <title> --lecturer <lecturer> --size <class size>")
+	id := flags.String("id", "", "course ID, e.g. GOS1000")
+	title := flags.String("title", "", "course title")
+	lecturer := flags.String("lecturer", "", "lecturer name")
+	size := flags.String("size", "", "expected class size")
+	credits := flags.String("credits", "0", "credit units")
+	status := flags.String("status", "", "draft, published or archived, draft if not given")
+	description := flags.String("description", "", "long description, may contain simple HTML")
+	if _, err := parseCommand(flags, args, 0); err != nil {
+		return err
+	}
+
+	var course client.Course
+	var err error
+	if course.CourseID, err = checkCourseID(*id); err != nil {
+		return err
+	}
+	if course.Title, err = checkTitle(*title); err != nil {
+		return err
+	}
+	if course.Lecturer, err = checkLecturer(*lecturer); err != nil {
+		return err
+	}
+	if course.ClassSize, err = checkClassSize(*size); err != nil {
+		return err
+	}
+	if course.Credits, err = checkCredits(*credits); err != nil {
+		return err
+	}
+	if *status != "" {
+		if course.Status, err = checkStatus(*status); err != nil {
+			return err
+		}
+	}
+	course.Description = Policy.Sanitize(strings.TrimSpace(*description))
+
+	if err := api.CreateCourse(context.Background(), course); err != nil {
+		return err
+	}
+	fmt.Println("Course added:", course.CourseID)
+	return nil
+}
+
+func updateCommand(args []string) error {
+	flags := newCommandFlags("courses update", "<course ID> [--title <title>] [--lecturer <lecturer>] [--size <class size>]")
+	title := flags.String("title", "", "new course title")
+	lecturer := flags.String("lecturer", "", "new lecturer name")
+	size := flags.String("size", "", "new class size")
+	credits := flags.String("credits", "", "new credit units")
+	status := flags.String("status", "", "new status: draft, published or archived")
+	description := flags.String("description", "", "new long description, may contain simple HTML")
+	positional, err := parseCommand(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	courseID, err := checkCourseID(positional[0])
+	if err != nil {
+		return err
+	}
+
+	//only the flags that were given are sent
+	var patch client.CoursePatch
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if set["title"] {
+		v, err := checkTitle(*title)
+		if err != nil {
+			return err
+		}
+		patch.Title = &v
+	}
+	if set["lecturer"] {
+		v, err := checkLecturer(*lecturer)
+		if err != nil {
+			return err
+		}
+		patch.Lecturer = &v
+	}
+	if set["size"] {
+		v, err := checkClassSize(*size)
+		if err != nil {
+			return err
+		}
+		patch.ClassSize = &v
+	}
+	if set["credits"] {
+		v, err := checkCredits(*credits)
+		if err != nil {
+			return err
+		}
+		patch.Credits = &v
+	}
+	if set["status"] {
+		v, err := checkStatus(*status)
+		if err != nil {
+			return err
+		}
+		patch.Status = &v
+	}
+	if set["description"] {
+		v := Policy.Sanitize(strings.TrimSpace(*description))
+		patch.Description = &v
+	}
+	if patch == (client.CoursePatch{}) {
+		fmt.Fprintln(flags.Output(), "nothing to update, give at least one of --title, --lecturer, --size, --credits, --status or --description")
+		flags.Usage()
+		return errUsage
+	}
+
+	updated, err := api.PatchCourse(context.Background(), courseID, patch)
+	if err != nil {
+		return err
+	}
+	return writeCourse(os.Stdout, updated)
+}
+
+func deleteCommand(args []string) error {
+	flags := newCommandFlags("courses delete", "<course ID> [--force]")
+	force := flags.Bool("force", false, "also remove the course from the prerequisites of other courses")
+	positional, err := parseCommand(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	courseID, err := checkCourseID(positional[0])
+	if err != nil {
+		return err
+	}
+	if *force {
+		err = api.ForceDeleteCourse(context.Background(), courseID)
+	} else {
+		err = api.DeleteCourse(context.Background(), courseID)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println("Course deleted:", courseID)
+	return nil
+}
+
+func listLecturersCommand(args []string) error {
+	if _, err := parseCommand(newCommandFlags("lecturers list", ""), args, 0); err != nil {
+		return err
+	}
+	lecturers, err := api.ListLecturers(context.Background())
+	if err != nil {
+		return err
+	}
+	return writeLecturers(os.Stdout, lecturers)
+}
+
+func lecturerCoursesCommand(args []string) error {
+	positional, err := parseCommand(newCommandFlags("lecturers courses", "<lecturer ID>"), args, 1)
+	if err != nil {
+		return err
+	}
+	lecturerID := Policy.Sanitize(strings.TrimSpace(positional[0]))
+	if !regexLecturerID.MatchString(lecturerID) {
+		return fmt.Errorf("%w: lecturer ID %q must be L followed by four digits", errInvalidInput, lecturerID)
+	}
+	courses, err := api.LecturerCourses(context.Background(), lecturerID)
+	if err != nil {
+		return err
+	}
+	return writeCourses(os.Stdout, courses)
+}
+
+func listDepartmentsCommand(args []string) error {
+	if _, err := parseCommand(newCommandFlags("departments list", ""), args, 0); err != nil {
+		return err
+	}
+	departments, err := api.ListDepartments(context.Background())
+	if err != nil {
+		return err
+	}
+	return writeDepartments(os.Stdout, departments)
+}
+
+func departmentCoursesCommand(args []string) error {
+	departmentID, err := parseDepartmentCommand("courses", args)
+	if err != nil {
+		return err
+	}
+	courses, err := api.DepartmentCourses(context.Background(), departmentID)
+	if err != nil {
+		return err
+	}
+	return writeCourses(os.Stdout, courses)
+}
+
+func departmentLecturersCommand(args []string) error {
+	departmentID, err := parseDepartmentCommand("lecturers", args)
+	if err != nil {
+		return err
+	}
+	lecturers, err := api.DepartmentLecturers(context.Background(), departmentID)
+	if err != nil {
+		return err
+	}
+	return writeLecturers(os.Stdout, lecturers)
+}
+
+func departmentStatsCommand(args []string) error {
+	departmentID, err := parseDepartmentCommand("stats", args)
+	if err != nil {
+		return err
+	}
+	stats, err := api.DepartmentStats(context.Background(), departmentID)
+	if err != nil {
+		return err
+	}
+	return writeDepartmentStats(os.Stdout, stats)
+}
+
+//parseDepartmentCommand parses the department ID of a department subcommand.
+func parseDepartmentCommand(name string, args []string) (string, error) {
+	positional, err := parseCommand(newCommandFlags("departments "+name, "<department ID>"), args, 1)
+	if err != nil {
+		return "", err
+	}
+	departmentID := Policy.Sanitize(strings.TrimSpace(positional[0]))
+	if !regexDepartmentID.MatchString(departmentID) {
+		return "", fmt.Errorf("%w: department ID %q must be D followed by three digits", errInvalidInput, departmentID)
+	}
+	return departmentID, nil
+}
+
+func listSemestersCommand(args []string) error {
+	if _, err := parseCommand(newCommandFlags("semesters list", ""), args, 0); err != nil {
+		return err
+	}
+	semesters, err := api.ListSemesters(context.Background())
+	if err != nil {
+		return err
+	}
+	return writeSemesters(os.Stdout, semesters)
+}
+
+func listOfferingsCommand(args []string) error {
+	positional, err := parseCommand(newCommandFlags("offerings list", "<semester ID>"), args, 1)
+	if err != nil {
+		return err
+	}
+	semesterID, err := checkSemesterID(positional[0])
+	if err != nil {
+		return err
+	}
+	offerings, err := api.SemesterOfferings(context.Background(), semesterID)
+	if err != nil {
+		return err
+	}
+	return writeOfferings(os.Stdout, offerings)
+}
+
+func courseOfferingsCommand(args []string) error {
+	positional, err := parseCommand(newCommandFlags("offerings course", "<course ID>"), args, 1)
+	if err != nil {
+		return err
+	}
+	courseID, err := checkCourseID(positional[0])
+	if err != nil {
+		return err
+	}
+	offerings, err := api.CourseOfferings(context.Background(), courseID)
+	if err != nil {
+		return err
+	}
+	return writeOfferings(os.Stdout, offerings)
+}
+
+func rollForwardCommand(args []string) error {
+	flags := newCommandFlags("offerings rollforward", "<semester ID> [--to <semester ID>]")
+	to := flags.String("to", "", "semester to copy into, the next one by start date if not given")
+	positional, err := parseCommand(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	semesterID, err := checkSemesterID(positional[0])
+	if err != nil {
+		return err
+	}
+	if *to != "" {
+		if *to, err = checkSemesterID(*to); err != nil {
+			return err
+		}
+	}
+	result, err := api.RollForwardOfferings(context.Background(), semesterID, *to)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Offerings of %s rolled forward into %s: %d copied, %d skipped\n", result.From, result.To, len(result.Copied), len(result.Skipped))
+	if len(result.Skipped) > 0 {
+		fmt.Println("Already offered:", strings.Join(result.Skipped, ", "))
+	}
+	return nil
+}
+
+func listRoomsCommand(args []string) error {
+	if _, err := parseCommand(newCommandFlags("rooms list", ""), args, 0); err != nil {
+		return err
+	}
+	rooms, err := api.ListRooms(context.Background())
+	if err != nil {
+		return err
+	}
+	return writeRooms(os.Stdout, rooms)
+}
+
+func courseTimetableCommand(args []string) error {
+	positional, err := parseCommand(newCommandFlags("timetable course", "<course ID>"), args, 1)
+	if err != nil {
+		return err
+	}
+	courseID, err := checkCourseID(positional[0])
+	if err != nil {
+		return err
+	}
+	sessions, err := api.CourseTimetable(context.Background(), courseID)
+	if err != nil {
+		return err
+	}
+	return writeSessions(os.Stdout, sessions)
+}
+
+func lecturerTimetableCommand(args []string) error {
+	positional, err := parseCommand(newCommandFlags("timetable lecturer", "<lecturer ID>"), args, 1)
+	if err != nil {
+		return err
+	}
+	lecturerID := Policy.Sanitize(strings.TrimSpace(positional[0]))
+	if !regexLecturerID.MatchString(lecturerID) {
+		return fmt.Errorf("%w: lecturer ID %q must be L followed by four digits", errInvalidInput, lecturerID)
+	}
+	sessions, err := api.LecturerTimetable(context.Background(), lecturerID)
+	if err != nil {
+		return err
+	}
+	return writeSessions(os.Stdout, sessions)
+}
+
+func roomTimetableCommand(args []string) error {
+	positional, err := parseCommand(newCommandFlags("timetable room", "<room ID>"), args, 1)
+	if err != nil {
+		return err
+	}
+	roomID := Policy.Sanitize(strings.TrimSpace(positional[0]))
+	if !regexRoomID.MatchString(roomID) {
+		return fmt.Errorf("%w: room ID %q must be 2 to 10 capital letters, digits or dashes", errInvalidInput, roomID)
+	}
+	sessions, err := api.RoomTimetable(context.Background(), roomID)
+	if err != nil {
+		return err
+	}
+	return writeSessions(os.Stdout, sessions)
+}
+
+func listEnrollmentsCommand(args []string) error {
+	positional, err := parseCommand(newCommandFlags("enrollments list", "<course ID>"), args, 1)
+	if err != nil {
+		return err
+	}
+	courseID, err := checkCourseID(positional[0])
+	if err != nil {
+		return err
+	}
+	list, err := api.ListEnrollments(context.Background(), courseID)
+	if err != nil {
+		return err
+	}
+	return writeEnrollments(os.Stdout, list)
+}
+
+func enrollmentStatusCommand(args []string) error {
+	courseID, studentID, err := parseEnrollmentCommand("status", args)
+	if err != nil {
+		return err
+	}
+	enrollment, err := api.GetEnrollment(context.Background(), courseID, studentID)
+	if err != nil {
+		return err
+	}
+	return writeEnrollment(os.Stdout, enrollment)
+}
+
+func enrollCommand(args []string) error {
+	courseID, studentID, err := parseEnrollmentCommand("add", args)
+	if err != nil {
+		return err
+	}
+	enrollment, err := api.Enroll(context.Background(), courseID, studentID)
+	if err != nil {
+		return err
+	}
+	return writeEnrollment(os.Stdout, enrollment)
+}
+
+func unenrollCommand(args []string) error {
+	courseID, studentID, err := parseEnrollmentCommand("drop", args)
+	if err != nil {
+		return err
+	}
+	if err := api.Unenroll(context.Background(), courseID, studentID); err != nil {
+		return err
+	}
+	fmt.Println("Student", studentID, "dropped from", courseID)
+	return nil
+}
+
+func listPrerequisitesCommand(args []string) error {
+	flags := newCommandFlags("prerequisites list", "<course ID> [--transitive]")
+	transitive := flags.Bool("transitive", false, "also list the prerequisites of the prerequisites")
+	positional, err := parseCommand(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	courseID, err := checkCourseID(positional[0])
+	if err != nil {
+		return err
+	}
+	courses, err := api.Prerequisites(context.Background(), courseID, *transitive)
+	if err != nil {
+		return err
+	}
+	return writeCourses(os.Stdout, courses)
+}
+
+func addPrerequisiteCommand(args []string) error {
+	courseID, prereqID, err := parsePrerequisiteCommand("add", args)
+	if err != nil {
+		return err
+	}
+	if err := api.AddPrerequisite(context.Background(), courseID, prereqID); err != nil {
+		return err
+	}
+	fmt.Println("Prerequisite added:", prereqID, "before", courseID)
+	return nil
+}
+
+func removePrerequisiteCommand(args []string) error {
+	courseID, prereqID, err := parsePrerequisiteCommand("remove", args)
+	if err != nil {
+		return err
+	}
+	if err := api.RemovePrerequisite(context.Background(), courseID, prereqID); err != nil {
+		return err
+	}
+	fmt.Println("Prerequisite removed:", prereqID, "from", courseID)
+	return nil
+}
+
+//parsePrerequisiteCommand parses the course ID and prerequisite ID of a prerequisite subcommand.
+func parsePrerequisiteCommand(name string, args []string) (courseID, prereqID string, err error) {
+	positional, err := parseCommand(newCommandFlags("prerequisites "+name, "<course ID> <prerequisite ID>"), args, 2)
+	if err != nil {
+		return "", "", err
+	}
+	if courseID, err = checkCourseID(positional[0]); err != nil {
+		return "", "", err
+	}
+	if prereqID, err = checkCourseID(positional[1]); err != nil {
+		return "", "", err
+	}
+	return courseID, prereqID, nil
+}
+
+func listTagsCommand(args []string) error {
+	if _, err := parseCommand(newCommandFlags("tags list", ""), args, 0); err != nil {
+		return err
+	}
+	tags, err := api.ListTags(context.Background())
+	if err != nil {
+		return err
+	}
+	return writeTags(os.Stdout, tags)
+}
+
+func courseTagsCommand(args []string) error {
+	positional, err := parseCommand(newCommandFlags("tags course", "<course ID>"), args, 1)
+	if err != nil {
+		return err
+	}
+	courseID, err := checkCourseID(positional[0])
+	if err != nil {
+		return err
+	}
+	tags, err := api.CourseTags(context.Background(), courseID)
+	if err != nil {
+		return err
+	}
+	return writeCourseTags(os.Stdout, tags)
+}
+
+func addTagCommand(args []string) error {
+	courseID, tag, err := parseTagCommand("add", args)
+	if err != nil {
+		return err
+	}
+	if err := api.AddTag(context.Background(), courseID, tag); err != nil {
+		return err
+	}
+	fmt.Println("Course", courseID, "tagged", tag)
+	return nil
+}
+
+func removeTagCommand(args []string) error {
+	courseID, tag, err := parseTagCommand("remove", args)
+	if err != nil {
+		return err
+	}
+	if err := api.RemoveTag(context.Background(), courseID, tag); err != nil {
+		return err
+	}
+	fmt.Println("Tag", tag, "removed from", courseID)
+	return nil
+}
+
+//parseTagCommand parses the course ID and tag of a tag subcommand.
+func parseTagCommand(name string, args []string) (courseID, tag string, err error) {
+	positional, err := parseCommand(newCommandFlags("tags "+name, "<course ID> <tag>"), args, 2)
+	if err != nil {
+		return "", "", err
+	}
+	if courseID, err = checkCourseID(positional[0]); err != nil {
+		return "", "", err
+	}
+	if tag, err = checkTag(positional[1]); err != nil {
+		return "", "", err
+	}
+	return courseID, tag, nil
+}
+
+//parseEnrollmentCommand parses the course ID and student ID of an enrolment subcommand.
+func parseEnrollmentCommand(name string, args []string) (courseID, studentID string, err error) {
+	positional, err := parseCommand(newCommandFlags("enrollments "+name, "<course ID> <student ID>"), args, 2)
+	if err != nil {
+		return "", "", err
+	}
+	if courseID, err = checkCourseID(positional[0]); err != nil {
+		return "", "", err
+	}
+	if studentID, err = checkStudentID(positional[1]); err != nil {
+		return "", "", err
+	}
+	return courseID, studentID, nil
+}
+
+//errInvalidInput is wrapped by the input checks below.
+var errInvalidInput = errors.New("invalid input")
+
+//checkCourseID sanitizes and validates a course ID given on the command line.
+func checkCourseID(v string) (string, error) {
+	v = Policy.Sanitize(strings.TrimSpace(v))
+	if !regexCourseID.MatchString(v) {
+		return "", fmt.Errorf("%w: course ID %q must be three capital letters followed by four digits", errInvalidInput, v)
+	}
+	return v, nil
+}
+
+//checkSemesterID sanitizes and validates a semester ID given on the command line.
+func checkSemesterID(v string) (string, error) {
+	v = Policy.Sanitize(strings.TrimSpace(v))
+	if !regexSemesterID.MatchString(v) {
+		return "", fmt.Errorf("%w: semester ID %q must be a year followed by S1, S2 or S3, e.g. 2024S1", errInvalidInput, v)
+	}
+	return v, nil
+}
+
+//checkTag sanitizes, lower-cases and validates a tag given on the command line.
+func checkTag(v string) (string, error) {
+	v = strings.ToLower(Policy.Sanitize(strings.TrimSpace(v)))
+	if !regexTag.MatchString(v) {
+		return "", fmt.Errorf("%w: tag %q must be up to 30 letters, digits, dots, colons or dashes, e.g. level:beginner", errInvalidInput, v)
+	}
+	return v, nil
+}
+
+//checkStudentID sanitizes and validates a student ID given on the command line.
+func checkStudentID(v string) (string, error) {
+	v = Policy.Sanitize(strings.TrimSpace(v))
+	if !regexStudentID.MatchString(v) {
+		return "", fmt.Errorf("%w: student ID %q must be S followed by seven digits", errInvalidInput, v)
+	}
+	return v, nil
+}
+
+//checkTitle sanitizes and validates a course title given on the command line.
+func checkTitle(v string) (string, error) {
+	v = Policy.Sanitize(strings.TrimSpace(v))
+	if !regexCourseTitle.MatchString(v) {
+		return "", fmt.Errorf("%w: title %q must be 3 to 100 letters, digits, spaces or .,:()+/-", errInvalidInput, v)
+	}
+	return v, nil
+}
+
+//checkLecturer sanitizes and validates a lecturer name given on the command line.
+func checkLecturer(v string) (string, error) {
+	v = Policy.Sanitize(strings.TrimSpace(v))
+	if !regexTitleLecturer.MatchString(v) {
+		return "", fmt.Errorf("%w: lecturer %q must be 3 to 30 letters, digits or spaces", errInvalidInput, v)
+	}
+	return v, nil
+}
+
+//checkCredits validates the credit units given on the command line.
+func checkCredits(v string) (int, error) {
+	credits, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil || credits < 0 || credits > 30 {
+		return 0, fmt.Errorf("%w: credits %q must be a whole number from 0 to 30", errInvalidInput, v)
+	}
+	return credits, nil
+}
+
+//checkStatus validates a course status given on the command line.
+func checkStatus(v string) (string, error) {
+	v = strings.ToLower(strings.TrimSpace(v))
+	if v != "draft" && v != "published" && v != "archived" {
+		return "", fmt.Errorf("%w: status %q must be draft, published or archived", errInvalidInput, v)
+	}
+	return v, nil
+}
+
+//checkClassSize validates a class size given on the command line.
+func checkClassSize(v string) (int, error) {
+	v = strings.TrimSpace(v)
+	size, _ := strconv.Atoi(v)
+	if !regexClassSize.MatchString(v) || size <= 0 {
+		return 0, fmt.Errorf("%w: class size %q must be a number from 1 to 9999", errInvalidInput, v)
+	}
+	return size, nil
+}
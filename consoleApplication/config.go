@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Built-in settings used when neither a flag, the environment nor the profile provides one.
+const (
+	defaultServerURL  = "https://localhost:5000"
+	defaultCAPath     = "cert/ca.crt"
+	defaultConfigPath = "config.json"
+	defaultProfile    = "dev"
+	defaultTimeout    = "10s"
+	defaultRetries    = "2"
+)
+
+//profile holds the settings needed to reach one deployment of the course API.
+type profile struct {
+	ServerURL string `json:"serverURL"`
+	CAPath    string `json:"caPath"`
+	APIKey    string `json:"APIKEY"`
+	Timeout   string `json:"timeout"` //limit on each request, e.g. 10s
+	Retries   string `json:"retries"` //extra attempts for requests that can be repeated safely
+}
+
+//configFile is the layout of config.json, see config.sample.json.
+type configFile struct {
+	DefaultProfile string             `json:"defaultProfile"`
+	Profiles       map[string]profile `json:"profiles"`
+}
+
+//config is the resolved console configuration.
+type config struct {
+	Profile string
+	profile
+	RequestTimeout time.Duration
+	RetryCount     int
+	Args           []string //command line arguments left after the flags
+}
+
+//loadConfig resolves the console settings from, in order of precedence, the command line flags,
+//the environment (including .env), the selected profile of the config file and the built-in defaults.
+//A profile chosen with --profile or the profile variable is the exception: its settings beat the
+//environment, so an APIKEY or serverURL left in .env for one server is never sent to another.
+func loadConfig(args []string) (config, error) {
+	loadDotEnv() //before the flags, whose defaults may come from .env
+
+	flags := flag.NewFlagSet("consoleApplication", flag.ContinueOnError)
+	configPath := flags.String("config", "", "path of the config file holding the profiles (env configPath, default "+defaultConfigPath+")")
+	profileName := flags.String("profile", "", "profile of the config file to use, e.g. dev, staging or prod (env profile)")
+	serverURL := flags.String("server", "", "scheme and host of the course API (env serverURL)")
+	caPath := flags.String("ca", "", "root CA certificate verifying the server (env caPath)")
+	apiKey := flags.String("key", "", "API key (env APIKEY)")
+	timeout := flags.String("timeout", "", "limit on each request, e.g. 10s (env requestTimeout, default "+defaultTimeout+")")
+	retries := flags.String("retries", "", "extra attempts for failed list, get, update and delete requests (env retries, default "+defaultRetries+")")
+	flags.StringVar(&outputFormat, "output", firstOf(os.Getenv("output"), outputFormat), "output format: "+strings.Join(outputFormats, ", ")+" (env output)")
+	flags.StringVar(&sortBy, "sort", "", "sort course lists by id, title, lecturer or size")
+	flags.BoolVar(&sortDesc, "desc", false, "sort in descending order")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: consoleApplication [flags] [command]")
+		flags.PrintDefaults()
+		fmt.Fprint(flags.Output(), commandsUsage)
+	}
+	if err := flags.Parse(args); err != nil {
+		return config{}, err
+	}
+	if err := checkOutput(); err != nil {
+		return config{}, err
+	}
+
+	path := firstOf(*configPath, os.Getenv("configPath"))
+	file, err := readConfigFile(firstOf(path, defaultConfigPath), path != "")
+	if err != nil {
+		return config{}, err
+	}
+
+	cfg := config{Args: flags.Args()}
+	requested := firstOf(*profileName, os.Getenv("profile"))
+	cfg.Profile = firstOf(requested, file.DefaultProfile, defaultProfile)
+	selected, ok := file.Profiles[cfg.Profile]
+	if !ok && len(file.Profiles) == 0 && requested != "" {
+		return config{}, fmt.Errorf("profile %q was requested but no config file defines any profiles", cfg.Profile)
+	}
+	if !ok && len(file.Profiles) > 0 {
+		return config{}, fmt.Errorf("profile %q is not in the config file, choose one of %s", cfg.Profile, strings.Join(file.names(), ", "))
+	}
+
+	// flag, then profile or environment depending on whether the profile was chosen, then the default
+	setting := func(flagValue, env, profileValue, def string) string {
+		if requested != "" {
+			return firstOf(flagValue, profileValue, os.Getenv(env), def)
+		}
+		return firstOf(flagValue, os.Getenv(env), profileValue, def)
+	}
+	cfg.ServerURL = setting(*serverURL, "serverURL", selected.ServerURL, defaultServerURL)
+	cfg.CAPath = setting(*caPath, "caPath", selected.CAPath, defaultCAPath)
+	cfg.APIKey = setting(*apiKey, "APIKEY", selected.APIKey, "")
+	cfg.Timeout = setting(*timeout, "requestTimeout", selected.Timeout, defaultTimeout)
+	cfg.Retries = setting(*retries, "retries", selected.Retries, defaultRetries)
+
+	if cfg.RequestTimeout, err = time.ParseDuration(cfg.Timeout); err != nil || cfg.RequestTimeout <= 0 {
+		return config{}, fmt.Errorf("timeout %q must be a positive duration such as 10s", cfg.Timeout)
+	}
+	if cfg.RetryCount, err = strconv.Atoi(cfg.Retries); err != nil || cfg.RetryCount < 0 {
+		return config{}, fmt.Errorf("retries %q must be a whole number of zero or more", cfg.Retries)
+	}
+	return cfg, nil
+}
+
+//readConfigFile reads the profiles from path. A missing file is only an error if it was asked for explicitly.
+func readConfigFile(path string, required bool) (configFile, error) {
+	var file configFile
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && !required {
+		return file, nil
+	}
+	if err != nil {
+		return file, err
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return file, fmt.Errorf("reading %s: %v", path, err)
+	}
+	return file, nil
+}
+
+//names lists the profiles of the file in alphabetical order.
+func (f configFile) names() []string {
+	names := []string{}
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//firstOf returns the first non-empty value.
+func firstOf(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"goMicroService1Assignment/RESTAPI/client"
+)
+
+//listEnrollments prints the seat count of a course, the students enrolled in it and its waitlist.
+func listEnrollments() {
+
+	courseID, ok := readCourseID("--listEnrollments")
+	if !ok {
+		return
+	}
+	list, err := api.ListEnrollments(context.Background(), courseID)
+	if err != nil {
+		reportError(err, "--listEnrollments")
+		return
+	}
+	writeEnrollments(os.Stdout, list)
+}
+
+//enrollStudent enrols a student in a course. The student is put on the waitlist if the course is full.
+func enrollStudent() {
+
+	courseID, studentID, ok := readEnrollment("--enrollStudent")
+	if !ok {
+		return
+	}
+	enrollment, err := api.Enroll(context.Background(), courseID, studentID)
+	if err != nil {
+		reportError(err, "--enrollStudent")
+		return
+	}
+	if enrollment.Status == client.StatusWaitlisted {
+		fmt.Printf("The course is full, %s is number %d on the waitlist.\n", studentID, enrollment.Position)
+		return
+	}
+	fmt.Println("Student", studentID, "enrolled in", courseID)
+}
+
+//dropStudent gives up the seat of a student, or takes the student off the waitlist.
+//The first student on the waitlist gets the seat.
+func dropStudent() {
+
+	courseID, studentID, ok := readEnrollment("--dropStudent")
+	if !ok {
+		return
+	}
+	if err := api.Unenroll(context.Background(), courseID, studentID); err != nil {
+		reportError(err, "--dropStudent")
+		return
+	}
+	fmt.Println("Student", studentID, "dropped from", courseID)
+}
+
+//readCourseID asks the user for a course ID. Empty input is not allowed.
+func readCourseID(caller string) (string, bool) {
+	var courseID string
+	for courseID == "" {
+		fmt.Println("Please provide the course ID.")
+		fmt.Scanln(&courseID)
+	}
+	courseID = Policy.Sanitize(strings.TrimSpace(courseID)) // input validation and sanitization
+	if !regexCourseID.MatchString(courseID) {
+		log.Error("Incorrect input format for Course ID detected. ", caller)
+		return "", false
+	}
+	return courseID, true
+}
+
+//readEnrollment asks the user for a course ID and a student ID. Empty input is not allowed.
+func readEnrollment(caller string) (courseID, studentID string, ok bool) {
+	if courseID, ok = readCourseID(caller); !ok {
+		return "", "", false
+	}
+	for studentID == "" {
+		fmt.Println("Please provide the student ID.")
+		fmt.Scanln(&studentID)
+	}
+	studentID = Policy.Sanitize(strings.TrimSpace(studentID)) // input validation and sanitization
+	if !regexStudentID.MatchString(studentID) {
+		log.Error("Incorrect input format for Student ID detected. ", caller)
+		return "", "", false
+	}
+	return courseID, studentID, true
+}
@@ -6,4 +6,9 @@ require (
 	github.com/joho/godotenv v1.3.0
 	github.com/microcosm-cc/bluemonday v1.0.7
 	github.com/sirupsen/logrus v1.8.1
+	goMicroService1Assignment/RESTAPI v0.0.0
+	golang.org/x/term v0.0.0-20210422114643-f5beecf764ed
+	gopkg.in/yaml.v2 v2.4.0
 )
+
+replace goMicroService1Assignment/RESTAPI => ../RESTAPI
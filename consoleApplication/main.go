@@ -2,7 +2,7 @@ package main
 
 import (
 	"crypto/x509"
-	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -15,9 +15,10 @@ import (
 )
 
 var (
-	key string
 	//Unique policy creation for the life of the program.
 	Policy = bluemonday.UGCPolicy()
+	//logFile is kept so the subcommands can send the console copy of the log to stderr instead of stdout.
+	logFile io.Writer
 )
 
 func init() {
@@ -27,6 +28,7 @@ func init() {
 	if err != nil {
 		log.Fatal("Error opening log file: ", err)
 	} else {
+		logFile = file
 		log.SetOutput(io.MultiWriter(file, os.Stdout)) //default logger will be writing to file and os.Stdout
 	}
 	Formatter := new(log.TextFormatter)
@@ -50,7 +52,17 @@ func loadCA(caFile string) *x509.CertPool {
 
 func main() {
 
-	key = goDotEnvVariable("APIKEY") //obtain API key from the environment variable file.
+	cfg, err := loadConfig(os.Args[1:])
+	if err == flag.ErrHelp {
+		return
+	}
+	if err != nil {
+		log.Fatal("Error loading configuration: ", err)
+	}
+	api = newAPIClient(cfg)
+	if len(cfg.Args) > 0 { //a subcommand was given, run it instead of the interactive menu
+		os.Exit(runCommand(cfg.Args))
+	}
 	consoleMenu()
 
 }
@@ -66,7 +78,7 @@ func consoleMenu() {
 		}
 	}()
 
-	for choice != 6 {
+	for choice != 9 {
 
 		fmt.Println("\n=================================================")
 		fmt.Println("University Course Listing Page (Lecturer Access)")
@@ -76,7 +88,10 @@ func consoleMenu() {
 		fmt.Println("3. Browse all course")
 		fmt.Println("4. Edit existing course")
 		fmt.Println("5. Delete existing course")
-		fmt.Println("6. Exit the course listing page")
+		fmt.Println("6. Browse enrolments and waitlist of a course")
+		fmt.Println("7. Enrol a student in a course")
+		fmt.Println("8. Drop a student from a course")
+		fmt.Println("9. Exit the course listing page")
 		fmt.Println("Select your choice: ")
 		fmt.Scanln(&choice)
 
@@ -89,45 +104,38 @@ func consoleMenu() {
 				fmt.Println("Please provide the course ID you wish to browse.")
 				fmt.Scanln(&courseID)
 			}
-			getCourse(courseID)
 			courseID = Policy.Sanitize(strings.TrimSpace(courseID))
 			if !regexCourseID.MatchString(courseID) {
 				log.Warning("Incorrect input format for Course ID detected. --getCourse")
-				return
+				break
 			}
+			getCourse(courseID)
 		case 3:
-			var jsonData []interface{}
-			data := getCourse("")
-			json.Unmarshal(data, &jsonData)
-			fmt.Println("\nBelow is the list of available course.")
-			for i, v := range jsonData {
-				fmt.Printf("%d. Course ID: %s, Title: %s, Lecturer: %s, Class Size: %v \n",
-					i+1, v.(map[string]interface{})["CourseID"], v.(map[string]interface{})["Title"],
-					v.(map[string]interface{})["Lecturer"], v.(map[string]interface{})["ClassSize"])
-			}
+			listCourses()
 		case 4:
 			updateCourse()
 		case 5:
 			deleteCourse()
 		case 6:
+			listEnrollments()
+		case 7:
+			enrollStudent()
+		case 8:
+			dropStudent()
+		case 9:
 			fmt.Println("Exiting the booking system")
 		default:
-			fmt.Println("Please select 1 to 6.")
+			fmt.Println("Please select 1 to 9.")
 		}
 
 	}
 
 }
 
-//goDotEnvVariable use godot package to load/read the .env file and return the value of the key
-func goDotEnvVariable(key string) string {
-	// load .env file
-	err := godotenv.Load(".env")
-
-	if err != nil {
-		log.Fatal("Error loading .env file")
+//loadDotEnv use godot package to load the .env file into the environment. The file is optional now that
+//the settings may also come from flags or the config file, and variables already set are not overridden.
+func loadDotEnv() {
+	if err := godotenv.Load(".env"); err != nil && !os.IsNotExist(err) {
+		log.Fatal("Error loading .env file: ", err)
 	}
-
-	return os.Getenv(key)
-
 }
@@ -0,0 +1,446 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"goMicroService1Assignment/RESTAPI/client"
+)
+
+//Output settings, set by the --output, --sort and --desc flags.
+var (
+	outputFormat = "table"
+	sortBy       string //empty keeps the order of the server
+	sortDesc     bool
+)
+
+//outputFormats lists the values accepted by --output.
+var outputFormats = []string{"table", "json", "csv", "yaml"}
+
+//courseKeys maps the values accepted by --sort to the ordering of courses on that field.
+var courseKeys = map[string]func(a, b client.Course) bool{
+	"id":       func(a, b client.Course) bool { return a.CourseID < b.CourseID },
+	"title":    func(a, b client.Course) bool { return strings.ToLower(a.Title) < strings.ToLower(b.Title) },
+	"lecturer": func(a, b client.Course) bool { return strings.ToLower(a.Lecturer) < strings.ToLower(b.Lecturer) },
+	"size":     func(a, b client.Course) bool { return a.ClassSize < b.ClassSize },
+}
+
+//csvColumns are the CSV columns, the same as the server's CSV export.
+var csvColumns = []string{"CourseID", "Title", "LecturerID", "Lecturer", "ClassSize", "Description", "Credits", "Status"}
+
+//checkOutput validates the output settings.
+func checkOutput() error {
+	valid := false
+	for _, f := range outputFormats {
+		valid = valid || f == outputFormat
+	}
+	if !valid {
+		return fmt.Errorf("%w: output format %q must be one of %s", errInvalidInput, outputFormat, strings.Join(outputFormats, ", "))
+	}
+	if _, ok := courseKeys[sortBy]; sortBy != "" && !ok {
+		return fmt.Errorf("%w: sort field %q must be one of id, title, lecturer, size", errInvalidInput, sortBy)
+	}
+	return nil
+}
+
+//sortCourses orders courses by the --sort field, keeping the server order for equal keys.
+func sortCourses(courses []client.Course) {
+	less, ok := courseKeys[sortBy]
+	if !ok {
+		return
+	}
+	sort.SliceStable(courses, func(i, j int) bool {
+		if sortDesc {
+			return less(courses[j], courses[i])
+		}
+		return less(courses[i], courses[j])
+	})
+}
+
+//writeCourses renders a list of courses in the selected output format.
+func writeCourses(w io.Writer, courses []client.Course) error {
+	if courses == nil {
+		courses = []client.Course{} //encode an empty list as [] rather than null
+	}
+	sortCourses(courses)
+
+	switch outputFormat {
+	case "json":
+		return writeJSON(w, courses)
+	case "yaml":
+		return yaml.NewEncoder(w).Encode(courses)
+	case "csv":
+		return writeCSV(w, courses)
+	}
+	return writeTable(w, courses)
+}
+
+//writeCourse renders a single course in the selected output format. Tables show it as a one row table.
+func writeCourse(w io.Writer, course client.Course) error {
+	switch outputFormat {
+	case "json":
+		return writeJSON(w, course)
+	case "yaml":
+		return yaml.NewEncoder(w).Encode(course)
+	case "csv":
+		return writeCSV(w, []client.Course{course})
+	}
+	return writeTable(w, []client.Course{course})
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func writeCSV(w io.Writer, courses []client.Course) error {
+	out := csv.NewWriter(w)
+	out.Write(csvColumns)
+	for _, v := range courses {
+		out.Write([]string{v.CourseID, v.Title, v.LecturerID, v.Lecturer, strconv.Itoa(v.ClassSize), v.Description, strconv.Itoa(v.Credits), v.Status})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+//writeTable prints the courses as aligned columns.
+func writeTable(w io.Writer, courses []client.Course) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "COURSE ID\tTITLE\tLECTURER\tCLASS SIZE\tCREDITS\tSTATUS")
+	for _, v := range courses {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%d\t%d\t%s\n", v.CourseID, v.Title, v.Lecturer, v.ClassSize, v.Credits, v.Status)
+	}
+	return table.Flush()
+}
+
+//enrollmentColumns are the CSV columns of enrolments.
+var enrollmentColumns = []string{"CourseID", "StudentID", "Status", "Position", "EnrolledAt"}
+
+//writeEnrollments renders the enrolments and waitlist of a course in the selected output format.
+//Tables and CSV list the enrolled students first, then the waitlist in order of position.
+func writeEnrollments(w io.Writer, list client.CourseEnrollments) error {
+	all := append(append([]client.Enrollment{}, list.Enrollments...), list.Waitlist...)
+	switch outputFormat {
+	case "json":
+		return writeJSON(w, list)
+	case "yaml":
+		return yaml.NewEncoder(w).Encode(list)
+	case "csv":
+		return writeEnrollmentsCSV(w, all)
+	}
+	fmt.Fprintf(w, "%s: %d of %d seats taken, %d left, %d waitlisted\n\n",
+		list.CourseID, list.Enrolled, list.ClassSize, list.SeatsLeft, list.Waitlisted)
+	return writeEnrollmentsTable(w, all)
+}
+
+//writeEnrollment renders one enrolment in the selected output format.
+func writeEnrollment(w io.Writer, enrollment client.Enrollment) error {
+	switch outputFormat {
+	case "json":
+		return writeJSON(w, enrollment)
+	case "yaml":
+		return yaml.NewEncoder(w).Encode(enrollment)
+	case "csv":
+		return writeEnrollmentsCSV(w, []client.Enrollment{enrollment})
+	}
+	return writeEnrollmentsTable(w, []client.Enrollment{enrollment})
+}
+
+func writeEnrollmentsCSV(w io.Writer, enrollments []client.Enrollment) error {
+	out := csv.NewWriter(w)
+	out.Write(enrollmentColumns)
+	for _, v := range enrollments {
+		out.Write([]string{v.CourseID, v.StudentID, v.Status, positionText(v), v.EnrolledAt.Format(time.RFC3339)})
+	}
+	out.Flush()
+	return out.Error()
+}
+
+//writeEnrollmentsTable prints the enrolments as aligned columns, in local time.
+func writeEnrollmentsTable(w io.Writer, enrollments []client.Enrollment) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "COURSE ID\tSTUDENT ID\tSTATUS\tPOSITION\tSINCE")
+	for _, v := range enrollments {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\n", v.CourseID, v.StudentID, v.Status, positionText(v), v.EnrolledAt.Local().Format("02-01-2006 15:04"))
+	}
+	return table.Flush()
+}
+
+//positionText is the waitlist position of an enrolment, empty for a student holding a seat.
+func positionText(enrollment client.Enrollment) string {
+	if enrollment.Position == 0 {
+		return ""
+	}
+	return strconv.Itoa(enrollment.Position)
+}
+
+//lecturerColumns are the CSV columns of lecturers.
+var lecturerColumns = []string{"LecturerID", "Name", "Email", "Department"}
+
+//writeLecturers renders a list of lecturers in the selected output format.
+func writeLecturers(w io.Writer, lecturers []client.Lecturer) error {
+	if lecturers == nil {
+		lecturers = []client.Lecturer{}
+	}
+	switch outputFormat {
+	case "json":
+		return writeJSON(w, lecturers)
+	case "yaml":
+		return yaml.NewEncoder(w).Encode(lecturers)
+	case "csv":
+		out := csv.NewWriter(w)
+		out.Write(lecturerColumns)
+		for _, v := range lecturers {
+			out.Write([]string{v.LecturerID, v.Name, v.Email, v.Department})
+		}
+		out.Flush()
+		return out.Error()
+	}
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "LECTURER ID\tNAME\tEMAIL\tDEPARTMENT")
+	for _, v := range lecturers {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", v.LecturerID, v.Name, v.Email, v.Department)
+	}
+	return table.Flush()
+}
+
+//departmentColumns are the CSV columns of departments. Prefixes are joined with spaces.
+var departmentColumns = []string{"DepartmentID", "Name", "Prefixes"}
+
+//writeDepartments renders a list of departments in the selected output format.
+func writeDepartments(w io.Writer, departments []client.Department) error {
+	if departments == nil {
+		departments = []client.Department{}
+	}
+	switch outputFormat {
+	case "json":
+		return writeJSON(w, departments)
+	case "yaml":
+		return yaml.NewEncoder(w).Encode(departments)
+	case "csv":
+		out := csv.NewWriter(w)
+		out.Write(departmentColumns)
+		for _, v := range departments {
+			out.Write([]string{v.DepartmentID, v.Name, strings.Join(v.Prefixes, " ")})
+		}
+		out.Flush()
+		return out.Error()
+	}
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "DEPARTMENT ID\tNAME\tPREFIXES")
+	for _, v := range departments {
+		fmt.Fprintf(table, "%s\t%s\t%s\n", v.DepartmentID, v.Name, strings.Join(v.Prefixes, ", "))
+	}
+	return table.Flush()
+}
+
+//departmentStatsColumns are the CSV columns of department statistics.
+var departmentStatsColumns = []string{"DepartmentID", "Courses", "Lecturers", "Seats", "Enrolled", "Waitlisted"}
+
+//writeDepartmentStats renders the statistics of a department in the selected output format.
+func writeDepartmentStats(w io.Writer, stats client.DepartmentStats) error {
+	switch outputFormat {
+	case "json":
+		return writeJSON(w, stats)
+	case "yaml":
+		return yaml.NewEncoder(w).Encode(stats)
+	case "csv":
+		out := csv.NewWriter(w)
+		out.Write(departmentStatsColumns)
+		out.Write([]string{stats.DepartmentID, strconv.Itoa(stats.Courses), strconv.Itoa(stats.Lecturers),
+			strconv.Itoa(stats.Seats), strconv.Itoa(stats.Enrolled), strconv.Itoa(stats.Waitlisted)})
+		out.Flush()
+		return out.Error()
+	}
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "DEPARTMENT ID\tCOURSES\tLECTURERS\tSEATS\tENROLLED\tWAITLISTED")
+	fmt.Fprintf(table, "%s\t%d\t%d\t%d\t%d\t%d\n", stats.DepartmentID, stats.Courses, stats.Lecturers, stats.Seats, stats.Enrolled, stats.Waitlisted)
+	return table.Flush()
+}
+
+//semesterColumns are the CSV columns of semesters.
+var semesterColumns = []string{"SemesterID", "Name", "StartDate", "EndDate"}
+
+//writeSemesters renders a list of semesters in the selected output format.
+func writeSemesters(w io.Writer, semesters []client.Semester) error {
+	if semesters == nil {
+		semesters = []client.Semester{}
+	}
+	switch outputFormat {
+	case "json":
+		return writeJSON(w, semesters)
+	case "yaml":
+		return yaml.NewEncoder(w).Encode(semesters)
+	case "csv":
+		out := csv.NewWriter(w)
+		out.Write(semesterColumns)
+		for _, v := range semesters {
+			out.Write([]string{v.SemesterID, v.Name, v.StartDate, v.EndDate})
+		}
+		out.Flush()
+		return out.Error()
+	}
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "SEMESTER ID\tNAME\tSTART\tEND")
+	for _, v := range semesters {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", v.SemesterID, v.Name, v.StartDate, v.EndDate)
+	}
+	return table.Flush()
+}
+
+//offeringColumns are the CSV columns of course offerings.
+var offeringColumns = []string{"SemesterID", "CourseID", "LecturerID", "Lecturer", "ClassSize"}
+
+//writeOfferings renders a list of course offerings in the selected output format.
+func writeOfferings(w io.Writer, offerings []client.CourseOffering) error {
+	if offerings == nil {
+		offerings = []client.CourseOffering{}
+	}
+	switch outputFormat {
+	case "json":
+		return writeJSON(w, offerings)
+	case "yaml":
+		return yaml.NewEncoder(w).Encode(offerings)
+	case "csv":
+		out := csv.NewWriter(w)
+		out.Write(offeringColumns)
+		for _, v := range offerings {
+			out.Write([]string{v.SemesterID, v.CourseID, v.LecturerID, v.Lecturer, strconv.Itoa(v.ClassSize)})
+		}
+		out.Flush()
+		return out.Error()
+	}
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "SEMESTER ID\tCOURSE ID\tLECTURER\tCLASS SIZE")
+	for _, v := range offerings {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%d\n", v.SemesterID, v.CourseID, v.Lecturer, v.ClassSize)
+	}
+	return table.Flush()
+}
+
+//roomColumns are the CSV columns of rooms.
+var roomColumns = []string{"RoomID", "Name", "Capacity"}
+
+//writeRooms renders a list of rooms in the selected output format.
+func writeRooms(w io.Writer, rooms []client.Room) error {
+	if rooms == nil {
+		rooms = []client.Room{}
+	}
+	switch outputFormat {
+	case "json":
+		return writeJSON(w, rooms)
+	case "yaml":
+		return yaml.NewEncoder(w).Encode(rooms)
+	case "csv":
+		out := csv.NewWriter(w)
+		out.Write(roomColumns)
+		for _, v := range rooms {
+			out.Write([]string{v.RoomID, v.Name, strconv.Itoa(v.Capacity)})
+		}
+		out.Flush()
+		return out.Error()
+	}
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "ROOM ID\tNAME\tCAPACITY")
+	for _, v := range rooms {
+		fmt.Fprintf(table, "%s\t%s\t%d\n", v.RoomID, v.Name, v.Capacity)
+	}
+	return table.Flush()
+}
+
+//sessionColumns are the CSV columns of timetable sessions.
+var sessionColumns = []string{"SessionID", "CourseID", "Day", "StartTime", "EndTime", "RoomID"}
+
+//writeSessions renders a timetable in the selected output format, in the order the API returned it.
+func writeSessions(w io.Writer, sessions []client.Session) error {
+	if sessions == nil {
+		sessions = []client.Session{}
+	}
+	switch outputFormat {
+	case "json":
+		return writeJSON(w, sessions)
+	case "yaml":
+		return yaml.NewEncoder(w).Encode(sessions)
+	case "csv":
+		out := csv.NewWriter(w)
+		out.Write(sessionColumns)
+		for _, v := range sessions {
+			out.Write([]string{strconv.Itoa(v.SessionID), v.CourseID, v.Day, v.StartTime, v.EndTime, v.RoomID})
+		}
+		out.Flush()
+		return out.Error()
+	}
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "DAY\tTIME\tCOURSE ID\tROOM\tSESSION")
+	for _, v := range sessions {
+		fmt.Fprintf(table, "%s\t%s-%s\t%s\t%s\t%d\n", v.Day, v.StartTime, v.EndTime, v.CourseID, v.RoomID, v.SessionID)
+	}
+	return table.Flush()
+}
+
+//tagColumns are the CSV columns of tag counts.
+var tagColumns = []string{"Tag", "Count"}
+
+//writeTags renders a list of tags and their course counts in the selected output format.
+func writeTags(w io.Writer, tags []client.TagCount) error {
+	if tags == nil {
+		tags = []client.TagCount{}
+	}
+	switch outputFormat {
+	case "json":
+		return writeJSON(w, tags)
+	case "yaml":
+		return yaml.NewEncoder(w).Encode(tags)
+	case "csv":
+		out := csv.NewWriter(w)
+		out.Write(tagColumns)
+		for _, v := range tags {
+			out.Write([]string{v.Tag, strconv.Itoa(v.Count)})
+		}
+		out.Flush()
+		return out.Error()
+	}
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "TAG\tCOURSES")
+	for _, v := range tags {
+		fmt.Fprintf(table, "%s\t%d\n", v.Tag, v.Count)
+	}
+	return table.Flush()
+}
+
+//writeCourseTags renders the tags of a course in the selected output format, one per line in a table.
+func writeCourseTags(w io.Writer, tags client.CourseTags) error {
+	if tags.Tags == nil {
+		tags.Tags = []string{}
+	}
+	switch outputFormat {
+	case "json":
+		return writeJSON(w, tags)
+	case "yaml":
+		return yaml.NewEncoder(w).Encode(tags)
+	case "csv":
+		out := csv.NewWriter(w)
+		out.Write([]string{"CourseID", "Tag"})
+		for _, tag := range tags.Tags {
+			out.Write([]string{tags.CourseID, tag})
+		}
+		out.Flush()
+		return out.Error()
+	}
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "COURSE ID\tTAG")
+	for _, tag := range tags.Tags {
+		fmt.Fprintf(table, "%s\t%s\n", tags.CourseID, tag)
+	}
+	return table.Flush()
+}
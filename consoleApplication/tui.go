@@ -0,0 +1,599 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/term"
+
+	"goMicroService1Assignment/RESTAPI/client"
+)
+
+//ANSI escape sequences used to draw the terminal UI.
+const (
+	escClear       = "\x1b[H\x1b[2J"
+	escAltScreen   = "\x1b[?1049h\x1b[?25l" //switch to the alternate screen and hide the cursor
+	escMainScreen  = "\x1b[?25h\x1b[?1049l"
+	escReverse     = "\x1b[7m"
+	escBold        = "\x1b[1m"
+	escRed         = "\x1b[31m"
+	escReset       = "\x1b[0m"
+	tuiMinWidth    = 60
+	tuiMinHeight   = 12
+	tuiListMinSize = 30
+)
+
+//tuiMode is what the keyboard currently drives.
+type tuiMode int
+
+const (
+	modeList    tuiMode = iota //moving through the course list
+	modeFilter                 //typing the filter
+	modeForm                   //adding or editing a course
+	modeConfirm                //confirming a deletion
+)
+
+//Fields of the course form, in the order they are shown.
+const (
+	fieldCourseID = iota
+	fieldTitle
+	fieldLecturer
+	fieldClassSize
+	formFields
+)
+
+var fieldLabels = [formFields]string{"Course ID", "Title", "Lecturer", "Class Size"}
+
+//courseForm is the add or edit form with its inline validation errors.
+type courseForm struct {
+	editing  bool //editing an existing course rather than adding one; the course ID is then read-only
+	original client.Course
+	values   [formFields]string
+	errs     [formFields]string
+	focus    int
+}
+
+//tui is the state of the full screen course browser. It is driven by handle and drawn by render,
+//so everything but runTUI works without a terminal.
+type tui struct {
+	courses []client.Course
+	visible []client.Course //courses matching the filter
+	cursor  int             //index in visible of the selected course
+	offset  int             //index in visible of the first row shown
+	mode    tuiMode
+	filter  string
+	form    courseForm
+	status  string
+	width   int
+	height  int
+}
+
+//runTUI takes over the terminal until the user quits.
+func runTUI() error {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return fmt.Errorf("%w: the tui command needs an interactive terminal", errInvalidInput)
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer term.Restore(fd, state)
+	os.Stdout.WriteString(escAltScreen)
+	defer os.Stdout.WriteString(escMainScreen)
+
+	t := &tui{}
+	t.reload()
+	in := bufio.NewReader(os.Stdin)
+	for {
+		t.width, t.height, err = term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			t.width, t.height = 80, 24
+		}
+		os.Stdout.WriteString(t.render())
+
+		k, err := readKey(in)
+		if err != nil {
+			return err
+		}
+		if !t.handle(k) {
+			return nil
+		}
+	}
+}
+
+//key is one key press: a printable rune, or the name of a special key.
+type key struct {
+	r    rune
+	name string
+}
+
+//escapeKeys names the escape sequences of the special keys, without their leading ESC.
+var escapeKeys = map[string]string{
+	"[A": "up", "[B": "down", "[C": "right", "[D": "left",
+	"OA": "up", "OB": "down", "OC": "right", "OD": "left",
+	"[5~": "pgup", "[6~": "pgdn", "[3~": "delete", "[Z": "backtab",
+	"[H": "home", "[1~": "home", "OH": "home",
+	"[F": "end", "[4~": "end", "OF": "end",
+}
+
+//readKey reads one key press from a terminal in raw mode. A lone ESC is the escape key, an ESC followed
+//by more input already read is the start of a sequence sent by a special key.
+func readKey(in *bufio.Reader) (key, error) {
+	r, _, err := in.ReadRune()
+	if err != nil {
+		return key{}, err
+	}
+	switch r {
+	case '\r', '\n':
+		return key{name: "enter"}, nil
+	case '\t':
+		return key{name: "tab"}, nil
+	case 127, '\b':
+		return key{name: "backspace"}, nil
+	case 3:
+		return key{name: "ctrl-c"}, nil
+	case 27:
+		if in.Buffered() == 0 {
+			return key{name: "esc"}, nil
+		}
+		seq := ""
+		for in.Buffered() > 0 {
+			b, _ := in.ReadByte()
+			seq += string(b)
+			//a sequence ends with its final byte, e.g. the A of ESC [ A or the ~ of ESC [ 5 ~
+			if len(seq) > 1 && b >= 0x40 && b <= 0x7e {
+				break
+			}
+		}
+		return key{name: escapeKeys[seq]}, nil
+	}
+	if r < ' ' {
+		return key{}, nil //other control keys are ignored
+	}
+	return key{r: r}, nil
+}
+
+//reload fetches the course list again, keeping the selected course if it still exists.
+func (t *tui) reload() {
+	selected := t.selectedID()
+	courses, err := api.ListCourses(context.Background())
+	if err != nil {
+		t.status = describeError(err)
+		log.Error("The HTTP request failed with error: ", err, " --tui")
+		return
+	}
+	t.courses = courses
+	t.applyFilter(selected)
+	t.status = fmt.Sprintf("Loaded %d courses.", len(courses))
+}
+
+//selectedID returns the ID of the selected course, or "" when the list is empty.
+func (t *tui) selectedID() string {
+	if t.cursor < len(t.visible) {
+		return t.visible[t.cursor].CourseID
+	}
+	return ""
+}
+
+//applyFilter keeps the courses whose ID, title or lecturer contains the filter, ignoring case,
+//and selects the course with the given ID if it is among them.
+func (t *tui) applyFilter(selectID string) {
+	needle := strings.ToLower(t.filter)
+	t.visible = t.visible[:0]
+	for _, c := range t.courses {
+		if strings.Contains(strings.ToLower(c.CourseID+"\x00"+c.Title+"\x00"+c.Lecturer), needle) {
+			t.visible = append(t.visible, c)
+		}
+	}
+	for i, c := range t.visible {
+		if c.CourseID == selectID {
+			t.cursor = i
+		}
+	}
+	t.move(0)
+}
+
+//move moves the selection by delta rows, staying within the list.
+func (t *tui) move(delta int) {
+	t.cursor += delta
+	if t.cursor >= len(t.visible) {
+		t.cursor = len(t.visible) - 1
+	}
+	if t.cursor < 0 {
+		t.cursor = 0
+	}
+}
+
+//listHeight is the number of course rows that fit between the title bar and the two bottom lines.
+func (t *tui) listHeight() int {
+	if h := t.height - 3; h > 1 {
+		return h - 1 //less the column header
+	}
+	return 1
+}
+
+//handle applies one key press and reports whether the UI should keep running.
+func (t *tui) handle(k key) bool {
+	if k.name == "ctrl-c" {
+		return false
+	}
+	switch t.mode {
+	case modeFilter:
+		t.handleFilter(k)
+	case modeForm:
+		t.handleForm(k)
+	case modeConfirm:
+		t.handleConfirm(k)
+	default:
+		return t.handleList(k)
+	}
+	return true
+}
+
+func (t *tui) handleList(k key) bool {
+	page := t.listHeight()
+	switch {
+	case k.r == 'q':
+		return false
+	case k.name == "up" || k.r == 'k':
+		t.move(-1)
+	case k.name == "down" || k.r == 'j':
+		t.move(1)
+	case k.name == "pgup":
+		t.move(-page)
+	case k.name == "pgdn":
+		t.move(page)
+	case k.name == "home" || k.r == 'g':
+		t.move(-len(t.visible))
+	case k.name == "end" || k.r == 'G':
+		t.move(len(t.visible))
+	case k.r == '/':
+		t.mode = modeFilter
+		t.status = "Type to filter by course ID, title or lecturer. Enter keeps the filter, Esc clears it."
+	case k.name == "esc" && t.filter != "":
+		t.filter = ""
+		t.applyFilter(t.selectedID())
+		t.status = ""
+	case k.r == 'r':
+		t.reload()
+	case k.r == 'a':
+		t.form = courseForm{}
+		t.mode = modeForm
+		t.status = "Adding a course. Tab moves between fields, Enter saves, Esc cancels."
+	case k.name == "enter" || k.r == 'e':
+		if len(t.visible) > 0 {
+			c := t.visible[t.cursor]
+			t.form = courseForm{editing: true, original: c, focus: fieldTitle}
+			t.form.values = [formFields]string{c.CourseID, c.Title, c.Lecturer, fmt.Sprint(c.ClassSize)}
+			t.mode = modeForm
+			t.status = "Editing " + c.CourseID + ". Tab moves between fields, Enter saves, Esc cancels."
+		}
+	case k.r == 'd' || k.name == "delete":
+		if len(t.visible) > 0 {
+			t.mode = modeConfirm
+			t.status = ""
+		}
+	}
+	return true
+}
+
+func (t *tui) handleFilter(k key) {
+	selected := t.selectedID()
+	switch {
+	case k.name == "enter":
+		t.mode = modeList
+		t.status = fmt.Sprintf("%d of %d courses match %q.", len(t.visible), len(t.courses), t.filter)
+		return
+	case k.name == "esc":
+		t.filter = ""
+		t.mode = modeList
+		t.status = ""
+	case k.name == "backspace":
+		t.filter = dropLastRune(t.filter)
+	case k.r != 0:
+		t.filter += string(k.r)
+	default:
+		return
+	}
+	t.applyFilter(selected)
+}
+
+func (t *tui) handleForm(k key) {
+	f := &t.form
+	first := fieldCourseID
+	if f.editing {
+		first = fieldTitle
+	}
+	switch {
+	case k.name == "esc":
+		t.mode = modeList
+		t.status = "Cancelled."
+	case k.name == "tab" || k.name == "down":
+		f.focus++
+		if f.focus == formFields {
+			f.focus = first
+		}
+	case k.name == "backtab" || k.name == "up":
+		f.focus--
+		if f.focus < first {
+			f.focus = formFields - 1
+		}
+	case k.name == "backspace":
+		f.values[f.focus] = dropLastRune(f.values[f.focus])
+		f.validate(f.focus)
+	case k.r != 0:
+		f.values[f.focus] += string(k.r)
+		f.validate(f.focus)
+	case k.name == "enter":
+		t.submitForm()
+	}
+}
+
+//validate checks one field of the form with the same rules as the menu, recording the error to show beside it.
+func (f *courseForm) validate(field int) {
+	var err error
+	switch field {
+	case fieldCourseID:
+		_, err = checkCourseID(f.values[field])
+	case fieldTitle:
+		_, err = checkTitle(f.values[field])
+	case fieldLecturer:
+		_, err = checkLecturer(f.values[field])
+	case fieldClassSize:
+		_, err = checkClassSize(f.values[field])
+	}
+	f.errs[field] = ""
+	if err != nil {
+		f.errs[field] = strings.TrimPrefix(err.Error(), errInvalidInput.Error()+": ")
+	}
+}
+
+//submitForm validates every field and then adds the course, or sends the changed fields of an edited one.
+func (t *tui) submitForm() {
+	f := &t.form
+	invalid := -1
+	for field := formFields - 1; field >= fieldCourseID; field-- {
+		f.validate(field)
+		if f.errs[field] != "" {
+			invalid = field
+		}
+	}
+	if invalid >= 0 {
+		f.focus = invalid
+		t.status = "Please correct the fields marked in red."
+		return
+	}
+
+	courseID, _ := checkCourseID(f.values[fieldCourseID])
+	title, _ := checkTitle(f.values[fieldTitle])
+	lecturer, _ := checkLecturer(f.values[fieldLecturer])
+	size, _ := checkClassSize(f.values[fieldClassSize])
+
+	var err error
+	if f.editing {
+		var patch client.CoursePatch
+		if title != f.original.Title {
+			patch.Title = &title
+		}
+		if lecturer != f.original.Lecturer {
+			patch.Lecturer = &lecturer
+		}
+		if size != f.original.ClassSize {
+			patch.ClassSize = &size
+		}
+		if patch == (client.CoursePatch{}) {
+			t.mode = modeList
+			t.status = "Nothing to update."
+			return
+		}
+		_, err = api.PatchCourse(context.Background(), courseID, patch)
+	} else {
+		err = api.CreateCourse(context.Background(), client.Course{CourseID: courseID, Title: title, Lecturer: lecturer, ClassSize: size})
+	}
+	if err != nil {
+		t.status = describeError(err)
+		log.Error("The HTTP request failed with error: ", err, " --tui")
+		return
+	}
+
+	t.mode = modeList
+	t.reload()
+	t.applyFilter(courseID)
+	if f.editing {
+		t.status = "Course updated: " + courseID
+	} else {
+		t.status = "Course added: " + courseID
+	}
+}
+
+func (t *tui) handleConfirm(k key) {
+	if k.r != 'y' && k.r != 'Y' {
+		t.mode = modeList
+		t.status = "Deletion cancelled."
+		return
+	}
+	courseID := t.selectedID()
+	t.mode = modeList
+	if err := api.DeleteCourse(context.Background(), courseID); err != nil {
+		t.status = describeError(err)
+		log.Error("The HTTP request failed with error: ", err, " --tui")
+		return
+	}
+	t.reload()
+	t.status = "Course deleted: " + courseID
+}
+
+//render draws the whole screen: title bar, course list, detail or form pane, status line and key help.
+func (t *tui) render() string {
+	w, h := t.width, t.height
+	if w < tuiMinWidth || h < tuiMinHeight {
+		return escClear + fmt.Sprintf("Please enlarge the terminal to at least %dx%d.", tuiMinWidth, tuiMinHeight)
+	}
+
+	//keep the selection on screen
+	rows := t.listHeight()
+	if t.cursor < t.offset {
+		t.offset = t.cursor
+	}
+	if t.cursor >= t.offset+rows {
+		t.offset = t.cursor - rows + 1
+	}
+
+	title := " University Course Listing"
+	if t.filter != "" || t.mode == modeFilter {
+		title += fmt.Sprintf("   filter: %s", t.filter)
+		if t.mode == modeFilter {
+			title += "_"
+		}
+	}
+	title += fmt.Sprintf("   %d/%d courses", len(t.visible), len(t.courses))
+	lines := []string{escReverse + pad(title, w) + escReset}
+
+	listWidth := w / 2
+	if listWidth < tuiListMinSize {
+		listWidth = tuiListMinSize
+	}
+	pane := t.detailPane()
+	if t.mode == modeForm {
+		pane = t.formPane()
+	}
+	for i := 0; i <= rows; i++ {
+		var left string
+		switch {
+		case i == 0:
+			left = escBold + pad(" COURSE ID  TITLE", listWidth) + escReset
+		case t.offset+i-1 < len(t.visible):
+			c := t.visible[t.offset+i-1]
+			left = pad(fmt.Sprintf(" %-9s  %s", c.CourseID, c.Title), listWidth)
+			if t.offset+i-1 == t.cursor {
+				left = escReverse + left + escReset
+			}
+		default:
+			left = pad("", listWidth)
+		}
+		right := ""
+		if i < len(pane) {
+			right = pane[i]
+		}
+		lines = append(lines, left+"│ "+pad(right, w-listWidth-2))
+	}
+
+	if t.mode == modeConfirm {
+		t.overlayConfirm(lines)
+	}
+
+	lines = append(lines, pad(" "+t.status, w), escReverse+pad(" "+t.help(), w)+escReset)
+	return escClear + strings.Join(lines, "\r\n")
+}
+
+//detailPane lists the fields of the selected course.
+func (t *tui) detailPane() []string {
+	if len(t.visible) == 0 {
+		if t.filter != "" {
+			return []string{"", "No course matches the filter."}
+		}
+		return []string{"", "No courses. Press a to add one."}
+	}
+	c := t.visible[t.cursor]
+	lecturer := c.Lecturer
+	if c.LecturerID != "" {
+		lecturer += " (" + c.LecturerID + ")"
+	}
+	return []string{
+		escBold + "Course details" + escReset,
+		"",
+		"Course ID:  " + c.CourseID,
+		"Title:      " + c.Title,
+		"Lecturer:   " + lecturer,
+		fmt.Sprintf("Class Size: %d", c.ClassSize),
+		fmt.Sprintf("Credits:    %d", c.Credits),
+		"Status:     " + c.Status,
+	}
+}
+
+//formPane draws the form, marking the focused field and showing each field's validation error below it.
+func (t *tui) formPane() []string {
+	f := t.form
+	heading := "Add course"
+	if f.editing {
+		heading = "Edit course " + f.original.CourseID
+	}
+	pane := []string{escBold + heading + escReset, ""}
+	for field := fieldCourseID; field < formFields; field++ {
+		marker, value := "  ", f.values[field]
+		if field == f.focus {
+			marker, value = "> ", value+"_"
+		}
+		if field == fieldCourseID && f.editing {
+			value += " (cannot be changed)"
+		}
+		pane = append(pane, fmt.Sprintf("%s%-11s %s", marker, fieldLabels[field]+":", value))
+		if f.errs[field] != "" {
+			pane = append(pane, "  "+escRed+f.errs[field]+escReset)
+		}
+	}
+	return pane
+}
+
+//overlayConfirm draws the delete confirmation dialog over the middle of the screen.
+func (t *tui) overlayConfirm(lines []string) {
+	question := " Delete course " + t.selectedID() + "? (y/n) "
+	width := utf8.RuneCountInString(question)
+	box := []string{
+		"┌" + strings.Repeat("─", width) + "┐",
+		"│" + question + "│",
+		"└" + strings.Repeat("─", width) + "┘",
+	}
+	top := len(lines)/2 - 1
+	indent := strings.Repeat(" ", (t.width-width-2)/2)
+	for i, row := range box {
+		lines[top+i] = pad(indent+escBold+row+escReset, t.width)
+	}
+}
+
+//help lists the keys of the current mode.
+func (t *tui) help() string {
+	switch t.mode {
+	case modeFilter:
+		return "type to filter  Enter keep  Esc clear"
+	case modeForm:
+		return "Tab/↓ next field  Shift-Tab/↑ previous  Enter save  Esc cancel"
+	case modeConfirm:
+		return "y delete  n cancel"
+	}
+	return "↑/↓ move  PgUp/PgDn page  / filter  a add  e edit  d delete  r reload  q quit"
+}
+
+//pad cuts or pads s with spaces to take exactly width columns. Escape sequences in s take no room.
+func pad(s string, width int) string {
+	var out strings.Builder
+	n, escape := 0, false
+	for _, r := range s {
+		switch {
+		case r == 0x1b:
+			escape = true
+		case escape:
+			escape = r < 0x40 || r > 0x7e || r == '['
+		case n == width:
+			return out.String() + escReset //cut, closing any style left open
+		default:
+			n++
+		}
+		out.WriteRune(r)
+	}
+	return out.String() + strings.Repeat(" ", width-n)
+}
+
+func dropLastRune(s string) string {
+	if s == "" {
+		return s
+	}
+	_, size := utf8.DecodeLastRuneInString(s)
+	return s[:len(s)-size]
+}